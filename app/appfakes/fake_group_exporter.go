@@ -3,16 +3,17 @@ package appfakes
 
 import (
 	"ctRestClient/app"
+	"ctRestClient/config"
 	"ctRestClient/rest"
 	"encoding/json"
 	"sync"
 )
 
 type FakeGroupExporter struct {
-	ExportGroupMembersStub        func(string, rest.GroupsEndpoint, rest.DynamicGroupsEndpoint, rest.PersonsEndpoint) ([]json.RawMessage, error)
+	ExportGroupMembersStub        func(config.Group, rest.GroupsEndpoint, rest.DynamicGroupsEndpoint, rest.PersonsEndpoint) ([]json.RawMessage, error)
 	exportGroupMembersMutex       sync.RWMutex
 	exportGroupMembersArgsForCall []struct {
-		arg1 string
+		arg1 config.Group
 		arg2 rest.GroupsEndpoint
 		arg3 rest.DynamicGroupsEndpoint
 		arg4 rest.PersonsEndpoint
@@ -29,11 +30,11 @@ type FakeGroupExporter struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeGroupExporter) ExportGroupMembers(arg1 string, arg2 rest.GroupsEndpoint, arg3 rest.DynamicGroupsEndpoint, arg4 rest.PersonsEndpoint) ([]json.RawMessage, error) {
+func (fake *FakeGroupExporter) ExportGroupMembers(arg1 config.Group, arg2 rest.GroupsEndpoint, arg3 rest.DynamicGroupsEndpoint, arg4 rest.PersonsEndpoint) ([]json.RawMessage, error) {
 	fake.exportGroupMembersMutex.Lock()
 	ret, specificReturn := fake.exportGroupMembersReturnsOnCall[len(fake.exportGroupMembersArgsForCall)]
 	fake.exportGroupMembersArgsForCall = append(fake.exportGroupMembersArgsForCall, struct {
-		arg1 string
+		arg1 config.Group
 		arg2 rest.GroupsEndpoint
 		arg3 rest.DynamicGroupsEndpoint
 		arg4 rest.PersonsEndpoint
@@ -57,13 +58,13 @@ func (fake *FakeGroupExporter) ExportGroupMembersCallCount() int {
 	return len(fake.exportGroupMembersArgsForCall)
 }
 
-func (fake *FakeGroupExporter) ExportGroupMembersCalls(stub func(string, rest.GroupsEndpoint, rest.DynamicGroupsEndpoint, rest.PersonsEndpoint) ([]json.RawMessage, error)) {
+func (fake *FakeGroupExporter) ExportGroupMembersCalls(stub func(config.Group, rest.GroupsEndpoint, rest.DynamicGroupsEndpoint, rest.PersonsEndpoint) ([]json.RawMessage, error)) {
 	fake.exportGroupMembersMutex.Lock()
 	defer fake.exportGroupMembersMutex.Unlock()
 	fake.ExportGroupMembersStub = stub
 }
 
-func (fake *FakeGroupExporter) ExportGroupMembersArgsForCall(i int) (string, rest.GroupsEndpoint, rest.DynamicGroupsEndpoint, rest.PersonsEndpoint) {
+func (fake *FakeGroupExporter) ExportGroupMembersArgsForCall(i int) (config.Group, rest.GroupsEndpoint, rest.DynamicGroupsEndpoint, rest.PersonsEndpoint) {
 	fake.exportGroupMembersMutex.RLock()
 	defer fake.exportGroupMembersMutex.RUnlock()
 	argsForCall := fake.exportGroupMembersArgsForCall[i]
@@ -0,0 +1,125 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package appfakes
+
+import (
+	"ctRestClient/app"
+	"ctRestClient/rest"
+	"sync"
+)
+
+type FakeGroupLister struct {
+	ListGroupsStub        func([]string, rest.GroupsEndpoint, rest.DynamicGroupsEndpoint) ([]app.GroupInfo, error)
+	listGroupsMutex       sync.RWMutex
+	listGroupsArgsForCall []struct {
+		arg1 []string
+		arg2 rest.GroupsEndpoint
+		arg3 rest.DynamicGroupsEndpoint
+	}
+	listGroupsReturns struct {
+		result1 []app.GroupInfo
+		result2 error
+	}
+	listGroupsReturnsOnCall map[int]struct {
+		result1 []app.GroupInfo
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeGroupLister) ListGroups(arg1 []string, arg2 rest.GroupsEndpoint, arg3 rest.DynamicGroupsEndpoint) ([]app.GroupInfo, error) {
+	var arg1Copy []string
+	if arg1 != nil {
+		arg1Copy = make([]string, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.listGroupsMutex.Lock()
+	ret, specificReturn := fake.listGroupsReturnsOnCall[len(fake.listGroupsArgsForCall)]
+	fake.listGroupsArgsForCall = append(fake.listGroupsArgsForCall, struct {
+		arg1 []string
+		arg2 rest.GroupsEndpoint
+		arg3 rest.DynamicGroupsEndpoint
+	}{arg1Copy, arg2, arg3})
+	stub := fake.ListGroupsStub
+	fakeReturns := fake.listGroupsReturns
+	fake.recordInvocation("ListGroups", []interface{}{arg1Copy, arg2, arg3})
+	fake.listGroupsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGroupLister) ListGroupsCallCount() int {
+	fake.listGroupsMutex.RLock()
+	defer fake.listGroupsMutex.RUnlock()
+	return len(fake.listGroupsArgsForCall)
+}
+
+func (fake *FakeGroupLister) ListGroupsCalls(stub func([]string, rest.GroupsEndpoint, rest.DynamicGroupsEndpoint) ([]app.GroupInfo, error)) {
+	fake.listGroupsMutex.Lock()
+	defer fake.listGroupsMutex.Unlock()
+	fake.ListGroupsStub = stub
+}
+
+func (fake *FakeGroupLister) ListGroupsArgsForCall(i int) ([]string, rest.GroupsEndpoint, rest.DynamicGroupsEndpoint) {
+	fake.listGroupsMutex.RLock()
+	defer fake.listGroupsMutex.RUnlock()
+	argsForCall := fake.listGroupsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeGroupLister) ListGroupsReturns(result1 []app.GroupInfo, result2 error) {
+	fake.listGroupsMutex.Lock()
+	defer fake.listGroupsMutex.Unlock()
+	fake.ListGroupsStub = nil
+	fake.listGroupsReturns = struct {
+		result1 []app.GroupInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGroupLister) ListGroupsReturnsOnCall(i int, result1 []app.GroupInfo, result2 error) {
+	fake.listGroupsMutex.Lock()
+	defer fake.listGroupsMutex.Unlock()
+	fake.ListGroupsStub = nil
+	if fake.listGroupsReturnsOnCall == nil {
+		fake.listGroupsReturnsOnCall = make(map[int]struct {
+			result1 []app.GroupInfo
+			result2 error
+		})
+	}
+	fake.listGroupsReturnsOnCall[i] = struct {
+		result1 []app.GroupInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGroupLister) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.listGroupsMutex.RLock()
+	defer fake.listGroupsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeGroupLister) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ app.GroupLister = new(FakeGroupLister)
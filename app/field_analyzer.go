@@ -0,0 +1,133 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxFieldExamples is the number of distinct example values of a field.
+const maxFieldExamples = 3
+
+// maxExampleLength is the number of characters after which an example value
+// is truncated.
+const maxExampleLength = 40
+
+// FieldInfo describes a field of the person data as listed by the fields
+// command.
+type FieldInfo struct {
+	// Path addresses the field in the config, e.g. 'addresses[0].street'.
+	Path string
+	// Types are the JSON types of the field, e.g. 'string' or 'null'.
+	Types []string
+	// Filled is the number of persons with a value that is not empty.
+	Filled int
+	// Examples are distinct values of the field, objects and arrays have no
+	// examples since their fields are listed themselves.
+	Examples []string
+}
+
+// AnalyzeFields returns all fields of the persons sorted by path. Nested
+// objects are listed with their fields, lists with the fields of their first
+// element.
+func AnalyzeFields(persons []json.RawMessage) ([]FieldInfo, error) {
+	fields := make(map[string]*FieldInfo)
+
+	for _, person := range persons {
+		var personJson map[string]json.RawMessage
+		if err := json.Unmarshal(person, &personJson); err != nil {
+			return nil, fmt.Errorf("failed to read person information raw json: %v", err)
+		}
+		analyzeObject(fields, "", personJson)
+	}
+
+	result := make([]FieldInfo, 0, len(fields))
+	for _, field := range fields {
+		slices.Sort(field.Types)
+		result = append(result, *field)
+	}
+	slices.SortFunc(result, func(a, b FieldInfo) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return result, nil
+}
+
+func analyzeObject(fields map[string]*FieldInfo, prefix string, object map[string]json.RawMessage) {
+	for key, value := range object {
+		analyzeValue(fields, prefix+key, value)
+	}
+}
+
+func analyzeValue(fields map[string]*FieldInfo, path string, value json.RawMessage) {
+	field, exists := fields[path]
+	if !exists {
+		field = &FieldInfo{Path: path}
+		fields[path] = field
+	}
+
+	var parsedValue any
+	if err := json.Unmarshal(value, &parsedValue); err != nil {
+		return
+	}
+
+	jsonType := jsonTypeName(parsedValue)
+	if !slices.Contains(field.Types, jsonType) {
+		field.Types = append(field.Types, jsonType)
+	}
+
+	switch v := parsedValue.(type) {
+	case nil:
+		return
+	case map[string]any:
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(value, &object); err == nil && len(object) > 0 {
+			field.Filled++
+			analyzeObject(fields, path+".", object)
+		}
+	case []any:
+		var list []json.RawMessage
+		if err := json.Unmarshal(value, &list); err == nil && len(list) > 0 {
+			field.Filled++
+			analyzeValue(fields, path+"[0]", list[0])
+		}
+	case string:
+		if v == "" {
+			return
+		}
+		field.Filled++
+		addExample(field, v)
+	default:
+		field.Filled++
+		addExample(field, string(value))
+	}
+}
+
+func addExample(field *FieldInfo, example string) {
+	if len(field.Examples) >= maxFieldExamples {
+		return
+	}
+	if runes := []rune(example); len(runes) > maxExampleLength {
+		example = string(runes[:maxExampleLength]) + "..."
+	}
+	if !slices.Contains(field.Examples, example) {
+		field.Examples = append(field.Examples, example)
+	}
+}
+
+func jsonTypeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
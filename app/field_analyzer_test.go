@@ -0,0 +1,68 @@
+package app_test
+
+import (
+	"ctRestClient/app"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AnalyzeFields", func() {
+
+	It("returns the fields of all persons with types, fill count and examples", func() {
+		persons := []json.RawMessage{
+			json.RawMessage(`{"id": 1, "firstName": "Max", "email": "", "isArchived": false, "sexId": 1}`),
+			json.RawMessage(`{"id": 2, "firstName": "Erika", "email": "erika@example.com", "isArchived": false, "sexId": null}`),
+			json.RawMessage(`{"id": 3, "firstName": "Max", "email": null, "isArchived": true, "sexId": 2}`),
+		}
+
+		fields, err := app.AnalyzeFields(persons)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(Equal([]app.FieldInfo{
+			{Path: "email", Types: []string{"null", "string"}, Filled: 1, Examples: []string{"erika@example.com"}},
+			{Path: "firstName", Types: []string{"string"}, Filled: 3, Examples: []string{"Max", "Erika"}},
+			{Path: "id", Types: []string{"number"}, Filled: 3, Examples: []string{"1", "2", "3"}},
+			{Path: "isArchived", Types: []string{"boolean"}, Filled: 3, Examples: []string{"false", "true"}},
+			{Path: "sexId", Types: []string{"null", "number"}, Filled: 2, Examples: []string{"1", "2"}},
+		}))
+	})
+
+	It("returns the fields of nested objects and the first element of lists", func() {
+		persons := []json.RawMessage{
+			json.RawMessage(`{"privacyPolicyAgreement": {"date": "2020-01-01", "typeId": 1}, "tags": [{"name": "choir"}, {"name": "band"}]}`),
+			json.RawMessage(`{"privacyPolicyAgreement": {}, "tags": []}`),
+		}
+
+		fields, err := app.AnalyzeFields(persons)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(Equal([]app.FieldInfo{
+			{Path: "privacyPolicyAgreement", Types: []string{"object"}, Filled: 1},
+			{Path: "privacyPolicyAgreement.date", Types: []string{"string"}, Filled: 1, Examples: []string{"2020-01-01"}},
+			{Path: "privacyPolicyAgreement.typeId", Types: []string{"number"}, Filled: 1, Examples: []string{"1"}},
+			{Path: "tags", Types: []string{"array"}, Filled: 1},
+			{Path: "tags[0]", Types: []string{"object"}, Filled: 1},
+			{Path: "tags[0].name", Types: []string{"string"}, Filled: 1, Examples: []string{"choir"}},
+		}))
+	})
+
+	It("truncates long example values", func() {
+		persons := []json.RawMessage{
+			json.RawMessage(`{"note": "Lorem ipsum dolor sit amet, consetetur sadipscing elitr"}`),
+		}
+
+		fields, err := app.AnalyzeFields(persons)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields[0].Examples).To(Equal([]string{"Lorem ipsum dolor sit amet, consetetur s..."}))
+	})
+
+	It("returns an error for invalid person data", func() {
+		_, err := app.AnalyzeFields([]json.RawMessage{json.RawMessage(`[1]`)})
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("failed to read person information raw json"))
+	})
+})
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"ctRestClient/config"
 	"ctRestClient/rest"
 	"encoding/json"
 	"fmt"
@@ -12,7 +13,7 @@ type GroupName2IDMap map[string]int
 //counterfeiter:generate . GroupExporter
 type GroupExporter interface {
 	ExportGroupMembers(
-		groupName string,
+		group config.Group,
 		groupsEndpoint rest.GroupsEndpoint,
 		dynamicGroupsEndpoint rest.DynamicGroupsEndpoint,
 		personsEndpoint rest.PersonsEndpoint,
@@ -27,24 +28,80 @@ func NewGroupExporter() GroupExporter {
 }
 
 func (g groupExporter) ExportGroupMembers(
-	groupName string,
+	group config.Group,
 	groupsEndpoint rest.GroupsEndpoint,
 	dynamicGroupsEndpoint rest.DynamicGroupsEndpoint,
 	personsEndpoint rest.PersonsEndpoint,
 ) ([]json.RawMessage, error) {
 	var result []json.RawMessage
 
-	ctGroup, err := groupsEndpoint.GetGroup(groupName)
+	// The dynamic groups are requested once, also for all groups of a set
+	dynamicGroupsResponse, err := dynamicGroupsEndpoint.GetAllDynamicGroups()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get group by name: %v", err)
+		return nil, fmt.Errorf("failed to get all dynamic groups, %w", err)
 	}
 
-	dynamicGroupsResponse, err := dynamicGroupsEndpoint.GetAllDynamicGroups()
+	var groupMembers []rest.GroupsMembersResponse
+	if group.IsVirtual() {
+		groupMembers, err = g.resolveGroupSet(*group.Set, groupsEndpoint, dynamicGroupsEndpoint, dynamicGroupsResponse.GroupIDs)
+	} else {
+		groupMembers, err = g.getGroupMembers(group.Name, groupsEndpoint, dynamicGroupsEndpoint, dynamicGroupsResponse.GroupIDs)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get all dynamic groups, %w", err)
+		return nil, err
+	}
+
+	withMembership := group.UsesNamespace(MemberNamespace)
+
+	for _, groupMember := range groupMembers {
+		personsJson, err := personsEndpoint.GetPerson(groupMember.PersonId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve person with id %d, %w", groupMember.PersonId, err)
+		}
+
+		if withMembership {
+			for i, personJson := range personsJson {
+				personsJson[i], err = mergeMembership(personJson, groupMember)
+				if err != nil {
+					return nil, fmt.Errorf("failed to add membership of person with id %d, %w", groupMember.PersonId, err)
+				}
+			}
+		}
+
+		result = append(result, personsJson...)
+	}
+
+	if group.UsesNamespace(RelationshipNamespace) || group.ReplaceWithParents {
+		result, err = addRelationships(result, personsEndpoint)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if group.ReplaceWithParents {
+		result, err = replaceWithParents(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to replace persons with their parents, %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// getGroupMembers returns the members of a ChurchTools group. The status of
+// the group is checked if its ID is one of the dynamic group IDs.
+func (g groupExporter) getGroupMembers(
+	groupName string,
+	groupsEndpoint rest.GroupsEndpoint,
+	dynamicGroupsEndpoint rest.DynamicGroupsEndpoint,
+	dynamicGroupIDs []int,
+) ([]rest.GroupsMembersResponse, error) {
+	ctGroup, err := groupsEndpoint.GetGroup(groupName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group by name: %v", err)
 	}
 
-	if slices.Contains(dynamicGroupsResponse.GroupIDs, ctGroup.ID) {
+	if slices.Contains(dynamicGroupIDs, ctGroup.ID) {
 		dynamicGroup, err := dynamicGroupsEndpoint.GetGroupStatus(ctGroup.ID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get dynamic group status, %w", err)
@@ -60,14 +117,104 @@ func (g groupExporter) ExportGroupMembers(
 		return nil, fmt.Errorf("failed to resolve group members, %w", err)
 	}
 
-	for _, groupMember := range groupMembers {
-		personsJson, err := personsEndpoint.GetPerson(groupMember.PersonId)
+	return groupMembers, nil
+}
+
+// resolveGroupSet returns the members of a virtual group. Persons are
+// compared by their ID, the membership of a person is taken from the first
+// group the person is a member of.
+func (g groupExporter) resolveGroupSet(
+	set config.GroupSet,
+	groupsEndpoint rest.GroupsEndpoint,
+	dynamicGroupsEndpoint rest.DynamicGroupsEndpoint,
+	dynamicGroupIDs []int,
+) ([]rest.GroupsMembersResponse, error) {
+	groupNames := slices.Concat(set.Union, set.Intersection, set.Difference)
+
+	membersOfGroups := make([]map[int]bool, len(groupNames))
+	var members []rest.GroupsMembersResponse
+	knownMembers := make(map[int]bool)
+
+	for i, groupName := range groupNames {
+		groupMembers, err := g.getGroupMembers(groupName, groupsEndpoint, dynamicGroupsEndpoint, dynamicGroupIDs)
 		if err != nil {
-			return nil, fmt.Errorf("failed to resolve person with id %d, %w", groupMember.PersonId, err)
+			return nil, fmt.Errorf("failed to resolve members of group '%s', %w", groupName, err)
 		}
 
-		result = append(result, personsJson...)
+		membersOfGroups[i] = make(map[int]bool, len(groupMembers))
+		for _, groupMember := range groupMembers {
+			membersOfGroups[i][groupMember.PersonId] = true
+			if !knownMembers[groupMember.PersonId] {
+				knownMembers[groupMember.PersonId] = true
+				members = append(members, groupMember)
+			}
+		}
 	}
 
-	return result, nil
+	return slices.DeleteFunc(members, func(member rest.GroupsMembersResponse) bool {
+		switch {
+		case set.Intersection != nil:
+			for _, membersOfGroup := range membersOfGroups {
+				if !membersOfGroup[member.PersonId] {
+					return true
+				}
+			}
+		case set.Difference != nil:
+			if !membersOfGroups[0][member.PersonId] {
+				return true
+			}
+			for _, membersOfGroup := range membersOfGroups[1:] {
+				if membersOfGroup[member.PersonId] {
+					return true
+				}
+			}
+		}
+		return false
+	}), nil
+}
+
+// MemberNamespace is the field namespace of the group membership attributes,
+// e.g. 'member.memberStartDate' or 'member.fields.<name>'.
+const MemberNamespace = "member"
+
+// mergeMembership adds the membership attributes of a group member to the
+// person json.
+func mergeMembership(personJson json.RawMessage, groupMember rest.GroupsMembersResponse) (json.RawMessage, error) {
+	var person map[string]json.RawMessage
+	if err := json.Unmarshal(personJson, &person); err != nil {
+		return nil, err
+	}
+
+	var member map[string]json.RawMessage
+	if err := json.Unmarshal(groupMember.Raw, &member); err != nil {
+		return nil, err
+	}
+
+	// ChurchTools returns the group specific fields as a list, make them
+	// addressable by their name.
+	var fields []struct {
+		Name  string          `json:"name"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(member["fields"], &fields); err == nil {
+		fieldsByName := make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			if field.Name != "" {
+				fieldsByName[field.Name] = field.Value
+			}
+		}
+		fieldsJson, err := json.Marshal(fieldsByName)
+		if err != nil {
+			return nil, err
+		}
+		member["fields"] = fieldsJson
+	}
+
+	memberJson, err := json.Marshal(member)
+	if err != nil {
+		return nil, err
+	}
+	person[MemberNamespace] = memberJson
+
+	return json.Marshal(person)
 }
@@ -2,10 +2,12 @@ package app_test
 
 import (
 	"ctRestClient/app"
+	"ctRestClient/config"
 	"ctRestClient/rest"
 	"ctRestClient/rest/restfakes"
 	"encoding/json"
 	"errors"
+	"fmt"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -18,6 +20,7 @@ var _ = Describe("GroupExporter", func() {
 		dynamicGroupsEndpoint *restfakes.FakeDynamicGroupsEndpoint
 		personsEndpoint       *restfakes.FakePersonsEndpoint
 		groupExporter         app.GroupExporter
+		group                 config.Group
 	)
 
 	BeforeEach(func() {
@@ -26,6 +29,7 @@ var _ = Describe("GroupExporter", func() {
 		personsEndpoint = &restfakes.FakePersonsEndpoint{}
 
 		groupExporter = app.NewGroupExporter()
+		group = config.Group{Name: "group1", Fields: []config.Field{{FieldName: ptr("id")}}}
 	})
 
 	var _ = Describe("ExportPersonData", func() {
@@ -60,7 +64,7 @@ var _ = Describe("GroupExporter", func() {
 			personsEndpoint.GetPersonReturnsOnCall(1, []json.RawMessage{json.RawMessage(person2)}, nil)
 
 			personData, err := groupExporter.ExportGroupMembers(
-				"group1",
+				group,
 				groupsEndpoint,
 				dynamicGroupsEndpoint,
 				personsEndpoint,
@@ -73,6 +77,158 @@ var _ = Describe("GroupExporter", func() {
 			Expect(personData[1]).To(MatchJSON(person2))
 		})
 
+		It("adds the membership attributes if member fields are exported", func() {
+			groupsEndpoint.GetGroupMembersReturns(
+				[]rest.GroupsMembersResponse{
+					{PersonId: 1, GroupId: 1, Raw: json.RawMessage(`{
+						"personId": 1,
+						"groupId": 1,
+						"groupTypeRoleId": 16,
+						"memberStartDate": "2024-01-01",
+						"fields": [{"name": "allergies", "value": "nuts"}]
+					}`)},
+				}, nil,
+			)
+			personsEndpoint.GetPersonReturnsOnCall(0, []json.RawMessage{json.RawMessage(`{"id": 1, "firstName": "foo_firstname"}`)}, nil)
+
+			group.Fields = []config.Field{{FieldName: ptr("id")}, {FieldName: ptr("member.memberStartDate")}}
+			personData, err := groupExporter.ExportGroupMembers(
+				group,
+				groupsEndpoint,
+				dynamicGroupsEndpoint,
+				personsEndpoint,
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(personData).To(HaveLen(1))
+			Expect(personData[0]).To(MatchJSON(`{
+				"id": 1,
+				"firstName": "foo_firstname",
+				"member": {
+					"personId": 1,
+					"groupId": 1,
+					"groupTypeRoleId": 16,
+					"memberStartDate": "2024-01-01",
+					"fields": {"allergies": "nuts"}
+				}
+			}`))
+		})
+
+		var _ = Context("group exports related persons", func() {
+			var (
+				child1, child2, mother, father string
+			)
+
+			parentOf := func(personId string) rest.PersonRelationshipResponse {
+				return rest.PersonRelationshipResponse{
+					DegreeOfRelationship: "relationship.part.parent",
+					Relative:             rest.RelativeResponse{DomainType: "person", DomainIdentifier: personId},
+				}
+			}
+
+			BeforeEach(func() {
+				child1 = `{"id": 1, "firstName": "child1", "street": "Mainstreet 1", "zip": "12345", "city": "Anytown"}`
+				child2 = `{"id": 2, "firstName": "child2", "street": "Mainstreet 1", "zip": "12345", "city": "Anytown"}`
+				mother = `{"id": 10, "firstName": "mother", "street": "Mainstreet 1", "zip": "12345", "city": "Anytown"}`
+				father = `{"id": 11, "firstName": "father", "street": "Otherstreet 2", "zip": "12345", "city": "Anytown"}`
+
+				personsEndpoint.GetPersonReturnsOnCall(0, []json.RawMessage{json.RawMessage(child1)}, nil)
+				personsEndpoint.GetPersonReturnsOnCall(1, []json.RawMessage{json.RawMessage(child2)}, nil)
+				personsEndpoint.GetRelationshipsStub = func(personId int) ([]rest.PersonRelationshipResponse, error) {
+					return []rest.PersonRelationshipResponse{parentOf("10"), parentOf("11")}, nil
+				}
+				personsEndpoint.GetPersonsReturns([]json.RawMessage{json.RawMessage(mother), json.RawMessage(father)}, nil)
+			})
+
+			It("adds the related persons", func() {
+				group.Fields = []config.Field{{FieldName: ptr("id")}, {FieldName: ptr("relationship.parent[0].firstName")}}
+				personData, err := groupExporter.ExportGroupMembers(
+					group,
+					groupsEndpoint,
+					dynamicGroupsEndpoint,
+					personsEndpoint,
+				)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(personData).To(HaveLen(2))
+				Expect(personData[0]).To(MatchJSON(`{
+					"id": 1, "firstName": "child1", "street": "Mainstreet 1", "zip": "12345", "city": "Anytown",
+					"relationship": {"parent": [` + mother + `, ` + father + `]}
+				}`))
+
+				Expect(personsEndpoint.GetRelationshipsCallCount()).To(Equal(2))
+				Expect(personsEndpoint.GetPersonsCallCount()).To(Equal(1))
+				Expect(personsEndpoint.GetPersonsArgsForCall(0)).To(Equal([]int{10, 11}))
+			})
+
+			It("returns an error if relationships cannot be resolved", func() {
+				personsEndpoint.GetRelationshipsStub = nil
+				personsEndpoint.GetRelationshipsReturns(nil, errors.New("boom"))
+
+				group.Fields = []config.Field{{FieldName: ptr("relationship.spouse.email")}}
+				personData, err := groupExporter.ExportGroupMembers(
+					group,
+					groupsEndpoint,
+					dynamicGroupsEndpoint,
+					personsEndpoint,
+				)
+				Expect(err.Error()).To(Equal("failed to resolve relationships of person with id 1, boom"))
+				Expect(personData).To(BeNil())
+			})
+
+			It("replaces the persons with the households of their parents", func() {
+				group.ReplaceWithParents = true
+				personData, err := groupExporter.ExportGroupMembers(
+					group,
+					groupsEndpoint,
+					dynamicGroupsEndpoint,
+					personsEndpoint,
+				)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(personData).To(HaveLen(2))
+
+				var households []struct {
+					Household struct {
+						Persons  []map[string]interface{} `json:"persons"`
+						Children []map[string]interface{} `json:"children"`
+					} `json:"household"`
+				}
+				Expect(json.Unmarshal([]byte("["+string(personData[0])+","+string(personData[1])+"]"), &households)).To(Succeed())
+				Expect(households[0].Household.Persons).To(HaveLen(1))
+				Expect(households[1].Household.Persons).To(HaveLen(1))
+				Expect(households[0].Household.Persons[0]["firstName"]).To(Equal("mother"))
+				Expect(households[0].Household.Children).To(HaveLen(2))
+				Expect(households[0].Household.Children[0]["firstName"]).To(Equal("child1"))
+				Expect(households[0].Household.Children[1]["firstName"]).To(Equal("child2"))
+				Expect(households[1].Household.Persons[0]["firstName"]).To(Equal("father"))
+				Expect(households[1].Household.Children).To(HaveLen(2))
+			})
+
+			It("exports parents with the same address as one household", func() {
+				father = `{"id": 11, "firstName": "father", "street": "Mainstreet  1", "zip": "12345", "city": "anytown"}`
+				personsEndpoint.GetPersonsReturns([]json.RawMessage{json.RawMessage(mother), json.RawMessage(father)}, nil)
+
+				group.ReplaceWithParents = true
+				personData, err := groupExporter.ExportGroupMembers(
+					group,
+					groupsEndpoint,
+					dynamicGroupsEndpoint,
+					personsEndpoint,
+				)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(personData).To(HaveLen(1))
+
+				var household map[string]json.RawMessage
+				Expect(json.Unmarshal(personData[0], &household)).To(Succeed())
+				Expect(household["firstName"]).To(MatchJSON(`"mother"`))
+				Expect(household["household"]).To(MatchJSON(`{
+					"persons": [` + mother + `, ` + father + `],
+					"children": [
+						{"id": 1, "firstName": "child1", "street": "Mainstreet 1", "zip": "12345", "city": "Anytown", "relationship": {"parent": [` + mother + `, ` + father + `]}},
+						{"id": 2, "firstName": "child2", "street": "Mainstreet 1", "zip": "12345", "city": "Anytown", "relationship": {"parent": [` + mother + `, ` + father + `]}}
+					]
+				}`))
+			})
+		})
+
 		var _ = Context("group is a dynamic group", func() {
 
 			BeforeEach(func() {
@@ -89,7 +245,7 @@ var _ = Describe("GroupExporter", func() {
 				)
 
 				personData, err := groupExporter.ExportGroupMembers(
-					"group1",
+					group,
 					groupsEndpoint,
 					dynamicGroupsEndpoint,
 					personsEndpoint,
@@ -105,7 +261,7 @@ var _ = Describe("GroupExporter", func() {
 				)
 
 				personData, err := groupExporter.ExportGroupMembers(
-					"group1",
+					group,
 					groupsEndpoint,
 					dynamicGroupsEndpoint,
 					personsEndpoint,
@@ -124,7 +280,7 @@ var _ = Describe("GroupExporter", func() {
 			groupsEndpoint.GetGroupMembersReturns(nil, errors.New("boom"))
 
 			personData, err := groupExporter.ExportGroupMembers(
-				"group1",
+				group,
 				groupsEndpoint,
 				dynamicGroupsEndpoint,
 				personsEndpoint,
@@ -142,7 +298,7 @@ var _ = Describe("GroupExporter", func() {
 			personsEndpoint.GetPersonReturnsOnCall(0, nil, errors.New("boom"))
 
 			personData, err := groupExporter.ExportGroupMembers(
-				"group1",
+				group,
 				groupsEndpoint,
 				dynamicGroupsEndpoint,
 				personsEndpoint,
@@ -151,5 +307,85 @@ var _ = Describe("GroupExporter", func() {
 			Expect(err.Error()).To(Equal("failed to resolve person with id 1, boom"))
 			Expect(personData).To(BeNil())
 		})
+
+		var _ = Describe("virtual groups", func() {
+			BeforeEach(func() {
+				groupsEndpoint.GetGroupStub = func(name string) (rest.GroupsResponse, error) {
+					return map[string]rest.GroupsResponse{"A": {ID: 1, Name: "A"}, "B": {ID: 2, Name: "B"}, "C": {ID: 3, Name: "C"}}[name], nil
+				}
+				groupsEndpoint.GetGroupMembersStub = func(groupId int) ([]rest.GroupsMembersResponse, error) {
+					return map[int][]rest.GroupsMembersResponse{
+						1: {{PersonId: 1, GroupId: 1}, {PersonId: 2, GroupId: 1}, {PersonId: 3, GroupId: 1}},
+						2: {{PersonId: 2, GroupId: 2}, {PersonId: 4, GroupId: 2}},
+						3: {{PersonId: 3, GroupId: 3}, {PersonId: 2, GroupId: 3}},
+					}[groupId], nil
+				}
+				personsEndpoint.GetPersonStub = func(personId int) ([]json.RawMessage, error) {
+					return []json.RawMessage{json.RawMessage(fmt.Sprintf(`{"id": %d}`, personId))}, nil
+				}
+			})
+
+			exportedIds := func(set config.GroupSet) []int {
+				group := config.Group{Name: "virtual", Set: &set, Fields: []config.Field{{FieldName: ptr("id")}}}
+				personData, err := groupExporter.ExportGroupMembers(group, groupsEndpoint, dynamicGroupsEndpoint, personsEndpoint)
+				Expect(err).NotTo(HaveOccurred())
+
+				var ids []int
+				for _, personJson := range personData {
+					var person struct{ ID int }
+					Expect(json.Unmarshal(personJson, &person)).To(Succeed())
+					ids = append(ids, person.ID)
+				}
+				return ids
+			}
+
+			It("returns the members of any group for unions", func() {
+				Expect(exportedIds(config.GroupSet{Union: []string{"A", "B"}})).To(Equal([]int{1, 2, 3, 4}))
+			})
+
+			It("returns the members of all groups for intersections", func() {
+				Expect(exportedIds(config.GroupSet{Intersection: []string{"A", "B", "C"}})).To(Equal([]int{2}))
+			})
+
+			It("returns the members of the first group that are not in the other groups for differences", func() {
+				Expect(exportedIds(config.GroupSet{Difference: []string{"A", "B"}})).To(Equal([]int{1, 3}))
+				Expect(exportedIds(config.GroupSet{Difference: []string{"A", "B", "C"}})).To(Equal([]int{1}))
+			})
+
+			It("does not look up the virtual group in ChurchTools", func() {
+				exportedIds(config.GroupSet{Union: []string{"A", "B"}})
+
+				Expect(groupsEndpoint.GetGroupCallCount()).To(Equal(2))
+				Expect(groupsEndpoint.GetGroupArgsForCall(0)).To(Equal("A"))
+				Expect(groupsEndpoint.GetGroupArgsForCall(1)).To(Equal("B"))
+			})
+
+			It("requests the dynamic groups once for all groups of the set", func() {
+				exportedIds(config.GroupSet{Union: []string{"A", "B", "C"}})
+
+				Expect(dynamicGroupsEndpoint.GetAllDynamicGroupsCallCount()).To(Equal(1))
+			})
+
+			It("returns the error of an inactive dynamic group of the set", func() {
+				dynamicGroupsEndpoint.GetAllDynamicGroupsReturns(rest.DynamicGroupsResponse{GroupIDs: []int{2}}, nil)
+				dynamicGroupsEndpoint.GetGroupStatusReturns(rest.DynamicGroupsStatusResponse{Status: ptr("not-active")}, nil)
+
+				group := config.Group{Name: "virtual", Set: &config.GroupSet{Union: []string{"A", "B"}}}
+				_, err := groupExporter.ExportGroupMembers(group, groupsEndpoint, dynamicGroupsEndpoint, personsEndpoint)
+				var notActiveError *app.GroupNotActiveError
+				Expect(errors.As(err, &notActiveError)).To(BeTrue())
+				Expect(notActiveError.GroupName).To(Equal("B"))
+			})
+
+			It("returns an error if a group cannot be resolved", func() {
+				groupsEndpoint.GetGroupMembersStub = nil
+				groupsEndpoint.GetGroupMembersReturns(nil, errors.New("boom"))
+
+				group := config.Group{Name: "virtual", Set: &config.GroupSet{Union: []string{"A", "B"}}}
+				personData, err := groupExporter.ExportGroupMembers(group, groupsEndpoint, dynamicGroupsEndpoint, personsEndpoint)
+				Expect(err).To(MatchError("failed to resolve members of group 'A', failed to resolve group members, boom"))
+				Expect(personData).To(BeNil())
+			})
+		})
 	})
 })
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"ctRestClient/rest"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// GroupInfo describes a group as listed by the groups command.
+type GroupInfo struct {
+	ID          int
+	Name        string
+	Type        string
+	MemberCount int
+	Dynamic     bool
+	// Status is the status of the group, for dynamic groups the status of
+	// the dynamic membership.
+	Status string
+}
+
+// IsActive returns false for groups that are not exported, i.e. dynamic
+// groups that are not active.
+func (g GroupInfo) IsActive() bool {
+	return !g.Dynamic || g.Status == "active"
+}
+
+//counterfeiter:generate . GroupLister
+type GroupLister interface {
+	// ListGroups returns the groups visible for the token sorted by name.
+	// If patterns are set, only groups with a name matching one of the glob
+	// patterns are returned.
+	ListGroups(
+		patterns []string,
+		groupsEndpoint rest.GroupsEndpoint,
+		dynamicGroupsEndpoint rest.DynamicGroupsEndpoint,
+	) ([]GroupInfo, error)
+}
+
+type groupLister struct {
+}
+
+func NewGroupLister() GroupLister {
+	return groupLister{}
+}
+
+func (l groupLister) ListGroups(
+	patterns []string,
+	groupsEndpoint rest.GroupsEndpoint,
+	dynamicGroupsEndpoint rest.DynamicGroupsEndpoint,
+) ([]GroupInfo, error) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid group pattern '%s', %w", pattern, err)
+		}
+	}
+
+	groups, err := groupsEndpoint.GetAllGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get groups, %w", err)
+	}
+
+	groups = slices.DeleteFunc(groups, func(group rest.GroupsResponse) bool {
+		return !matchesAnyPattern(group.Name, patterns)
+	})
+	if len(groups) == 0 {
+		return []GroupInfo{}, nil
+	}
+
+	groupTypes, err := groupsEndpoint.GetGroupTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group types, %w", err)
+	}
+	groupTypeNames := make(map[int]string, len(groupTypes))
+	for _, groupType := range groupTypes {
+		groupTypeNames[groupType.ID] = groupType.Name
+	}
+
+	dynamicGroups, err := dynamicGroupsEndpoint.GetAllDynamicGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dynamic groups, %w", err)
+	}
+
+	groupIDs := make([]int, len(groups))
+	for i, group := range groups {
+		groupIDs[i] = group.ID
+	}
+	memberCounts, err := groupsEndpoint.GetMemberCounts(groupIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group members, %w", err)
+	}
+
+	result := make([]GroupInfo, 0, len(groups))
+	for _, group := range groups {
+		info := GroupInfo{
+			ID:          group.ID,
+			Name:        group.Name,
+			Type:        groupTypeNames[group.Information.GroupTypeId],
+			MemberCount: memberCounts[group.ID],
+			Dynamic:     slices.Contains(dynamicGroups.GroupIDs, group.ID),
+			Status:      group.Status(),
+		}
+
+		if info.Dynamic {
+			dynamicGroup, err := dynamicGroupsEndpoint.GetGroupStatus(group.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get status of dynamic group '%s', %w", group.Name, err)
+			}
+			info.Status = *dynamicGroup.Status
+		}
+
+		result = append(result, info)
+	}
+
+	slices.SortFunc(result, func(a, b GroupInfo) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	return result, nil
+}
+
+func matchesAnyPattern(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matches, _ := path.Match(pattern, name); matches {
+			return true
+		}
+	}
+	return false
+}
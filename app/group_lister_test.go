@@ -0,0 +1,97 @@
+package app_test
+
+import (
+	"ctRestClient/app"
+	"ctRestClient/rest"
+	"ctRestClient/rest/restfakes"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GroupLister", func() {
+
+	var (
+		groupsEndpoint        *restfakes.FakeGroupsEndpoint
+		dynamicGroupsEndpoint *restfakes.FakeDynamicGroupsEndpoint
+		groupLister           app.GroupLister
+	)
+
+	BeforeEach(func() {
+		groupsEndpoint = &restfakes.FakeGroupsEndpoint{}
+		dynamicGroupsEndpoint = &restfakes.FakeDynamicGroupsEndpoint{}
+		groupLister = app.NewGroupLister()
+
+		groupsEndpoint.GetAllGroupsReturns([]rest.GroupsResponse{
+			{ID: 1, Name: "youth", Information: rest.GroupInformation{GroupTypeId: 1, GroupStatusId: 1}},
+			{ID: 2, Name: "Choir", Information: rest.GroupInformation{GroupTypeId: 2, GroupStatusId: 3}},
+			{ID: 3, Name: "youth leaders", Information: rest.GroupInformation{GroupTypeId: 1, GroupStatusId: 1}},
+		}, nil)
+		groupsEndpoint.GetGroupTypesReturns([]rest.GroupTypeResponse{
+			{ID: 1, Name: "Kleingruppe"},
+			{ID: 2, Name: "Dienst"},
+		}, nil)
+		groupsEndpoint.GetMemberCountsReturns(map[int]int{1: 12, 2: 0, 3: 3}, nil)
+		dynamicGroupsEndpoint.GetAllDynamicGroupsReturns(rest.DynamicGroupsResponse{GroupIDs: []int{3}}, nil)
+		dynamicGroupsEndpoint.GetGroupStatusReturns(rest.DynamicGroupsStatusResponse{Status: ptr("inactive")}, nil)
+	})
+
+	It("returns all groups sorted by name", func() {
+		groups, err := groupLister.ListGroups(nil, groupsEndpoint, dynamicGroupsEndpoint)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(groups).To(Equal([]app.GroupInfo{
+			{ID: 2, Name: "Choir", Type: "Dienst", MemberCount: 0, Dynamic: false, Status: "archived"},
+			{ID: 1, Name: "youth", Type: "Kleingruppe", MemberCount: 12, Dynamic: false, Status: "active"},
+			{ID: 3, Name: "youth leaders", Type: "Kleingruppe", MemberCount: 3, Dynamic: true, Status: "inactive"},
+		}))
+		Expect(groups[2].IsActive()).To(BeFalse())
+
+		Expect(dynamicGroupsEndpoint.GetGroupStatusCallCount()).To(Equal(1))
+		Expect(dynamicGroupsEndpoint.GetGroupStatusArgsForCall(0)).To(Equal(3))
+	})
+
+	It("returns the groups matching the patterns", func() {
+		groups, err := groupLister.ListGroups([]string{"youth*"}, groupsEndpoint, dynamicGroupsEndpoint)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(groups).To(HaveLen(2))
+		Expect(groups[0].Name).To(Equal("youth"))
+		Expect(groups[1].Name).To(Equal("youth leaders"))
+		Expect(groupsEndpoint.GetMemberCountsArgsForCall(0)).To(Equal([]int{1, 3}))
+	})
+
+	It("returns no groups if no group matches the patterns", func() {
+		groups, err := groupLister.ListGroups([]string{"Band"}, groupsEndpoint, dynamicGroupsEndpoint)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(groups).To(BeEmpty())
+		Expect(groupsEndpoint.GetMemberCountsCallCount()).To(Equal(0))
+	})
+
+	It("returns an error for invalid patterns", func() {
+		_, err := groupLister.ListGroups([]string{"[youth"}, groupsEndpoint, dynamicGroupsEndpoint)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("invalid group pattern '[youth'"))
+	})
+
+	It("returns an error if the groups cannot be requested", func() {
+		groupsEndpoint.GetAllGroupsReturns(nil, errors.New("request failed"))
+
+		_, err := groupLister.ListGroups(nil, groupsEndpoint, dynamicGroupsEndpoint)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("failed to get groups, request failed"))
+	})
+
+	It("returns an error if the status of a dynamic group cannot be requested", func() {
+		dynamicGroupsEndpoint.GetGroupStatusReturns(rest.DynamicGroupsStatusResponse{}, errors.New("request failed"))
+
+		_, err := groupLister.ListGroups(nil, groupsEndpoint, dynamicGroupsEndpoint)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("failed to get status of dynamic group 'youth leaders', request failed"))
+	})
+})
@@ -4,13 +4,23 @@ import (
 	"ctRestClient/config"
 	"ctRestClient/csv"
 	"ctRestClient/data_provider"
+	"ctRestClient/diff"
 	"ctRestClient/httpclient"
 	"ctRestClient/logger"
+	"ctRestClient/output"
+	"ctRestClient/privacy"
 	"ctRestClient/rest"
+	"ctRestClient/secret"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 type InstancesProcessor interface {
@@ -20,22 +30,50 @@ type InstancesProcessor interface {
 		rootDir string,
 		personDataProvider data_provider.FileDataProvider,
 		blocklistsDataProvider data_provider.BlockListDataProvider,
-		keepassCli KeepassCli,
-	) error
+		secretStores secret.Stores,
+	) (RunReport, error)
+}
+
+// ProcessorOptions control how the instances are processed.
+type ProcessorOptions struct {
+	// DryRun processes all groups without writing any files. The result of
+	// each group is logged instead.
+	DryRun bool
+	// PreviewRows is the number of rows logged per group in a dry run.
+	PreviewRows int
+	// Selection restricts the run to some instances and groups, all are
+	// processed if it is empty.
+	Selection config.Selection
+	// Diff compares each group with its previous export and writes the
+	// added, removed and changed persons.
+	Diff bool
+	// LatestDir is the directory with the newest files of each group, it is
+	// not maintained if it is empty.
+	LatestDir string
+	// Bundle is the encrypted bundle the csv writer adds the files to. No
+	// directories are created then and the output paths of the run report
+	// are the paths within the bundle.
+	Bundle string
+	// Partial is true if the config contains only some groups, e.g. the due
+	// groups of a scheduled run.
+	Partial bool
 }
 
 type instancesProcessor struct {
-	config config.Config
-	logger logger.Logger
+	config  config.Config
+	logger  logger.Logger
+	options ProcessorOptions
 }
 
 func NewInstancesProcessor(
 	config config.Config,
 	logger logger.Logger,
+	options ProcessorOptions,
 ) InstancesProcessor {
 	return instancesProcessor{
-		config: config,
-		logger: logger,
+		config:  config,
+		logger:  logger,
+		options: options,
 	}
 }
 
@@ -45,83 +83,489 @@ func (p instancesProcessor) Process(
 	rootDir string,
 	fileDataProvider data_provider.FileDataProvider,
 	blocklistsDataProvider data_provider.BlockListDataProvider,
-	keepassCli KeepassCli,
-) error {
-	for _, instance := range p.config.Instances {
+	secretStores secret.Stores,
+) (RunReport, error) {
+	cfg, err := p.config.Select(p.options.Selection)
+	if err != nil {
+		return RunReport{}, err
+	}
+	if !p.options.Selection.IsEmpty() {
+		p.logger.Info(fmt.Sprintf("processing the selected %s", p.options.Selection))
+	}
+	// The previous exports are encrypted, so there is nothing to compare
+	// with
+	if p.options.Diff && cfg.Output.Encryption.IsSet() {
+		return RunReport{}, errors.New("the diff cannot be combined with the encryption of the output")
+	}
+
+	privacyProfile := cfg.Privacy()
+	if cfg.PrivacyProfile != "" {
+		p.logger.Info(fmt.Sprintf("using privacy profile '%s'", cfg.PrivacyProfile))
+	}
+	if cfg.UsesPrivacyRule(privacy.Hash) {
+		privacySecret, err := secret.Lookup(secretStores, cfg.PrivacySecretSource, cfg.PrivacySecretName)
+		if err != nil {
+			return RunReport{}, fmt.Errorf("failed to get privacy secret with name '%s' from %s, %w", cfg.PrivacySecretName, cfg.GetPrivacySecretSource(), err)
+		}
+		privacyProfile.Secret = privacySecret
+	}
+
+	report := RunReport{DryRun: p.options.DryRun, Bundle: p.options.Bundle, Start: time.Now()}
+	// The date placeholders of the output path use the time of the run
+	// directory, so that the files of earlier runs can be found again
+	runTime, exists := output.RunTime(rootDir)
+	if !exists {
+		runTime = report.Start
+	}
+	files := outputFiles{
+		filepath.Join(rootDir, RunReportFileName):                 "the run report",
+		filepath.Join(rootDir, UnmatchedBlocklistEntriesFileName): "the unmatched blocklist entries",
+	}.normalized()
+	for _, instance := range cfg.Instances {
 
 		p.logTitle(instance)
+		instanceResult := InstanceResult{Hostname: instance.Hostname, Groups: []GroupResult{}}
 
-		token, err := keepassCli.GetPassword(instance.TokenName)
+		token, err := secret.Lookup(secretStores, instance.TokenSource, instance.TokenName)
 		if err != nil {
-			p.logger.Warn(fmt.Sprintf("  skipping export, failed to get token with name '%s' from Keepass. Err: %v", instance.TokenName, err))
+			p.logger.Warn(fmt.Sprintf("  skipping export, failed to get token with name '%s' from %s. Err: %v", instance.TokenName, instance.GetTokenSource(), err))
+			instanceResult.Error = fmt.Sprintf("failed to get token with name '%s' from %s, %v", instance.TokenName, instance.GetTokenSource(), err)
+			for _, group := range instance.Groups {
+				instanceResult.Groups = append(instanceResult.Groups, GroupResult{Name: group.Name, Status: StatusFailed, Error: instanceResult.Error})
+			}
+			instanceResult.finish()
+			report.Instances = append(report.Instances, instanceResult)
 			continue
 		}
 
 		httpClient := httpclient.NewHTTPClient(instance.Hostname, token)
-		groupsEndpoint := rest.NewGroupsEndpoint(httpClient)
-		dynamicGroupsEndpoint := rest.NewDynamicGroupsEndpoint(httpClient)
-		personEndpoint := rest.NewPersonsEndpoint(httpClient)
+		run := instanceRun{
+			instance:               instance,
+			groupsEndpoint:         rest.NewGroupsEndpoint(httpClient),
+			dynamicGroupsEndpoint:  rest.NewDynamicGroupsEndpoint(httpClient),
+			personEndpoint:         rest.NewPersonsEndpoint(httpClient),
+			privacyProfile:         privacyProfile,
+			groupExporter:          groupExporter,
+			csvWriter:              csvWriter,
+			rootDir:                rootDir,
+			runTime:                runTime,
+			files:                  files,
+			fileDataProvider:       fileDataProvider,
+			blocklistsDataProvider: blocklistsDataProvider,
+		}
+
+		blocklistsDataProvider.SetGroupsEndpoint(instance, run.groupsEndpoint)
 
 		for _, group := range instance.Groups {
-			p.logger.Info("")
-			p.logger.Info(fmt.Sprintf("  processing group '%s'", group.Name))
-
-			persons, err := groupExporter.ExportGroupMembers(
-				group.Name,
-				groupsEndpoint,
-				dynamicGroupsEndpoint,
-				personEndpoint,
-			)
-			if err != nil {
-				if _, ok := err.(*GroupNotActiveError); ok {
-					p.logger.Warn("      skipping csv creation since the group is not active")
-					continue
-				} else {
-					p.logger.Error(fmt.Sprintf("      failed to get person information: %v", err))
-					continue
-				}
+			start := time.Now()
+			result := p.processGroup(run, group)
+			if p.options.LatestDir != "" && !p.options.DryRun && result.Status != StatusFailed {
+				p.updateLatest(run, group, &result)
 			}
+			result.DurationSeconds = time.Since(start).Seconds()
+			instanceResult.Groups = append(instanceResult.Groups, result)
+		}
+		instanceResult.finish()
+		report.Instances = append(report.Instances, instanceResult)
+	}
 
-			if len(persons) == 0 {
-				p.logger.Info("      the group is empty")
-				continue
-			} else {
-				p.logger.Info(fmt.Sprintf("      the group has %d persons", len(persons)))
-			}
+	// The entries of blocklists of groups that are not selected never match
+	if p.options.Selection.IsEmpty() && !p.options.Partial {
+		p.writeUnmatchedBlocklistEntries(csvWriter, rootDir, blocklistsDataProvider)
+	}
 
-			if blocklistsDataProvider.BlockListExists(group) {
-				p.logger.Info(fmt.Sprintf("      using blocklist '%s'", group.BlocklistFileName()))
-			}
+	report.finish()
+	p.logSummary(report)
+	if !p.options.DryRun {
+		if err := writeRunReport(filepath.Join(rootDir, RunReportFileName), report); err != nil {
+			p.logger.Error(err.Error())
+		}
+	}
 
-			personData, err := csv.NewPersonData(persons, group, fileDataProvider, blocklistsDataProvider, p.logger)
-			if err != nil {
-				p.logger.Error(fmt.Sprintf("      failed to extract persons: %v", err))
-				continue
-			}
+	return report, nil
+}
 
-			err = os.MkdirAll(filepath.Join(rootDir, instance.Hostname), 0755)
-			if err != nil {
-				p.logger.Error(fmt.Sprintf("     failed to create directory: %v", err))
-				continue
-			}
+// instanceRun are the dependencies of the groups of an instance.
+type instanceRun struct {
+	instance               config.Instance
+	groupsEndpoint         rest.GroupsEndpoint
+	dynamicGroupsEndpoint  rest.DynamicGroupsEndpoint
+	personEndpoint         rest.PersonsEndpoint
+	privacyProfile         privacy.Profile
+	groupExporter          GroupExporter
+	csvWriter              csv.CSVFileWriter
+	rootDir                string
+	runTime                time.Time
+	files                  outputFiles
+	fileDataProvider       data_provider.FileDataProvider
+	blocklistsDataProvider data_provider.BlockListDataProvider
+}
+
+// processGroup exports the group and returns its result. Failures are
+// logged and returned in the result, so that the other groups are still
+// processed.
+func (p instancesProcessor) processGroup(run instanceRun, group config.Group) GroupResult {
+	p.logger.Info("")
+	p.logger.Info(fmt.Sprintf("  processing group '%s'", group.Name))
 
-			csvFilePath := filepath.Join(
-				rootDir,
-				instance.Hostname,
-				group.CSVFileName(),
-			)
+	// The warnings of a group are counted for the dry run summary and the
+	// run report
+	groupLogger := &countingLogger{Logger: p.logger}
+	result := GroupResult{Name: group.Name}
+	fail := func(message string, err error) GroupResult {
+		groupLogger.Error(fmt.Sprintf("      %s: %v", message, err))
+		result.Status = StatusFailed
+		result.Error = fmt.Sprintf("%s: %v", message, err)
+		result.Warnings = groupLogger.warnings
+		return result
+	}
+
+	persons, err := run.groupExporter.ExportGroupMembers(
+		group,
+		run.groupsEndpoint,
+		run.dynamicGroupsEndpoint,
+		run.personEndpoint,
+	)
+	if err != nil {
+		// The error of an inactive group of a set is wrapped
+		var notActiveError *GroupNotActiveError
+		if errors.As(err, &notActiveError) {
+			groupLogger.Warn("      skipping csv creation since the group is not active")
+			result.Status = StatusSkipped
+			result.Warnings = groupLogger.warnings
+			return result
+		}
+		return fail("failed to get person information", err)
+	}
 
-			err = csvWriter.Write(csvFilePath, personData.Header(), personData.Records())
+	// An empty group is still compared with its previous export, so that
+	// the persons who left it are reported as removed
+	isEmpty := len(persons) == 0
+	if isEmpty {
+		p.logger.Info("      the group is empty")
+		if !p.options.Diff {
+			result.Status = StatusEmpty
+			return result
+		}
+	} else {
+		p.logger.Info(fmt.Sprintf("      the group has %d persons", len(persons)))
+	}
+
+	for _, blocklistFile := range run.blocklistsDataProvider.BlockListFiles(run.instance, group) {
+		p.logger.Info(fmt.Sprintf("      using blocklist '%s'", blocklistFile))
+	}
+
+	personData, err := csv.NewPersonData(persons, run.instance, group, run.privacyProfile, run.fileDataProvider, run.blocklistsDataProvider, groupLogger)
+	if err != nil {
+		return fail("failed to extract persons", err)
+	}
+	result.Rows = len(personData.Records())
+	result.Blocked, result.Filtered = countExcluded(personData.Excluded())
+
+	pathValues, err := p.pathValues(run, group)
+	if err != nil {
+		return fail("failed to get the output path", err)
+	}
+	csvFilePath := p.config.Output.GroupPath(pathValues)
+	err = run.files.claim(p.groupFiles(run, group, filepath.Join(run.rootDir, csvFilePath)), fmt.Sprintf("the group '%s' of '%s'", group.Name, run.instance.Hostname))
+	if err != nil {
+		return fail("failed to write csv file", err)
+	}
+
+	var changes *diff.Result
+	if p.options.Diff {
+		changes, result.Diff, err = p.compareWithPreviousExport(run, group, pathValues, personData, groupLogger)
+		if err != nil {
+			return fail("failed to compare with the previous export", err)
+		}
+	}
+
+	// The csv file of an empty group is only written with the changes, the
+	// next run compares with it then
+	status := StatusSucceeded
+	if isEmpty {
+		status = StatusEmpty
+		if changes == nil {
+			result.Status = status
+			result.Warnings = groupLogger.warnings
+			return result
+		}
+	}
+
+	if p.options.DryRun {
+		p.logPreview(csvFilePath, personData, groupLogger.warnings)
+		result.Status = status
+		result.Warnings = groupLogger.warnings
+		return result
+	}
+
+	result.OutputPath = filepath.ToSlash(csvFilePath)
+	csvFilePath = filepath.Join(run.rootDir, csvFilePath)
+	if p.options.Bundle == "" {
+		err = os.MkdirAll(filepath.Dir(csvFilePath), 0755)
+		if err != nil {
+			return fail("failed to create directory", err)
+		}
+		result.OutputPath = csvFilePath
+	}
+
+	err = run.csvWriter.Write(csvFilePath, personData.Header(), personData.Records())
+	if err != nil {
+		result.OutputPath = ""
+		return fail("failed to write csv file", err)
+	}
+
+	excluded := personData.Excluded()
+	if len(excluded.Records()) > 0 {
+		blockedFilePath := variantPath(csvFilePath, "blocked")
+		err = run.csvWriter.Write(blockedFilePath, excluded.Header(), excluded.Records())
+		if err != nil {
+			return fail("failed to write csv file of excluded persons", err)
+		}
+	}
+
+	if changes != nil {
+		for _, file := range []struct {
+			kind string
+			data csv.CsvData
+		}{{"added", changes.Added}, {"removed", changes.Removed}, {"changed", changes.Changed}} {
+			diffFilePath := variantPath(csvFilePath, file.kind)
+			err = run.csvWriter.Write(diffFilePath, file.data.Header(), file.data.Records())
 			if err != nil {
-				p.logger.Error(fmt.Sprintf("    failed to write csv file: %v", err))
-				continue
+				return fail(fmt.Sprintf("failed to write csv file of %s persons", file.kind), err)
 			}
 		}
 	}
 
+	result.Status = status
+	result.Warnings = groupLogger.warnings
+	return result
+}
+
+// compareWithPreviousExport compares the persons with the newest earlier run
+// that exported the group. It returns nil if the group does not export the
+// person id or has no previous export.
+func (p instancesProcessor) compareWithPreviousExport(
+	run instanceRun,
+	group config.Group,
+	pathValues config.PathValues,
+	personData csv.PersonData,
+	groupLogger logger.Logger,
+) (*diff.Result, *DiffResult, error) {
+	idColumn, exists := group.IDColumn()
+	if !exists {
+		groupLogger.Warn("      skipping the comparison with the previous export, the group does not export the field 'id'")
+		return nil, nil, nil
+	}
+
+	previousPath, exists := previousRunFile(run.rootDir, func(runTime time.Time) string {
+		pathValues.Time = runTime
+		return p.config.Output.GroupPath(pathValues)
+	})
+	if !exists {
+		p.logger.Info("      no previous export of the group to compare with")
+		return nil, nil, nil
+	}
+
+	previous, err := csv.ReadFile(previousPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	changes, err := diff.Compare(previous, personData, idColumn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := &DiffResult{
+		PreviousPath: previousPath,
+		Added:        len(changes.Added.Records()),
+		Removed:      len(changes.Removed.Records()),
+		Changed:      changes.ChangedPersons,
+	}
+	p.logger.Info(fmt.Sprintf("      compared with '%s': %d added, %d removed, %d changed", previousPath, result.Added, result.Removed, result.Changed))
+	return &changes, result, nil
+}
+
+// pathValues returns the values of the output path of the group. The group
+// ID is only requested if the path uses it.
+func (p instancesProcessor) pathValues(run instanceRun, group config.Group) (config.PathValues, error) {
+	values := config.PathValues{Instance: run.instance, Group: group, Time: run.runTime}
+	if p.config.Output.UsesPlaceholder("group_id") {
+		ctGroup, err := run.groupsEndpoint.GetGroup(group.Name)
+		if err != nil {
+			return values, fmt.Errorf("failed to get group by name: %v", err)
+		}
+		values.GroupID = ctGroup.ID
+	}
+	return values, nil
+}
+
+// groupFileKinds are the kinds of the files written next to the csv file of
+// a group, see variantPath.
+var groupFileKinds = []string{"blocked", "added", "removed", "changed"}
+
+// countExcluded returns the number of blocked persons and of persons
+// excluded by the filter of the group.
+func countExcluded(excluded csv.CsvData) (int, int) {
+	reason := slices.Index(excluded.Header(), "reason")
+	blocked, filtered := 0, 0
+	for _, record := range excluded.Records() {
+		if record[reason] == csv.ReasonFilter {
+			filtered++
+		} else {
+			blocked++
+		}
+	}
+	return blocked, filtered
+}
+
+// variantPath returns the path of a file of the kind next to the csv file,
+// e.g. 'Choir.blocked.csv' for 'Choir.csv'.
+func variantPath(csvFilePath string, kind string) string {
+	return strings.TrimSuffix(csvFilePath, ".csv") + "." + kind + ".csv"
+}
+
+// groupFiles returns all files a group may write, in the run directory and
+// in the latest directory.
+func (p instancesProcessor) groupFiles(run instanceRun, group config.Group, csvFilePath string) []string {
+	files := []string{csvFilePath}
+	for _, kind := range groupFileKinds {
+		files = append(files, variantPath(csvFilePath, kind))
+	}
+	if p.options.LatestDir != "" {
+		latestPath := latestPath(p.options.LatestDir, run.instance, group)
+		files = append(files, latestPath)
+		for _, kind := range groupFileKinds {
+			files = append(files, variantPath(latestPath, kind))
+		}
+	}
+	return files
+}
+
+// latestPath returns the path of the csv file of the group in the latest
+// directory. It does not depend on the output path, so that the path stays
+// the same when the output path has date placeholders.
+func latestPath(latestDir string, instance config.Instance, group config.Group) string {
+	return filepath.Join(latestDir, config.SanitizeFileName(instance.Hostname), group.CSVFileName())
+}
+
+// outputFiles are the files written by a run by their normalized path, so
+// that two groups never write the same file.
+type outputFiles map[string]string
+
+// normalized returns the files by their normalized path.
+func (f outputFiles) normalized() outputFiles {
+	files := make(outputFiles, len(f))
+	for path, owner := range f {
+		files[normalizePath(path)] = owner
+	}
+	return files
+}
+
+// claim registers the files of the owner. It returns an error if a file is
+// already registered by another owner, also if the paths only differ in
+// case, since Windows and macOS do not tell them apart.
+func (f outputFiles) claim(paths []string, owner string) error {
+	for _, path := range paths {
+		if other, exists := f[normalizePath(path)]; exists {
+			return fmt.Errorf("the file '%s' is already written for %s", path, other)
+		}
+	}
+	for _, path := range paths {
+		f[normalizePath(path)] = owner
+	}
 	return nil
 }
 
+func normalizePath(path string) string {
+	return strings.ToLower(norm.NFC.String(filepath.Clean(path)))
+}
+
+// updateLatest replaces the files of the group in the latest directory. The
+// files of an empty group without written files or of an inactive group are
+// deleted, the ones of a failed group are kept.
+func (p instancesProcessor) updateLatest(run instanceRun, group config.Group, result *GroupResult) {
+	latestPath := latestPath(p.options.LatestDir, run.instance, group)
+	files := []output.LatestFile{{Path: latestPath, Source: result.OutputPath}}
+	for _, kind := range groupFileKinds {
+		file := output.LatestFile{Path: variantPath(latestPath, kind)}
+		if result.OutputPath != "" {
+			file.Source = variantPath(result.OutputPath, kind)
+		}
+		files = append(files, file)
+	}
+
+	err := output.UpdateLatest(files, p.config.Output.Retention.SecureDelete)
+	if err != nil {
+		p.logger.Error(fmt.Sprintf("      failed to update the latest directory: %v", err))
+		result.Warnings++
+	}
+}
+
+// UnmatchedBlocklistEntriesFileName is the name of the report of blocklist
+// entries that did not block any person.
+const UnmatchedBlocklistEntriesFileName = "unmatched_blocklist_entries.csv"
+
+func (p instancesProcessor) writeUnmatchedBlocklistEntries(
+	csvWriter csv.CSVFileWriter,
+	rootDir string,
+	blocklistsDataProvider data_provider.BlockListDataProvider,
+) {
+	unmatchedEntries := blocklistsDataProvider.UnmatchedEntries()
+	if len(unmatchedEntries) == 0 {
+		return
+	}
+
+	records := make([][]string, 0, len(unmatchedEntries))
+	for _, entry := range unmatchedEntries {
+		records = append(records, []string{entry.File, strconv.Itoa(entry.Entry), strconv.Itoa(entry.Line), entry.Definition})
+	}
+
+	p.logger.Info("")
+	if p.options.DryRun {
+		p.logger.Info(fmt.Sprintf("%d blocklist entries did not match any person", len(unmatchedEntries)))
+		for _, entry := range unmatchedEntries {
+			p.logger.Info(fmt.Sprintf("  %s, entry %d (line %d): %s", entry.File, entry.Entry, entry.Line, entry.Definition))
+		}
+		return
+	}
+	p.logger.Info(fmt.Sprintf("%d blocklist entries did not match any person, see '%s'", len(unmatchedEntries), UnmatchedBlocklistEntriesFileName))
+
+	err := csvWriter.Write(filepath.Join(rootDir, UnmatchedBlocklistEntriesFileName), []string{"blocklist", "entry", "line", "definition"}, records)
+	if err != nil {
+		p.logger.Error(fmt.Sprintf("failed to write csv file of unmatched blocklist entries: %v", err))
+	}
+}
+
+// logPreview logs the result of a group in a dry run instead of writing the
+// csv files.
+func (p instancesProcessor) logPreview(csvFilePath string, personData csv.PersonData, warnings int) {
+	records := personData.Records()
+	p.logger.Info(fmt.Sprintf("      dry run: %d rows, %d excluded, %d warnings", len(records), len(personData.Excluded().Records()), warnings))
+	if len(records) == 0 {
+		return
+	}
+
+	previewRows := min(p.options.PreviewRows, len(records))
+	p.logger.Info(fmt.Sprintf("      first %d rows of '%s':", previewRows, csvFilePath))
+	p.logger.Info("        " + strings.Join(personData.Header(), ";"))
+	for _, record := range records[:previewRows] {
+		p.logger.Info("        " + strings.Join(record, ";"))
+	}
+}
+
+// logSummary logs the number of groups per status and the status of the
+// run.
+func (p instancesProcessor) logSummary(report RunReport) {
+	counts := report.Counts()
+	p.logger.Info("")
+	p.logger.Info(fmt.Sprintf("%d groups succeeded, %d empty, %d skipped, %d failed",
+		counts[StatusSucceeded], counts[StatusEmpty], counts[StatusSkipped], counts[StatusFailed]))
+	if report.Status != StatusSucceeded {
+		p.logger.Warn(fmt.Sprintf("the run %s", strings.ReplaceAll(string(report.Status), "_", " ")))
+	}
+}
+
 func (p instancesProcessor) logTitle(instance config.Instance) {
 	boxLength := 70
 	title := fmt.Sprintf("Processing instance '%s'", instance.Hostname)
@@ -133,3 +577,19 @@ func (p instancesProcessor) logTitle(instance config.Instance) {
 	p.logger.Info(fmt.Sprintf("| %s "+strings.Repeat(" ", boxLength-titleLength-2)+"|", title))
 	p.logger.Info(fmt.Sprintf("+%s+", border))
 }
+
+// countingLogger counts the warnings and errors it logs.
+type countingLogger struct {
+	logger.Logger
+	warnings int
+}
+
+func (l *countingLogger) Warn(message string) {
+	l.warnings++
+	l.Logger.Warn(message)
+}
+
+func (l *countingLogger) Error(message string) {
+	l.warnings++
+	l.Logger.Error(message)
+}
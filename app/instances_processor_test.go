@@ -4,12 +4,18 @@ import (
 	"ctRestClient/app"
 	"ctRestClient/app/appfakes"
 	"ctRestClient/config"
+	"ctRestClient/csv"
 	"ctRestClient/csv/csvfakes"
+	"ctRestClient/data_provider"
 	"ctRestClient/data_provider/data_providerfakes"
 	"ctRestClient/logger/loggerfakes"
+	"ctRestClient/privacy"
+	"ctRestClient/secret/secretfakes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -21,19 +27,24 @@ var _ = Describe("InstanceProcessor", func() {
 		groupExporter          *appfakes.FakeGroupExporter
 		csvWriter              *csvfakes.FakeCSVFileWriter
 		logger                 *loggerfakes.FakeLogger
-		keepassCli             *appfakes.FakeKeepassCli
+		secretStores           *secretfakes.FakeStores
+		tokenStore             *secretfakes.FakeStore
 		personDataProvider     *data_providerfakes.FakeFileDataProvider
 		blocklistsDataProvider *data_providerfakes.FakeBlockListDataProvider
 		cfg                    config.Config
 		instancesProcessor     app.InstancesProcessor
 		result                 []json.RawMessage
+		rootDir                string
 	)
 
 	BeforeEach(func() {
+		rootDir = GinkgoT().TempDir()
 		groupExporter = &appfakes.FakeGroupExporter{}
 		csvWriter = &csvfakes.FakeCSVFileWriter{}
 		logger = &loggerfakes.FakeLogger{}
-		keepassCli = &appfakes.FakeKeepassCli{}
+		tokenStore = &secretfakes.FakeStore{}
+		secretStores = &secretfakes.FakeStores{}
+		secretStores.StoreReturns(tokenStore, nil)
 		personDataProvider = &data_providerfakes.FakeFileDataProvider{}
 		blocklistsDataProvider = &data_providerfakes.FakeBlockListDataProvider{}
 
@@ -52,7 +63,7 @@ var _ = Describe("InstanceProcessor", func() {
 			},
 		}
 
-		instancesProcessor = app.NewInstancesProcessor(cfg, logger)
+		instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{})
 
 		person1 := `{
             "id": 1,
@@ -67,7 +78,7 @@ var _ = Describe("InstanceProcessor", func() {
 
 		result = []json.RawMessage{json.RawMessage(person1), json.RawMessage(person2)}
 
-		keepassCli.GetPasswordReturns("the_token", nil)
+		tokenStore.GetReturns("the_token", nil)
 	})
 
 	var _ = Describe("Process", func() {
@@ -75,7 +86,7 @@ var _ = Describe("InstanceProcessor", func() {
 			groupExporter.ExportGroupMembersReturns(result, nil)
 			csvWriter.WriteReturns(nil)
 
-			err := instancesProcessor.Process(groupExporter, csvWriter, os.TempDir(), personDataProvider, blocklistsDataProvider, keepassCli)
+			_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
 			Expect(err).NotTo(HaveOccurred())
 
 			path, header, content := csvWriter.WriteArgsForCall(0)
@@ -84,6 +95,92 @@ var _ = Describe("InstanceProcessor", func() {
 			Expect(content).To(Equal([][]string{{"1", "foo_firstname", "foo_lastname"}, {"2", "bar_firstname", "bar_lastname"}}))
 		})
 
+		It("writes a csv of the excluded persons", func() {
+			groupExporter.ExportGroupMembersReturns(result, nil)
+			blocklistsDataProvider.IsBlockedReturnsOnCall(1, &data_provider.BlockMatch{Layer: "global", File: "_all.yml", Entry: 1, Fields: []string{"id"}}, nil)
+
+			_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(csvWriter.WriteCallCount()).To(Equal(2))
+			path, header, content := csvWriter.WriteArgsForCall(1)
+			Expect(path).To(Equal(filepath.Join(rootDir, "foo", "foo_group.blocked.csv")))
+			Expect(header).To(Equal(csv.ExcludedHeader))
+			Expect(content).To(Equal([][]string{{"2", "bar_firstname", "bar_lastname", "global blocklist", "_all.yml", "1", "id"}}))
+		})
+
+		It("writes a report of unmatched blocklist entries", func() {
+			groupExporter.ExportGroupMembersReturns(result, nil)
+			blocklistsDataProvider.UnmatchedEntriesReturns([]data_provider.UnmatchedEntry{{File: "_all.yml", Entry: 2, Line: 3, Definition: "{person_ids: [7]}"}})
+
+			_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+			Expect(err).NotTo(HaveOccurred())
+
+			path, header, content := csvWriter.WriteArgsForCall(csvWriter.WriteCallCount() - 1)
+			Expect(path).To(Equal(filepath.Join(rootDir, "unmatched_blocklist_entries.csv")))
+			Expect(header).To(Equal([]string{"blocklist", "entry", "line", "definition"}))
+			Expect(content).To(Equal([][]string{{"_all.yml", "2", "3", "{person_ids: [7]}"}}))
+		})
+
+		It("does not write a report of unmatched blocklist entries for a selection or a partial config", func() {
+			groupExporter.ExportGroupMembersReturns(result, nil)
+			blocklistsDataProvider.UnmatchedEntriesReturns([]data_provider.UnmatchedEntry{{File: "_all.yml", Entry: 2, Line: 3, Definition: "{person_ids: [7]}"}})
+
+			for _, options := range []app.ProcessorOptions{
+				{Selection: config.Selection{Groups: []string{"foo_group"}}},
+				{Partial: true},
+			} {
+				_, err := app.NewInstancesProcessor(cfg, logger, options).Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			Expect(blocklistsDataProvider.UnmatchedEntriesCallCount()).To(Equal(0))
+			for i := 0; i < csvWriter.WriteCallCount(); i++ {
+				path, _, _ := csvWriter.WriteArgsForCall(i)
+				Expect(path).NotTo(HaveSuffix("unmatched_blocklist_entries.csv"))
+			}
+		})
+
+		It("gets the privacy secret from the KeePass database", func() {
+			cfg.PrivacySecretName = "PRIVACY_SECRET"
+			cfg.PrivacyProfile = "print_shop"
+			cfg.PrivacyProfiles = map[string]map[string]privacy.Rule{"print_shop": {"lastName": privacy.Hash}}
+			groupExporter.ExportGroupMembersReturns(result, nil)
+
+			instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{})
+			_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(secretStores.StoreArgsForCall(0)).To(Equal(""))
+			Expect(tokenStore.GetArgsForCall(0)).To(Equal("PRIVACY_SECRET"))
+			_, _, content := csvWriter.WriteArgsForCall(0)
+			Expect(content[0][2]).To(HaveLen(16))
+			Expect(content[0][2]).NotTo(Equal("foo_lastname"))
+		})
+
+		It("returns an error if the privacy secret cannot be read", func() {
+			cfg.PrivacySecretName = "PRIVACY_SECRET"
+			cfg.Instances[0].Groups[0].Fields[2] = config.Field{Object: &config.FieldInformation{FieldName: "lastName", ColumnName: "lastName", Privacy: privacy.Hash}}
+			tokenStore.GetReturns("", errors.New("booom"))
+
+			instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{})
+			_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+			Expect(err).To(MatchError("failed to get privacy secret with name 'PRIVACY_SECRET' from keepass, booom"))
+			Expect(csvWriter.WriteCallCount()).To(Equal(0))
+		})
+
+		It("sets the groups endpoint of the instance for blocklists", func() {
+			groupExporter.ExportGroupMembersReturns(result, nil)
+
+			_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(blocklistsDataProvider.SetGroupsEndpointCallCount()).To(Equal(1))
+			instance, groupsEndpoint := blocklistsDataProvider.SetGroupsEndpointArgsForCall(0)
+			Expect(instance.Hostname).To(Equal("foo"))
+			Expect(groupsEndpoint).NotTo(BeNil())
+		})
+
 		It("logs a warning if a token is not in the environment", func() {
 			cfg = config.Config{
 				Instances: []config.Instance{
@@ -100,14 +197,39 @@ var _ = Describe("InstanceProcessor", func() {
 				},
 			}
 
-			keepassCli.GetPasswordReturns("", errors.New("booom"))
+			tokenStore.GetReturns("", errors.New("booom"))
 
-			instancesProcessor = app.NewInstancesProcessor(cfg, logger)
-			err := instancesProcessor.Process(groupExporter, csvWriter, os.TempDir(), personDataProvider, blocklistsDataProvider, keepassCli)
+			instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{})
+			_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
 			Expect(err).NotTo(HaveOccurred())
 
 			message := logger.WarnArgsForCall(0)
-			Expect(message).To(Equal("  skipping export, failed to get token with name 'THE_UNKNOWN_TOKEN' from Keepass. Err: booom"))
+			Expect(message).To(Equal("  skipping export, failed to get token with name 'THE_UNKNOWN_TOKEN' from keepass. Err: booom"))
+		})
+
+		It("reads the token from the token source of the instance", func() {
+			cfg.Instances[0].TokenSource = "env"
+			groupExporter.ExportGroupMembersReturns(result, nil)
+
+			instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{})
+			_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(secretStores.StoreCallCount()).To(Equal(1))
+			Expect(secretStores.StoreArgsForCall(0)).To(Equal("env"))
+			Expect(tokenStore.GetArgsForCall(0)).To(Equal("THE_TOKEN"))
+		})
+
+		It("logs a warning if the store of the token source cannot be opened", func() {
+			cfg.Instances[0].TokenSource = "file:tokens.yml"
+			secretStores.StoreReturns(nil, errors.New("booom"))
+
+			instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{})
+			_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.WarnArgsForCall(0)).To(Equal("  skipping export, failed to get token with name 'THE_TOKEN' from file:tokens.yml. Err: booom"))
+			Expect(groupExporter.ExportGroupMembersCallCount()).To(Equal(0))
 		})
 
 		It("logs empty groups", func() {
@@ -115,7 +237,7 @@ var _ = Describe("InstanceProcessor", func() {
 			groupExporter.ExportGroupMembersReturns(emptyGroupResult, nil)
 			csvWriter.WriteReturns(nil)
 
-			err := instancesProcessor.Process(groupExporter, csvWriter, os.TempDir(), personDataProvider, blocklistsDataProvider, keepassCli)
+			_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(logger.InfoArgsForCall(2)).To(ContainSubstring("Processing instance 'foo'"))
@@ -127,7 +249,7 @@ var _ = Describe("InstanceProcessor", func() {
 			groupExporter.ExportGroupMembersReturns(result, nil)
 			csvWriter.WriteReturns(nil)
 
-			err := instancesProcessor.Process(groupExporter, csvWriter, os.TempDir(), personDataProvider, blocklistsDataProvider, keepassCli)
+			_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(logger.InfoArgsForCall(2)).To(ContainSubstring("Processing instance 'foo'"))
@@ -138,7 +260,7 @@ var _ = Describe("InstanceProcessor", func() {
 		It("logs a warning for not active groups", func() {
 			groupExporter.ExportGroupMembersReturns(nil, &app.GroupNotActiveError{GroupName: "foo_group"})
 
-			err := instancesProcessor.Process(groupExporter, csvWriter, os.TempDir(), personDataProvider, blocklistsDataProvider, keepassCli)
+			_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(logger.InfoArgsForCall(2)).To(ContainSubstring("Processing instance 'foo'"))
@@ -146,10 +268,20 @@ var _ = Describe("InstanceProcessor", func() {
 			Expect(logger.WarnArgsForCall(0)).To(Equal("      skipping csv creation since the group is not active"))
 		})
 
+		It("skips a virtual group with an inactive dynamic group", func() {
+			groupExporter.ExportGroupMembersReturns(nil, fmt.Errorf("failed to resolve members of group 'A', %w", &app.GroupNotActiveError{GroupName: "A"}))
+
+			report, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(report.Instances[0].Groups[0].Status).To(Equal(app.StatusSkipped))
+			Expect(logger.WarnArgsForCall(0)).To(Equal("      skipping csv creation since the group is not active"))
+		})
+
 		It("returns an error if person data export fails", func() {
 			groupExporter.ExportGroupMembersReturns(nil, errors.New("boom"))
 
-			err := instancesProcessor.Process(groupExporter, csvWriter, os.TempDir(), personDataProvider, blocklistsDataProvider, keepassCli)
+			_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(logger.InfoArgsForCall(2)).To(ContainSubstring("Processing instance 'foo'"))
@@ -163,12 +295,456 @@ var _ = Describe("InstanceProcessor", func() {
 			groupExporter.ExportGroupMembersReturns(result, nil)
 			csvWriter.WriteReturns(nil)
 
-			err := instancesProcessor.Process(groupExporter, csvWriter, os.TempDir(), personDataProvider, blocklistsDataProvider, keepassCli)
+			_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(logger.InfoArgsForCall(2)).To(ContainSubstring("Processing instance 'foo'"))
 			Expect(logger.InfoArgsForCall(5)).To(Equal("  processing group 'foo_group'"))
 			Expect(logger.ErrorArgsForCall(0)).To(ContainSubstring("    failed to extract persons:"))
 		})
+
+		It("fails the group if the blocklists cannot be checked", func() {
+			groupExporter.ExportGroupMembersReturns(result, nil)
+			blocklistsDataProvider.IsBlockedReturns(nil, errors.New("failed to get members of blocked group 'Keine Post', timeout"))
+
+			report, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(csvWriter.WriteCallCount()).To(Equal(0))
+			Expect(report.Instances[0].Groups[0].Status).To(Equal(app.StatusFailed))
+			Expect(report.Instances[0].Groups[0].Error).To(Equal("failed to extract persons: failed to check if person is blocked: failed to get members of blocked group 'Keine Post', timeout"))
+		})
+
+		var _ = Describe("run report", func() {
+			It("writes the results of the groups", func() {
+				groupExporter.ExportGroupMembersReturns(result, nil)
+				blocklistsDataProvider.IsBlockedReturnsOnCall(1, &data_provider.BlockMatch{Layer: "global", File: "_all.yml", Entry: 1}, nil)
+
+				report, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(report.Status).To(Equal(app.StatusSucceeded))
+				Expect(report.Instances).To(HaveLen(1))
+				Expect(report.Instances[0].Hostname).To(Equal("foo"))
+				Expect(report.Instances[0].Status).To(Equal(app.StatusSucceeded))
+				group := report.Instances[0].Groups[0]
+				Expect(group.Name).To(Equal("foo_group"))
+				Expect(group.Status).To(Equal(app.StatusSucceeded))
+				Expect(group.Rows).To(Equal(1))
+				Expect(group.Blocked).To(Equal(1))
+				Expect(group.OutputPath).To(Equal(filepath.Join(rootDir, "foo", "foo_group.csv")))
+
+				content, err := os.ReadFile(filepath.Join(rootDir, app.RunReportFileName))
+				Expect(err).NotTo(HaveOccurred())
+				var written app.RunReport
+				Expect(json.Unmarshal(content, &written)).To(Succeed())
+				Expect(written.Instances).To(Equal(report.Instances))
+				Expect(string(content)).To(ContainSubstring(`"status": "succeeded"`))
+			})
+
+			It("counts the persons excluded by the filter separately from the blocked persons", func() {
+				cfg.Instances[0].Groups[0].Filter = "id in [2]"
+				instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{})
+				groupExporter.ExportGroupMembersReturns(result, nil)
+				blocklistsDataProvider.IsBlockedReturnsOnCall(1, &data_provider.BlockMatch{Layer: "global", File: "_all.yml", Entry: 1}, nil)
+
+				report, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				group := report.Instances[0].Groups[0]
+				Expect(group.Rows).To(Equal(0))
+				Expect(group.Blocked).To(Equal(1))
+				Expect(group.Filtered).To(Equal(1))
+			})
+
+			It("reports a partial failure if some groups fail", func() {
+				cfg.Instances[0].Groups = append(cfg.Instances[0].Groups,
+					config.Group{Name: "failing_group", Fields: []config.Field{{FieldName: ptr("id")}}},
+					config.Group{Name: "inactive_group", Fields: []config.Field{{FieldName: ptr("id")}}},
+				)
+				instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{})
+				groupExporter.ExportGroupMembersReturnsOnCall(0, result, nil)
+				groupExporter.ExportGroupMembersReturnsOnCall(1, nil, errors.New("boom"))
+				groupExporter.ExportGroupMembersReturnsOnCall(2, nil, &app.GroupNotActiveError{GroupName: "inactive_group"})
+
+				report, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(report.Status).To(Equal(app.StatusPartiallyFailed))
+				Expect(report.Instances[0].Status).To(Equal(app.StatusPartiallyFailed))
+				groups := report.Instances[0].Groups
+				Expect(groups[1].Status).To(Equal(app.StatusFailed))
+				Expect(groups[1].Error).To(Equal("failed to get person information: boom"))
+				Expect(groups[1].OutputPath).To(BeEmpty())
+				Expect(groups[2].Status).To(Equal(app.StatusSkipped))
+				Expect(groups[2].Warnings).To(Equal(1))
+				Expect(report.Counts()).To(Equal(map[app.Status]int{app.StatusSucceeded: 1, app.StatusFailed: 1, app.StatusSkipped: 1}))
+				Expect(logger.WarnArgsForCall(logger.WarnCallCount() - 1)).To(Equal("the run partially failed"))
+			})
+
+			It("reports a failure if the token cannot be read", func() {
+				tokenStore.GetReturns("", errors.New("booom"))
+
+				report, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(report.Status).To(Equal(app.StatusFailed))
+				Expect(report.Instances[0].Error).To(Equal("failed to get token with name 'THE_TOKEN' from keepass, booom"))
+				Expect(report.Instances[0].Groups).To(Equal([]app.GroupResult{
+					{Name: "foo_group", Status: app.StatusFailed, Error: "failed to get token with name 'THE_TOKEN' from keepass, booom"},
+				}))
+			})
+
+			It("does not write the report in a dry run", func() {
+				instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{DryRun: true})
+				groupExporter.ExportGroupMembersReturns(result, nil)
+
+				report, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(report.DryRun).To(BeTrue())
+				Expect(report.Instances[0].Groups[0].Rows).To(Equal(2))
+				Expect(filepath.Join(rootDir, app.RunReportFileName)).NotTo(BeAnExistingFile())
+			})
+		})
+
+		var _ = Describe("selection", func() {
+			BeforeEach(func() {
+				cfg.Instances[0].Groups = append(cfg.Instances[0].Groups, config.Group{
+					Name:   "bar_group",
+					Tags:   []string{"monthly"},
+					Fields: []config.Field{{FieldName: ptr("id")}},
+				})
+				cfg.Instances = append(cfg.Instances, config.Instance{
+					Hostname:  "bar",
+					TokenName: "OTHER_TOKEN",
+					Groups:    []config.Group{{Name: "other_group", Fields: []config.Field{{FieldName: ptr("id")}}}},
+				})
+				groupExporter.ExportGroupMembersReturns(result, nil)
+			})
+
+			It("processes only the selected groups", func() {
+				instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{
+					Selection: config.Selection{Instances: []string{"foo"}, Tags: []string{"month*"}},
+				})
+				blocklistsDataProvider.UnmatchedEntriesReturns([]data_provider.UnmatchedEntry{{File: "_all.yml", Entry: 2}})
+
+				_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(groupExporter.ExportGroupMembersCallCount()).To(Equal(1))
+				group, _, _, _ := groupExporter.ExportGroupMembersArgsForCall(0)
+				Expect(group.Name).To(Equal("bar_group"))
+				Expect(tokenStore.GetCallCount()).To(Equal(1))
+				Expect(csvWriter.WriteCallCount()).To(Equal(1))
+				Expect(logger.InfoArgsForCall(0)).To(Equal("processing the selected instances foo; tags month*"))
+			})
+
+			It("returns an error if nothing is selected", func() {
+				instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{
+					Selection: config.Selection{Groups: []string{"unknown_*"}},
+				})
+
+				_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).To(MatchError("no group matches the selection (groups unknown_*)"))
+				Expect(groupExporter.ExportGroupMembersCallCount()).To(Equal(0))
+			})
+		})
+
+		var _ = Describe("diff", func() {
+			var outputDir string
+
+			writePreviousExport := func(run string, records [][]string) {
+				Expect(os.MkdirAll(filepath.Join(outputDir, run, "foo"), 0755)).To(Succeed())
+				Expect(csv.NewCSVFileWriter().Write(filepath.Join(outputDir, run, "foo", "foo_group.csv"), []string{"id", "firstName", "lastName"}, records)).To(Succeed())
+			}
+
+			BeforeEach(func() {
+				outputDir = GinkgoT().TempDir()
+				rootDir = filepath.Join(outputDir, "2025.03.04_06-00-00")
+				instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{Diff: true})
+				groupExporter.ExportGroupMembersReturns(result, nil)
+			})
+
+			It("writes the differences to the previous export", func() {
+				writePreviousExport("2025.03.02_06-00-00", [][]string{{"9", "old", "old"}})
+				writePreviousExport("2025.03.03_06-00-00", [][]string{{"1", "foo_firstname", "old_lastname"}, {"3", "baz_firstname", "baz_lastname"}})
+				writePreviousExport("2025.03.05_06-00-00", [][]string{})
+
+				report, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(csvWriter.WriteCallCount()).To(Equal(4))
+				path, header, records := csvWriter.WriteArgsForCall(1)
+				Expect(path).To(Equal(filepath.Join(rootDir, "foo", "foo_group.added.csv")))
+				Expect(header).To(Equal([]string{"id", "firstName", "lastName"}))
+				Expect(records).To(Equal([][]string{{"2", "bar_firstname", "bar_lastname"}}))
+				path, _, records = csvWriter.WriteArgsForCall(2)
+				Expect(path).To(Equal(filepath.Join(rootDir, "foo", "foo_group.removed.csv")))
+				Expect(records).To(Equal([][]string{{"3", "baz_firstname", "baz_lastname"}}))
+				path, header, records = csvWriter.WriteArgsForCall(3)
+				Expect(path).To(Equal(filepath.Join(rootDir, "foo", "foo_group.changed.csv")))
+				Expect(header).To(Equal([]string{"id", "column", "previous", "current"}))
+				Expect(records).To(Equal([][]string{{"1", "lastName", "old_lastname", "foo_lastname"}}))
+
+				previousPath := filepath.Join(outputDir, "2025.03.03_06-00-00", "foo", "foo_group.csv")
+				Expect(report.Instances[0].Groups[0].Diff).To(Equal(&app.DiffResult{PreviousPath: previousPath, Added: 1, Removed: 1, Changed: 1}))
+			})
+
+			It("finds the previous export of a path with the date", func() {
+				cfg.Output.Path = "{hostname}/{group}_{date}.{ext}"
+				instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{Diff: true})
+				Expect(os.MkdirAll(filepath.Join(outputDir, "2025.03.03_06-00-00", "foo"), 0755)).To(Succeed())
+				Expect(csv.NewCSVFileWriter().Write(filepath.Join(outputDir, "2025.03.03_06-00-00", "foo", "foo_group_2025-03-03.csv"), []string{"id", "firstName", "lastName"}, nil)).To(Succeed())
+
+				report, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(report.Instances[0].Groups[0].Diff.Added).To(Equal(2))
+				path, _, _ := csvWriter.WriteArgsForCall(1)
+				Expect(path).To(Equal(filepath.Join(rootDir, "foo", "foo_group_2025-03-04.added.csv")))
+			})
+
+			It("reports all persons as removed if the group becomes empty", func() {
+				writePreviousExport("2025.03.03_06-00-00", [][]string{{"1", "foo_firstname", "foo_lastname"}})
+				groupExporter.ExportGroupMembersReturns([]json.RawMessage{}, nil)
+
+				report, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(csvWriter.WriteCallCount()).To(Equal(4))
+				path, _, records := csvWriter.WriteArgsForCall(0)
+				Expect(path).To(Equal(filepath.Join(rootDir, "foo", "foo_group.csv")))
+				Expect(records).To(BeEmpty())
+				path, _, records = csvWriter.WriteArgsForCall(2)
+				Expect(path).To(Equal(filepath.Join(rootDir, "foo", "foo_group.removed.csv")))
+				Expect(records).To(Equal([][]string{{"1", "foo_firstname", "foo_lastname"}}))
+
+				group := report.Instances[0].Groups[0]
+				Expect(group.Status).To(Equal(app.StatusEmpty))
+				Expect(group.Diff.Removed).To(Equal(1))
+			})
+
+			It("writes no files for an empty group without a previous export", func() {
+				groupExporter.ExportGroupMembersReturns([]json.RawMessage{}, nil)
+
+				report, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(csvWriter.WriteCallCount()).To(Equal(0))
+				Expect(report.Instances[0].Groups[0].Status).To(Equal(app.StatusEmpty))
+				Expect(report.Instances[0].Groups[0].OutputPath).To(BeEmpty())
+			})
+
+			It("writes no differences if there is no previous export", func() {
+				report, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(csvWriter.WriteCallCount()).To(Equal(1))
+				Expect(report.Instances[0].Groups[0].Diff).To(BeNil())
+				Expect(report.Instances[0].Groups[0].Status).To(Equal(app.StatusSucceeded))
+			})
+
+			It("skips the comparison if the group does not export the id", func() {
+				cfg.Instances[0].Groups[0].Fields = cfg.Instances[0].Groups[0].Fields[1:]
+				instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{Diff: true})
+				writePreviousExport("2025.03.03_06-00-00", [][]string{})
+
+				report, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(csvWriter.WriteCallCount()).To(Equal(1))
+				Expect(report.Instances[0].Groups[0].Warnings).To(Equal(1))
+				Expect(logger.WarnArgsForCall(0)).To(Equal("      skipping the comparison with the previous export, the group does not export the field 'id'"))
+			})
+
+			It("fails the group if the previous export cannot be compared", func() {
+				Expect(os.MkdirAll(filepath.Join(outputDir, "2025.03.03_06-00-00", "foo"), 0755)).To(Succeed())
+				Expect(csv.NewCSVFileWriter().Write(filepath.Join(outputDir, "2025.03.03_06-00-00", "foo", "foo_group.csv"), []string{"firstName"}, nil)).To(Succeed())
+
+				report, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(csvWriter.WriteCallCount()).To(Equal(0))
+				Expect(report.Instances[0].Groups[0].Status).To(Equal(app.StatusFailed))
+				Expect(report.Instances[0].Groups[0].Error).To(Equal("failed to compare with the previous export: failed to read the previous export, the column 'id' does not exist"))
+			})
+
+			It("logs the differences in a dry run", func() {
+				instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{Diff: true, DryRun: true})
+				writePreviousExport("2025.03.03_06-00-00", [][]string{{"1", "foo_firstname", "foo_lastname"}})
+
+				report, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(csvWriter.WriteCallCount()).To(Equal(0))
+				Expect(report.Instances[0].Groups[0].Diff.Added).To(Equal(1))
+				var messages []string
+				for i := 0; i < logger.InfoCallCount(); i++ {
+					messages = append(messages, logger.InfoArgsForCall(i))
+				}
+				Expect(messages).To(ContainElement(ContainSubstring("': 1 added, 0 removed, 0 changed")))
+			})
+		})
+
+		var _ = Describe("output path", func() {
+			BeforeEach(func() {
+				groupExporter.ExportGroupMembersReturns(result, nil)
+				rootDir = filepath.Join(GinkgoT().TempDir(), "2025.03.04_06-00-00")
+			})
+
+			It("writes the files to the path of the template", func() {
+				cfg.Instances[0].Alias = "Zürich"
+				cfg.Output.Path = "{instance}/{year}-{month}/{group}.{ext}"
+				instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{})
+				blocklistsDataProvider.IsBlockedReturnsOnCall(1, &data_provider.BlockMatch{Layer: "global", File: "_all.yml", Entry: 1}, nil)
+
+				report, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(report.Instances[0].Groups[0].OutputPath).To(Equal(filepath.Join(rootDir, "Zuerich", "2025-03", "foo_group.csv")))
+				path, _, _ := csvWriter.WriteArgsForCall(1)
+				Expect(path).To(Equal(filepath.Join(rootDir, "Zuerich", "2025-03", "foo_group.blocked.csv")))
+			})
+
+			It("fails a group that would overwrite the file of another group", func() {
+				cfg.Instances[0].Groups = append(cfg.Instances[0].Groups,
+					config.Group{Name: "Foo Group", Fields: []config.Field{{FieldName: ptr("id")}}},
+					config.Group{Name: "foo_group.blocked", Fields: []config.Field{{FieldName: ptr("id")}}},
+				)
+				instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{})
+
+				report, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(csvWriter.WriteCallCount()).To(Equal(1))
+				groups := report.Instances[0].Groups
+				Expect(groups[0].Status).To(Equal(app.StatusSucceeded))
+				Expect(groups[1].Status).To(Equal(app.StatusFailed))
+				Expect(groups[1].Error).To(Equal("failed to write csv file: the file '" + filepath.Join(rootDir, "foo", "Foo_Group.csv") + "' is already written for the group 'foo_group' of 'foo'"))
+				Expect(groups[2].Status).To(Equal(app.StatusFailed))
+				Expect(report.Status).To(Equal(app.StatusPartiallyFailed))
+			})
+		})
+
+		var _ = Describe("encrypted bundle", func() {
+			BeforeEach(func() {
+				groupExporter.ExportGroupMembersReturns(result, nil)
+				rootDir = filepath.Join(GinkgoT().TempDir(), "2025.03.04_06-00-00")
+				Expect(os.MkdirAll(rootDir, 0755)).To(Succeed())
+				cfg.Output.Encryption = config.Encryption{Format: config.EncryptionZIP, SecretName: "bundle"}
+			})
+
+			It("writes the files without creating directories", func() {
+				bundlePath := filepath.Join(rootDir, "export.zip")
+				instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{Bundle: bundlePath})
+
+				report, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				path, _, _ := csvWriter.WriteArgsForCall(0)
+				Expect(path).To(Equal(filepath.Join(rootDir, "foo", "foo_group.csv")))
+				Expect(filepath.Join(rootDir, "foo")).NotTo(BeAnExistingFile())
+				Expect(report.Bundle).To(Equal(bundlePath))
+				Expect(report.Instances[0].Groups[0].OutputPath).To(Equal("foo/foo_group.csv"))
+			})
+
+			It("returns an error if the groups are compared with the previous export", func() {
+				instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{Diff: true})
+
+				_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).To(MatchError("the diff cannot be combined with the encryption of the output"))
+				Expect(csvWriter.WriteCallCount()).To(Equal(0))
+			})
+		})
+
+		var _ = Describe("latest directory", func() {
+			var latestDir string
+
+			BeforeEach(func() {
+				latestDir = filepath.Join(GinkgoT().TempDir(), "latest")
+				csvWriter.WriteStub = csv.NewCSVFileWriter().Write
+				Expect(os.MkdirAll(filepath.Join(latestDir, "foo"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(latestDir, "foo", "foo_group.csv"), []byte("old"), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(latestDir, "foo", "foo_group.blocked.csv"), []byte("old"), 0644)).To(Succeed())
+				instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{LatestDir: latestDir})
+			})
+
+			It("replaces the files of the exported groups", func() {
+				groupExporter.ExportGroupMembersReturns(result, nil)
+
+				_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				data, err := csv.ReadFile(filepath.Join(latestDir, "foo", "foo_group.csv"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(data.Records()).To(HaveLen(2))
+				Expect(filepath.Join(latestDir, "foo", "foo_group.blocked.csv")).NotTo(BeAnExistingFile())
+			})
+
+			It("deletes the files of an empty group", func() {
+				groupExporter.ExportGroupMembersReturns([]json.RawMessage{}, nil)
+
+				_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(filepath.Join(latestDir, "foo", "foo_group.csv")).NotTo(BeAnExistingFile())
+			})
+
+			It("keeps the files of a failed group", func() {
+				groupExporter.ExportGroupMembersReturns(nil, errors.New("boom"))
+
+				_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(os.ReadFile(filepath.Join(latestDir, "foo", "foo_group.csv"))).To(Equal([]byte("old")))
+			})
+		})
+
+		var _ = Describe("dry run", func() {
+			BeforeEach(func() {
+				instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{DryRun: true, PreviewRows: 1})
+			})
+
+			It("logs the rows instead of writing csv files", func() {
+				groupExporter.ExportGroupMembersReturns(result, nil)
+				blocklistsDataProvider.IsBlockedReturnsOnCall(1, &data_provider.BlockMatch{Layer: "global", File: "_all.yml", Entry: 1}, nil)
+				blocklistsDataProvider.UnmatchedEntriesReturns([]data_provider.UnmatchedEntry{{File: "_all.yml", Entry: 2, Line: 3, Definition: "{person_ids: [7]}"}})
+
+				_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(csvWriter.WriteCallCount()).To(Equal(0))
+
+				var messages []string
+				for i := 0; i < logger.InfoCallCount(); i++ {
+					messages = append(messages, logger.InfoArgsForCall(i))
+				}
+				Expect(messages).To(ContainElements(
+					"      dry run: 1 rows, 1 excluded, 0 warnings",
+					"      first 1 rows of '"+filepath.Join("foo", "foo_group.csv")+"':",
+					"        id;firstName;lastName",
+					"        1;foo_firstname;foo_lastname",
+					"1 blocklist entries did not match any person",
+					"  _all.yml, entry 2 (line 3): {person_ids: [7]}",
+				))
+			})
+
+			It("counts the warnings of a group", func() {
+				cfg.Instances[0].Groups[0].Fields = append(cfg.Instances[0].Groups[0].Fields, config.Field{FieldName: ptr("unknown")})
+				instancesProcessor = app.NewInstancesProcessor(cfg, logger, app.ProcessorOptions{DryRun: true, PreviewRows: 1})
+				groupExporter.ExportGroupMembersReturns(result, nil)
+
+				_, err := instancesProcessor.Process(groupExporter, csvWriter, rootDir, personDataProvider, blocklistsDataProvider, secretStores)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.WarnCallCount()).To(Equal(2))
+				var messages []string
+				for i := 0; i < logger.InfoCallCount(); i++ {
+					messages = append(messages, logger.InfoArgsForCall(i))
+				}
+				Expect(messages).To(ContainElement("      dry run: 2 rows, 0 excluded, 2 warnings"))
+			})
+		})
 	})
 })
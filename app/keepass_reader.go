@@ -0,0 +1,64 @@
+package app
+
+import (
+	"ctRestClient/kdbx"
+	"ctRestClient/logger"
+	"errors"
+	"fmt"
+)
+
+// keepassReader is a KeepassCli reading the database itself, so that
+// keepassxc-cli does not need to be installed.
+type keepassReader struct {
+	dbFilePath  string
+	keyFilePath string
+	password    string
+	logger      logger.Logger
+	database    *kdbx.Database
+}
+
+// NewKeepassReader returns a KeepassCli decrypting the KDBX database once on
+// first use. The key file path is optional.
+func NewKeepassReader(dbFilePath string, password string, keyFilePath string, log logger.Logger) (KeepassCli, error) {
+	if err := checkKeepassDbFile(dbFilePath); err != nil {
+		return nil, err
+	}
+	return &keepassReader{
+		dbFilePath:  dbFilePath,
+		keyFilePath: keyFilePath,
+		password:    password,
+		logger:      log,
+	}, nil
+}
+
+// GetPassword returns the password of the entry with the path, e.g.
+// 'Tokens/example.church.tools', or with the unique title.
+func (r *keepassReader) GetPassword(passwordName string) (string, error) {
+	if r.database == nil {
+		database, err := kdbx.Open(r.dbFilePath, r.password, r.keyFilePath)
+		if err != nil {
+			return "", fmt.Errorf("failed to open the Keepass DB file '%s', %w", r.dbFilePath, err)
+		}
+		r.database = database
+	}
+
+	entry, err := r.database.Entry(passwordName)
+	if err != nil {
+		return "", err
+	}
+	return entry.Password(), nil
+}
+
+func (r *keepassReader) IsPasswordValid(password string) (bool, error) {
+	database, err := kdbx.Open(r.dbFilePath, password, r.keyFilePath)
+	if errors.Is(err, kdbx.ErrInvalidCredentials) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to open the Keepass DB file '%s', %w", r.dbFilePath, err)
+	}
+	if password == r.password {
+		r.database = database
+	}
+	return true, nil
+}
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"ctRestClient/logger"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewKeepassReader", func() {
+	const dbFilePath = "../kdbx/testdata/churchtools-tokens.kdbx"
+
+	It("returns error if file does not exist", func() {
+		_, err := NewKeepassReader("/tmp/nonexistent.kdbx", "password", "", logger.NewLogger("/dev/null"))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("could not be found"))
+	})
+
+	It("checks the password", func() {
+		reader, err := NewKeepassReader(dbFilePath, "abcd1234", "", logger.NewLogger("/dev/null"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(reader.IsPasswordValid("abcd1234")).To(BeTrue())
+		Expect(reader.IsPasswordValid("wrong")).To(BeFalse())
+	})
+
+	It("returns the password of an entry", func() {
+		reader, err := NewKeepassReader(dbFilePath, "abcd1234", "", logger.NewLogger("/dev/null"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(reader.GetPassword("TEST_TOKEN")).To(Equal("1234567890"))
+		_, err = reader.GetPassword("MISSING_TOKEN")
+		Expect(err).To(MatchError("the entry 'MISSING_TOKEN' could not be found"))
+	})
+
+	It("returns an error for a wrong password", func() {
+		reader, err := NewKeepassReader(dbFilePath, "wrong", "", logger.NewLogger("/dev/null"))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = reader.GetPassword("TEST_TOKEN")
+		Expect(err).To(MatchError(ContainSubstring("the password or the key file is invalid")))
+	})
+})
@@ -16,31 +16,54 @@ type KeepassCli interface {
 }
 
 type keepassCli struct {
-	dbFilePath string
-	password   string
-	logger     logger.Logger
+	dbFilePath  string
+	keyFilePath string
+	password    string
+	logger      logger.Logger
 }
 
-func NewKeepassCli(dbFilePath string, password string, log logger.Logger) (KeepassCli, error) {
+// NewKeepassCli returns a KeepassCli running keepassxc-cli, which must be
+// installed. The key file path is optional.
+func NewKeepassCli(dbFilePath string, password string, keyFilePath string, log logger.Logger) (KeepassCli, error) {
+	if err := checkKeepassDbFile(dbFilePath); err != nil {
+		return nil, err
+	}
+	return keepassCli{
+		dbFilePath:  dbFilePath,
+		keyFilePath: keyFilePath,
+		password:    password,
+		logger:      log,
+	}, nil
+}
+
+func checkKeepassDbFile(dbFilePath string) error {
 	info, err := os.Stat(dbFilePath)
 	if os.IsNotExist(err) {
-		return nil, fmt.Errorf("the Keepass DB file '%s' could not be found", dbFilePath)
+		return fmt.Errorf("the Keepass DB file '%s' could not be found", dbFilePath)
 	}
 	if err != nil {
-		return nil, fmt.Errorf("error checking Keepass DB file '%s': %v", dbFilePath, err)
+		return fmt.Errorf("error checking Keepass DB file '%s': %v", dbFilePath, err)
 	}
 	if !info.Mode().IsRegular() {
-		return nil, fmt.Errorf("the Keepass DB file '%s' exists but is not a regular file", dbFilePath)
+		return fmt.Errorf("the Keepass DB file '%s' exists but is not a regular file", dbFilePath)
 	}
-	return keepassCli{
-		dbFilePath: dbFilePath,
-		password:   password,
-		logger:     log,
-	}, nil
+	return nil
+}
+
+// command returns the keepassxc-cli command with the key file option.
+func (s keepassCli) command(name string, args ...string) *exec.Cmd {
+	cmdArgs := []string{name, "-q"}
+	if s.keyFilePath != "" {
+		cmdArgs = append(cmdArgs, "--key-file", s.keyFilePath)
+		if s.password == "" {
+			cmdArgs = append(cmdArgs, "--no-password")
+		}
+	}
+	return exec.Command("keepassxc-cli", append(cmdArgs, args...)...)
 }
 
 func (s keepassCli) GetPassword(passwordName string) (string, error) {
-	cmd := exec.Command("keepassxc-cli", "show", "-q", "-a", "Password", s.dbFilePath, passwordName)
+	cmd := s.command("show", "-a", "Password", s.dbFilePath, passwordName)
 	cmd.Stdin = bytes.NewBufferString(s.password + "\n")
 
 	var out, stderr bytes.Buffer
@@ -57,7 +80,7 @@ func (s keepassCli) GetPassword(passwordName string) (string, error) {
 }
 
 func (s keepassCli) IsPasswordValid(passwordName string) (bool, error) {
-	cmd := exec.Command("keepassxc-cli", "ls", "-q", s.dbFilePath)
+	cmd := s.command("ls", s.dbFilePath)
 	cmd.Stdin = bytes.NewBufferString(s.password + "\n")
 
 	var out, stderr bytes.Buffer
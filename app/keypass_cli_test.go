@@ -10,14 +10,14 @@ import (
 
 var _ = Describe("NewKeepassCli", func() {
 	It("returns error if file does not exist", func() {
-		_, err := NewKeepassCli("/tmp/nonexistent.kdbx", "password", logger.NewLogger("/dev/null"))
+		_, err := NewKeepassCli("/tmp/nonexistent.kdbx", "password", "", logger.NewLogger("/dev/null"))
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("could not be found"))
 	})
 
 	It("returns error if path is a directory", func() {
 		dir := GinkgoT().TempDir()
-		_, err := NewKeepassCli(dir, "password", logger.NewLogger("/dev/null"))
+		_, err := NewKeepassCli(dir, "password", "", logger.NewLogger("/dev/null"))
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("not a regular file"))
 	})
@@ -27,7 +27,7 @@ var _ = Describe("NewKeepassCli", func() {
 		f, err := os.Create(file)
 		Expect(err).NotTo(HaveOccurred())
 		f.Close()
-		cli, err := NewKeepassCli(file, "password", logger.NewLogger("/dev/null"))
+		cli, err := NewKeepassCli(file, "password", "", logger.NewLogger("/dev/null"))
 		Expect(err).NotTo(HaveOccurred())
 		Expect(cli).NotTo(BeNil())
 	})
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"ctRestClient/output"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// previousRunFile returns the file in the newest run directory before the run
+// directory or false if no earlier run has the file, e.g. because its group
+// was not selected or failed. The path of the file relative to a run
+// directory may depend on the time of the run.
+func previousRunFile(rootDir string, relativePath func(runTime time.Time) string) (string, bool) {
+	runs, err := output.Runs(filepath.Dir(rootDir))
+	if err != nil {
+		return "", false
+	}
+
+	current := filepath.Base(rootDir)
+	for _, run := range slices.Backward(runs) {
+		if filepath.Base(run.Path) >= current {
+			continue
+		}
+		path := filepath.Join(run.Path, relativePath(run.Time))
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,217 @@
+package app
+
+import (
+	"ctRestClient/rest"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// RelationshipNamespace is the field namespace of related persons, e.g.
+// 'relationship.parent[0].firstName' or 'relationship.spouse.email'.
+const RelationshipNamespace = "relationship"
+
+// HouseholdNamespace is the field namespace of the households created for
+// groups that are replaced by the parents of their members, e.g.
+// 'household.persons[1].firstName' or 'household.children[0].firstName'.
+const HouseholdNamespace = "household"
+
+type personRecord struct {
+	id   int
+	json map[string]json.RawMessage
+}
+
+func parsePersonRecord(personJson json.RawMessage) (personRecord, error) {
+	var person map[string]json.RawMessage
+	if err := json.Unmarshal(personJson, &person); err != nil {
+		return personRecord{}, err
+	}
+
+	var id int
+	if err := json.Unmarshal(person["id"], &id); err != nil {
+		return personRecord{}, fmt.Errorf("person has no valid id, %w", err)
+	}
+
+	return personRecord{id: id, json: person}, nil
+}
+
+// addRelationships adds the related persons of each person to the person
+// json. The relatives of all persons are collected first and are requested
+// at once, relatives that are persons of the group are not requested again.
+func addRelationships(persons []json.RawMessage, personsEndpoint rest.PersonsEndpoint) ([]json.RawMessage, error) {
+	records := make([]personRecord, 0, len(persons))
+	knownPersons := make(map[int]json.RawMessage)
+
+	for _, personJson := range persons {
+		record, err := parsePersonRecord(personJson)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read person, %w", err)
+		}
+		records = append(records, record)
+		knownPersons[record.id] = personJson
+	}
+
+	relationships := make(map[int][]rest.PersonRelationshipResponse, len(records))
+	var missingPersonIds []int
+
+	for _, record := range records {
+		personRelationships, err := personsEndpoint.GetRelationships(record.id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve relationships of person with id %d, %w", record.id, err)
+		}
+		relationships[record.id] = personRelationships
+
+		for _, relationship := range personRelationships {
+			relativeId := relationship.PersonId()
+			if relativeId == 0 {
+				continue
+			}
+			if _, known := knownPersons[relativeId]; !known && !slices.Contains(missingPersonIds, relativeId) {
+				missingPersonIds = append(missingPersonIds, relativeId)
+			}
+		}
+	}
+
+	if len(missingPersonIds) > 0 {
+		relatives, err := personsEndpoint.GetPersons(missingPersonIds)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve related persons, %w", err)
+		}
+		for _, relativeJson := range relatives {
+			relative, err := parsePersonRecord(relativeJson)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read related person, %w", err)
+			}
+			knownPersons[relative.id] = relativeJson
+		}
+	}
+
+	result := make([]json.RawMessage, 0, len(records))
+	for _, record := range records {
+		relatives := make(map[string][]json.RawMessage)
+		for _, relationship := range relationships[record.id] {
+			if relativeJson, known := knownPersons[relationship.PersonId()]; known {
+				relationType := relationship.RelationType()
+				relatives[relationType] = append(relatives[relationType], relativeJson)
+			}
+		}
+
+		relativesJson, err := json.Marshal(relatives)
+		if err != nil {
+			return nil, err
+		}
+		record.json[RelationshipNamespace] = relativesJson
+
+		personJson, err := json.Marshal(record.json)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, personJson)
+	}
+
+	return result, nil
+}
+
+type household struct {
+	persons  []json.RawMessage
+	children []json.RawMessage
+}
+
+// replaceWithParents replaces each person by the household of its parents.
+// Parents with the same address form a single household which is exported
+// with the data of the first parent. Each household is only exported once,
+// even if several children of the household are members of the group.
+// Persons without parents are exported as their own household.
+func replaceWithParents(persons []json.RawMessage) ([]json.RawMessage, error) {
+	var households []*household
+	householdsByAddress := make(map[string]*household)
+	householdsByPerson := make(map[int]*household)
+
+	for _, personJson := range persons {
+		child, err := parsePersonRecord(personJson)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read person, %w", err)
+		}
+
+		var relatives map[string][]json.RawMessage
+		if err := json.Unmarshal(child.json[RelationshipNamespace], &relatives); err != nil {
+			relatives = nil
+		}
+		parents := relatives["parent"]
+		if len(parents) == 0 {
+			parents = []json.RawMessage{personJson}
+		}
+
+		var childHouseholds []*household
+		for _, parentJson := range parents {
+			parent, err := parsePersonRecord(parentJson)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read parent, %w", err)
+			}
+
+			h, exists := householdsByPerson[parent.id]
+			if !exists {
+				address := householdAddress(parent.json)
+				h, exists = householdsByAddress[address]
+				if !exists || address == "" {
+					h = &household{}
+					households = append(households, h)
+					if address != "" {
+						householdsByAddress[address] = h
+					}
+				}
+				h.persons = append(h.persons, parentJson)
+				householdsByPerson[parent.id] = h
+			}
+			if !slices.Contains(childHouseholds, h) {
+				childHouseholds = append(childHouseholds, h)
+			}
+		}
+
+		for _, h := range childHouseholds {
+			h.children = append(h.children, personJson)
+		}
+	}
+
+	result := make([]json.RawMessage, 0, len(households))
+	for _, h := range households {
+		var person map[string]json.RawMessage
+		if err := json.Unmarshal(h.persons[0], &person); err != nil {
+			return nil, err
+		}
+
+		householdJson, err := json.Marshal(map[string][]json.RawMessage{
+			"persons":  h.persons,
+			"children": h.children,
+		})
+		if err != nil {
+			return nil, err
+		}
+		person[HouseholdNamespace] = householdJson
+
+		personJson, err := json.Marshal(person)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, personJson)
+	}
+
+	return result, nil
+}
+
+func householdAddress(person map[string]json.RawMessage) string {
+	var parts []string
+	for _, fieldName := range []string{"street", "zip", "city"} {
+		var value string
+		if err := json.Unmarshal(person[fieldName], &value); err != nil {
+			value = ""
+		}
+		value = strings.ToLower(strings.Join(strings.Fields(value), " "))
+		if value == "" {
+			return ""
+		}
+		parts = append(parts, value)
+	}
+	return strings.Join(parts, "|")
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// RunReportFileName is the name of the machine-readable report in the run
+// directory.
+const RunReportFileName = "run-report.json"
+
+// The status of a group, an instance or a run.
+type Status string
+
+const (
+	// StatusSucceeded means that the csv file was written, or would have been
+	// written in a dry run.
+	StatusSucceeded Status = "succeeded"
+	// StatusEmpty means that the group has no members, no file is written
+	// unless the group is compared with a previous export.
+	StatusEmpty Status = "empty"
+	// StatusSkipped means that the dynamic group is not active.
+	StatusSkipped Status = "skipped"
+	// StatusFailed means that the group could not be exported.
+	StatusFailed Status = "failed"
+	// StatusPartiallyFailed means that some groups of an instance or a run
+	// failed and others did not.
+	StatusPartiallyFailed Status = "partially_failed"
+)
+
+// GroupResult is the result of the export of a group.
+type GroupResult struct {
+	Name     string `json:"name"`
+	Status   Status `json:"status"`
+	Rows     int    `json:"rows"`
+	Blocked  int    `json:"blocked"`
+	Filtered int    `json:"filtered"`
+	Warnings int    `json:"warnings"`
+	Error    string `json:"error,omitempty"`
+	// OutputPath is the csv file, it is empty if no file was written. In an
+	// encrypted run it is the path within the bundle.
+	OutputPath string `json:"output_path,omitempty"`
+	// Diff is set if the group was compared with its previous export.
+	Diff            *DiffResult `json:"diff,omitempty"`
+	DurationSeconds float64     `json:"duration_seconds"`
+}
+
+// DiffResult is the number of differences to the previous export of a
+// group.
+type DiffResult struct {
+	PreviousPath string `json:"previous_path"`
+	Added        int    `json:"added"`
+	Removed      int    `json:"removed"`
+	Changed      int    `json:"changed"`
+}
+
+// InstanceResult is the result of the groups of an instance. The error is
+// set if the instance could not be processed at all, e.g. because its token
+// could not be read.
+type InstanceResult struct {
+	Hostname string        `json:"hostname"`
+	Status   Status        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	Groups   []GroupResult `json:"groups"`
+}
+
+// RunReport aggregates the results of a run.
+type RunReport struct {
+	Status          Status           `json:"status"`
+	DryRun          bool             `json:"dry_run"`
+	Start           time.Time        `json:"start"`
+	DurationSeconds float64          `json:"duration_seconds"`
+	Instances       []InstanceResult `json:"instances"`
+	// Bundle is the encrypted bundle with the files of the run, it is empty
+	// if the files are not encrypted.
+	Bundle string `json:"bundle,omitempty"`
+}
+
+// aggregateStatus returns failed if all results failed, partially failed if
+// some failed and succeeded otherwise. Empty and skipped groups are no
+// failures.
+func aggregateStatus(statuses []Status) Status {
+	failed := 0
+	for _, status := range statuses {
+		if status == StatusFailed {
+			failed++
+		} else if status == StatusPartiallyFailed {
+			return StatusPartiallyFailed
+		}
+	}
+
+	switch {
+	case failed == 0:
+		return StatusSucceeded
+	case failed == len(statuses):
+		return StatusFailed
+	default:
+		return StatusPartiallyFailed
+	}
+}
+
+func (r *InstanceResult) finish() {
+	statuses := make([]Status, 0, len(r.Groups))
+	for _, group := range r.Groups {
+		statuses = append(statuses, group.Status)
+	}
+	r.Status = aggregateStatus(statuses)
+	if r.Error != "" {
+		r.Status = StatusFailed
+	}
+}
+
+func (r *RunReport) finish() {
+	statuses := make([]Status, 0, len(r.Instances))
+	for _, instance := range r.Instances {
+		statuses = append(statuses, instance.Status)
+	}
+	r.Status = aggregateStatus(statuses)
+	r.DurationSeconds = time.Since(r.Start).Seconds()
+}
+
+// Counts returns the number of groups per status.
+func (r RunReport) Counts() map[Status]int {
+	counts := make(map[Status]int)
+	for _, instance := range r.Instances {
+		for _, group := range instance.Groups {
+			counts[group.Status]++
+		}
+	}
+	return counts
+}
+
+func writeRunReport(path string, report RunReport) error {
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write the run report, %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GlobalOptions are the flags shared by all commands. They can be set before
+// or after the name of the command.
+type GlobalOptions struct {
+	ConfigFilePath    string
+	DataDir           string
+	OutputDir         string
+	KeepassDbFilePath string
+	// KeyFilePath is the optional key file of the KeePass database.
+	KeyFilePath string
+	// KeepassBackend reads the KeePass database, either 'native' or
+	// 'keepassxc-cli'.
+	KeepassBackend string
+	// PasswordSource selects where the KeePass password is read from, see
+	// secret.ParseSource.
+	PasswordSource string
+	// TokenFilePasswordSource selects where the password of encrypted token
+	// files is read from.
+	TokenFilePasswordSource string
+}
+
+// A Command is a subcommand like 'export' or 'validate'.
+type Command struct {
+	Name string
+	// Usage describes the arguments of the command, e.g. '<person id>'.
+	Usage       string
+	Description string
+	// SetFlags registers the flags of the command besides the global flags,
+	// it may be nil.
+	SetFlags func(flags *flag.FlagSet)
+	// Run executes the command and returns the exit code.
+	Run func(options GlobalOptions, args []string) int
+}
+
+// CLI dispatches the command line arguments to its commands. The default
+// command runs if no command is given, so 'ctRestClient -c config.yml' is
+// the same as 'ctRestClient export -c config.yml'.
+type CLI struct {
+	Name           string
+	Commands       []Command
+	DefaultCommand string
+	Defaults       GlobalOptions
+	Output         io.Writer
+}
+
+const (
+	ExitOK = 0
+	// ExitError is returned if a command failed, e.g. if no group of an
+	// export could be exported.
+	ExitError = 1
+	// ExitUsage is returned for unknown commands and invalid flags.
+	ExitUsage = 2
+	// ExitPartialFailure is returned if some groups of an export failed and
+	// others were exported.
+	ExitPartialFailure = 3
+)
+
+// Run parses the arguments without the program name and runs the command.
+func (c CLI) Run(args []string) int {
+	options := c.Defaults
+
+	globalFlags := c.newFlagSet(c.Name)
+	registerGlobalFlags(globalFlags, &options)
+	globalFlags.Usage = func() { c.printUsage() }
+	if err := globalFlags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+
+	args = globalFlags.Args()
+	name := c.DefaultCommand
+	if len(args) > 0 {
+		name = args[0]
+		args = args[1:]
+	}
+
+	if name == "help" {
+		return c.help(args)
+	}
+
+	command, exists := c.command(name)
+	if !exists {
+		fmt.Fprintf(c.Output, "unknown command '%s'\n\n", name)
+		c.printUsage()
+		return ExitUsage
+	}
+
+	flags := c.newFlagSet(c.Name + " " + command.Name)
+	registerGlobalFlags(flags, &options)
+	if command.SetFlags != nil {
+		command.SetFlags(flags)
+	}
+	flags.Usage = func() { c.printCommandUsage(command) }
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+
+	return command.Run(options, flags.Args())
+}
+
+func (c CLI) help(args []string) int {
+	if len(args) == 0 {
+		c.printUsage()
+		return ExitOK
+	}
+
+	command, exists := c.command(args[0])
+	if !exists {
+		fmt.Fprintf(c.Output, "unknown command '%s'\n\n", args[0])
+		c.printUsage()
+		return ExitUsage
+	}
+	c.printCommandUsage(command)
+	return ExitOK
+}
+
+func (c CLI) command(name string) (Command, bool) {
+	for _, command := range c.Commands {
+		if command.Name == name {
+			return command, true
+		}
+	}
+	return Command{}, false
+}
+
+func (c CLI) newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(c.Output)
+	return flags
+}
+
+func registerGlobalFlags(flags *flag.FlagSet, options *GlobalOptions) {
+	flags.StringVar(&options.ConfigFilePath, "c", options.ConfigFilePath, "the config file path")
+	flags.StringVar(&options.DataDir, "d", options.DataDir, "the data directory")
+	flags.StringVar(&options.OutputDir, "o", options.OutputDir, "the output directory")
+	flags.StringVar(&options.KeepassDbFilePath, "k", options.KeepassDbFilePath, "the Keepass DB file path")
+	flags.StringVar(&options.KeyFilePath, "key-file", options.KeyFilePath, "the key file of the Keepass DB")
+	flags.StringVar(&options.KeepassBackend, "keepass-backend", options.KeepassBackend, "reads the Keepass DB: native or keepassxc-cli")
+	flags.StringVar(&options.PasswordSource, "password-from", options.PasswordSource, "the source of the Keepass password: prompt, env:NAME, file:PATH, fd:N, stdin or keyring[:ATTRIBUTES]")
+	flags.StringVar(&options.TokenFilePasswordSource, "token-file-password-from", options.TokenFilePasswordSource, "the source of the password of encrypted token files, like -password-from")
+}
+
+func (c CLI) printUsage() {
+	fmt.Fprintf(c.Output, "Usage: %s [global options] [command] [options] [arguments]\n\n", c.Name)
+	fmt.Fprintln(c.Output, "Commands:")
+
+	width := 0
+	for _, command := range c.Commands {
+		width = max(width, len(command.Name))
+	}
+	for _, command := range c.Commands {
+		// The first line of the description is the summary of the command
+		description, _, _ := strings.Cut(command.Description, "\n")
+		if command.Name == c.DefaultCommand {
+			description += " (default)"
+		}
+		fmt.Fprintf(c.Output, "  %-*s  %s\n", width, command.Name, description)
+	}
+
+	fmt.Fprintln(c.Output)
+	fmt.Fprintln(c.Output, "Global options:")
+	c.printGlobalDefaults()
+	fmt.Fprintln(c.Output)
+	fmt.Fprintf(c.Output, "Run '%s help <command>' for the options of a command.\n", c.Name)
+}
+
+func (c CLI) printCommandUsage(command Command) {
+	usage := fmt.Sprintf("Usage: %s %s [options]", c.Name, command.Name)
+	if command.Usage != "" {
+		usage += " " + command.Usage
+	}
+	fmt.Fprintln(c.Output, usage)
+	fmt.Fprintln(c.Output)
+	fmt.Fprintln(c.Output, command.Description)
+
+	if command.SetFlags != nil {
+		flags := c.newFlagSet(command.Name)
+		command.SetFlags(flags)
+		var builder strings.Builder
+		flags.SetOutput(&builder)
+		flags.PrintDefaults()
+		if builder.Len() > 0 {
+			fmt.Fprintln(c.Output)
+			fmt.Fprintln(c.Output, "Options:")
+			fmt.Fprint(c.Output, builder.String())
+		}
+	}
+
+	fmt.Fprintln(c.Output)
+	fmt.Fprintln(c.Output, "Global options:")
+	c.printGlobalDefaults()
+}
+
+func (c CLI) printGlobalDefaults() {
+	options := c.Defaults
+	flags := c.newFlagSet(c.Name)
+	registerGlobalFlags(flags, &options)
+	flags.PrintDefaults()
+}
+
+// StringList is a flag that can be repeated, e.g. '-group a -group b'.
+type StringList []string
+
+func (l *StringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *StringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
@@ -0,0 +1,13 @@
+package cli_test
+
+import (
+    "testing"
+
+    . "github.com/onsi/ginkgo/v2"
+    . "github.com/onsi/gomega"
+)
+
+func TestClient(t *testing.T) {
+    RegisterFailHandler(Fail)
+    RunSpecs(t, "CLI Suite")
+}
\ No newline at end of file
@@ -0,0 +1,147 @@
+package cli_test
+
+import (
+	"bytes"
+	"ctRestClient/cli"
+	"flag"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CLI", func() {
+	var (
+		output      *bytes.Buffer
+		commandLine cli.CLI
+		ranCommand  string
+		ranOptions  cli.GlobalOptions
+		ranArgs     []string
+		instance    string
+	)
+
+	recordRun := func(name string) func(cli.GlobalOptions, []string) int {
+		return func(options cli.GlobalOptions, args []string) int {
+			ranCommand = name
+			ranOptions = options
+			ranArgs = args
+			return 3
+		}
+	}
+
+	BeforeEach(func() {
+		output = &bytes.Buffer{}
+		ranCommand = ""
+		ranOptions = cli.GlobalOptions{}
+		ranArgs = nil
+		instance = ""
+
+		commandLine = cli.CLI{
+			Name: "ctRestClient",
+			Commands: []cli.Command{
+				{
+					Name:        "export",
+					Description: "exports the groups",
+					Run:         recordRun("export"),
+				},
+				{
+					Name:        "person",
+					Usage:       "<person id>",
+					Description: "shows a person",
+					SetFlags: func(flags *flag.FlagSet) {
+						flags.StringVar(&instance, "instance", "", "the hostname of the instance")
+					},
+					Run: recordRun("person"),
+				},
+			},
+			DefaultCommand: "export",
+			Defaults: cli.GlobalOptions{
+				ConfigFilePath:    "config.yml",
+				DataDir:           "data",
+				OutputDir:         "exports",
+				KeepassDbFilePath: "passwords.kdbx",
+			},
+			Output: output,
+		}
+	})
+
+	It("runs the default command without a command", func() {
+		exitCode := commandLine.Run([]string{"-c", "my.yml", "-k", "tokens.kdbx"})
+
+		Expect(exitCode).To(Equal(3))
+		Expect(ranCommand).To(Equal("export"))
+		Expect(ranOptions).To(Equal(cli.GlobalOptions{
+			ConfigFilePath:    "my.yml",
+			DataDir:           "data",
+			OutputDir:         "exports",
+			KeepassDbFilePath: "tokens.kdbx",
+		}))
+	})
+
+	It("runs the default command without any argument", func() {
+		Expect(commandLine.Run([]string{})).To(Equal(3))
+		Expect(ranCommand).To(Equal("export"))
+		Expect(ranOptions.ConfigFilePath).To(Equal("config.yml"))
+	})
+
+	It("accepts global options before and after the command", func() {
+		commandLine.Run([]string{"-c", "my.yml", "person", "-d", "mydata", "-instance", "foo", "42"})
+
+		Expect(ranCommand).To(Equal("person"))
+		Expect(ranOptions.ConfigFilePath).To(Equal("my.yml"))
+		Expect(ranOptions.DataDir).To(Equal("mydata"))
+		Expect(instance).To(Equal("foo"))
+		Expect(ranArgs).To(Equal([]string{"42"}))
+	})
+
+	It("returns the usage exit code for unknown commands", func() {
+		Expect(commandLine.Run([]string{"unknown"})).To(Equal(cli.ExitUsage))
+		Expect(ranCommand).To(BeEmpty())
+		Expect(output.String()).To(ContainSubstring("unknown command 'unknown'"))
+	})
+
+	It("returns the usage exit code for unknown flags", func() {
+		Expect(commandLine.Run([]string{"export", "-instance", "foo"})).To(Equal(cli.ExitUsage))
+		Expect(ranCommand).To(BeEmpty())
+	})
+
+	It("prints the commands and global options", func() {
+		Expect(commandLine.Run([]string{"help"})).To(Equal(cli.ExitOK))
+
+		Expect(output.String()).To(ContainSubstring("  export  exports the groups (default)\n"))
+		Expect(output.String()).To(ContainSubstring("  person  shows a person\n"))
+		Expect(output.String()).To(ContainSubstring("the Keepass DB file path"))
+	})
+
+	It("prints the general help for -h", func() {
+		Expect(commandLine.Run([]string{"-h"})).To(Equal(cli.ExitOK))
+		Expect(output.String()).To(ContainSubstring("Commands:"))
+	})
+
+	It("prints the help of a command", func() {
+		Expect(commandLine.Run([]string{"help", "person"})).To(Equal(cli.ExitOK))
+
+		Expect(output.String()).To(HavePrefix("Usage: ctRestClient person [options] <person id>\n\nshows a person\n"))
+		Expect(output.String()).To(ContainSubstring("Options:\n  -instance string\n"))
+		Expect(output.String()).To(ContainSubstring("Global options:"))
+	})
+
+	It("prints the help of a command for -h", func() {
+		Expect(commandLine.Run([]string{"person", "-h"})).To(Equal(cli.ExitOK))
+
+		Expect(ranCommand).To(BeEmpty())
+		Expect(output.String()).To(HavePrefix("Usage: ctRestClient person [options] <person id>"))
+	})
+})
+
+var _ = Describe("StringList", func() {
+	It("collects the values of a repeated flag", func() {
+		var groups []string
+		flags := flag.NewFlagSet("export", flag.ContinueOnError)
+		flags.Var((*cli.StringList)(&groups), "group", "the groups")
+
+		Expect(flags.Parse([]string{"-group", "Jugend*", "--group", "Chor"})).To(Succeed())
+
+		Expect(groups).To(Equal([]string{"Jugend*", "Chor"}))
+		Expect(flags.Lookup("group").Value.String()).To(Equal("Jugend*, Chor"))
+	})
+})
@@ -0,0 +1,715 @@
+package main
+
+import (
+	"bytes"
+	"ctRestClient/app"
+	"ctRestClient/cli"
+	"ctRestClient/config"
+	"ctRestClient/csv"
+	"ctRestClient/data_provider"
+	"ctRestClient/httpclient"
+	"ctRestClient/logger"
+	"ctRestClient/output"
+	"ctRestClient/privacy"
+	"ctRestClient/rest"
+	"ctRestClient/secret"
+	"ctRestClient/validation"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func exportCommand() cli.Command {
+	var processorOptions app.ProcessorOptions
+
+	return cli.Command{
+		Name: "export",
+		Description: "Exports the members of all configured groups to CSV files.\n" +
+			"With -dry-run all groups are processed without writing any files, the number of rows,\n" +
+			"excluded persons and warnings as well as the first rows of each group are shown instead.\n" +
+			"With -instance, -group and -tag only the matching instances and groups are exported.\n" +
+			"With -diff each group is compared with its previous export by the person id.",
+		SetFlags: func(flags *flag.FlagSet) {
+			flags.BoolVar(&processorOptions.DryRun, "dry-run", false, "process all groups without writing any files")
+			flags.IntVar(&processorOptions.PreviewRows, "preview-rows", 5, "the number of rows shown per group in a dry run")
+			flags.Var((*cli.StringList)(&processorOptions.Selection.Instances), "instance", "export only the instances with a matching hostname, a glob pattern, can be repeated")
+			flags.Var((*cli.StringList)(&processorOptions.Selection.Groups), "group", "export only the groups with a matching name, a glob pattern, can be repeated")
+			flags.Var((*cli.StringList)(&processorOptions.Selection.Tags), "tag", "export only the groups with a matching tag, a glob pattern, can be repeated")
+			flags.BoolVar(&processorOptions.Diff, "diff", false, "write the added, removed and changed persons since the previous export of each group")
+		},
+		Run: func(options cli.GlobalOptions, args []string) int {
+			return runExport(options, processorOptions)
+		},
+	}
+}
+
+func runExport(options cli.GlobalOptions, processorOptions app.ProcessorOptions) int {
+	rootDir := runDir(options)
+
+	// The config is loaded before the log is opened, since the log of an
+	// encrypted run must not be written to the disk
+	config, configErr := config.LoadConfig(options.ConfigFilePath)
+	encrypted := configErr == nil && config.Output.Encryption.IsSet()
+
+	// A dry run writes no files, so the log is only written to the console
+	appLogger := logger.NewLogger("")
+	var runLog *bytes.Buffer
+	if !processorOptions.DryRun {
+		err := os.MkdirAll(rootDir, 0755)
+		if err != nil {
+			log.Fatalf("    failed to create directory: %v", err)
+		}
+		appLogger, runLog = runLogger(rootDir, encrypted)
+	}
+
+	logGeneralInfo(appLogger, getCurrentUserName(), getCurrentOSName(), getDate())
+	if processorOptions.DryRun {
+		appLogger.Info("dry run, no files are written")
+	}
+
+	if configErr != nil {
+		appLogger.Fatal(fmt.Sprintf("Failed to load config from path %s: %v", options.ConfigFilePath, configErr))
+	}
+
+	stores, err := secretStores(options, appLogger)
+	if err != nil {
+		appLogger.Fatal(err.Error())
+	}
+	// The KeePass password is checked before any instance is processed, but
+	// only if a selected instance uses it
+	selectedConfig, err := config.Select(processorOptions.Selection)
+	if err != nil {
+		appLogger.Fatal(err.Error())
+	}
+	if selectedConfig.UsesKeepass() {
+		if _, err := stores.Store(secret.KeepassStore); err != nil {
+			appLogger.Fatal(err.Error())
+		}
+	}
+
+	report, err := processInstances(options, processorOptions, *config, stores, rootDir, appLogger, runLog)
+	if err != nil {
+		appLogger.Fatal(fmt.Sprintf("Failed to process instances: %v", err))
+	}
+	return exitCode(report.Status)
+}
+
+// runDir returns a new timestamped directory of a run in the output
+// directory.
+func runDir(options cli.GlobalOptions) string {
+	return filepath.Join(options.OutputDir, time.Now().Format(output.RunDirFormat))
+}
+
+// runLogFileName is the name of the log in the run directory or the bundle.
+const runLogFileName = "ctRestClient.log"
+
+// runLogger returns the logger of a run. The log of an encrypted run names
+// the excluded persons, so it is kept in the returned buffer and added to the
+// bundle. Otherwise it is written to the run directory.
+func runLogger(rootDir string, encrypted bool) (logger.Logger, *bytes.Buffer) {
+	if encrypted {
+		runLog := &bytes.Buffer{}
+		return logger.NewLogger("", runLog), runLog
+	}
+	return logger.NewLogger(filepath.Join(rootDir, runLogFileName)), nil
+}
+
+// processInstances exports the groups of the config into the run directory.
+// The expired runs are deleted first, a failure is only logged so that the
+// export still runs. If the output is encrypted, the files and the run log
+// are written into the bundle instead.
+func processInstances(
+	options cli.GlobalOptions,
+	processorOptions app.ProcessorOptions,
+	cfg config.Config,
+	stores secret.Stores,
+	rootDir string,
+	appLogger logger.Logger,
+	runLog *bytes.Buffer,
+) (app.RunReport, error) {
+	err := output.ApplyRetention(options.OutputDir, rootDir, cfg.Output.Retention, time.Now(), processorOptions.DryRun, appLogger)
+	if err != nil {
+		appLogger.Error(fmt.Sprintf("failed to apply the retention, %v", err))
+	}
+	if cfg.Output.Latest {
+		processorOptions.LatestDir = filepath.Join(options.OutputDir, output.LatestDirName)
+	}
+
+	csvWriter := csv.NewCSVFileWriter()
+	var bundle output.Bundle
+	if cfg.Output.Encryption.IsSet() && !processorOptions.DryRun {
+		processorOptions.Bundle = filepath.Join(rootDir, cfg.Output.Encryption.BundleFileName())
+		bundle, err = newBundle(cfg.Output.Encryption, stores, processorOptions.Bundle, rootDir)
+		if err != nil {
+			return app.RunReport{}, err
+		}
+		csvWriter = bundle
+	}
+
+	report, err := app.NewInstancesProcessor(
+		cfg,
+		appLogger,
+		processorOptions,
+	).Process(
+		app.NewGroupExporter(),
+		csvWriter,
+		rootDir,
+		data_provider.NewFileDataProvider(filepath.Join(options.DataDir, "mappings/persons")),
+		data_provider.NewBlockListDataProvider(filepath.Join(options.DataDir, "blocklists"), appLogger),
+		stores,
+	)
+	if bundle != nil {
+		err = errors.Join(err, closeBundle(bundle, processorOptions.Bundle, rootDir, runLog, appLogger))
+	}
+	return report, err
+}
+
+// newBundle creates the encrypted bundle of a run with the passphrase or
+// the recipient key of the secret store.
+func newBundle(encryption config.Encryption, stores secret.Stores, path string, rootDir string) (output.Bundle, error) {
+	encryptionSecret, err := secret.Lookup(stores, encryption.SecretSource, encryption.SecretName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the encryption secret with name '%s' from %s, %w", encryption.SecretName, encryption.GetSecretSource(), err)
+	}
+	return output.NewBundle(path, rootDir, encryption, encryptionSecret)
+}
+
+// closeBundle adds the run log to the bundle and completes it. Later log
+// messages are only written to the console.
+func closeBundle(bundle output.Bundle, path string, rootDir string, runLog *bytes.Buffer, appLogger logger.Logger) error {
+	appLogger.Info(fmt.Sprintf("the files were written to the encrypted bundle '%s'", path))
+	var err error
+	if runLog != nil {
+		err = bundle.WriteFile(filepath.Join(rootDir, runLogFileName), runLog.Bytes())
+	}
+	return errors.Join(err, bundle.Close())
+}
+
+// exitCode maps the status of a run to the exit code, so that schedulers can
+// tell a clean run from a run with failed groups.
+func exitCode(status app.Status) int {
+	switch status {
+	case app.StatusSucceeded:
+		return cli.ExitOK
+	case app.StatusPartiallyFailed:
+		return cli.ExitPartialFailure
+	default:
+		return cli.ExitError
+	}
+}
+
+func validateCommand() cli.Command {
+	return cli.Command{
+		Name:        "validate",
+		Description: "Checks the config, the mapping files and the blocklists without connecting to any instance.",
+		Run:         runValidate,
+	}
+}
+
+// runValidate checks the config, mapping files and blocklists offline and
+// returns the exit code.
+func runValidate(options cli.GlobalOptions, args []string) int {
+	problems := validation.Validate(options.ConfigFilePath, options.DataDir)
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+
+	if len(problems) > 0 {
+		fmt.Printf("found %d problems\n", len(problems))
+		return cli.ExitError
+	}
+	fmt.Println("no problems found")
+	return cli.ExitOK
+}
+
+func personCommand() cli.Command {
+	var hostname string
+	var groupName string
+
+	return cli.Command{
+		Name:  "person",
+		Usage: "<person id>",
+		Description: "Shows the data of a person as returned by ChurchTools.\n" +
+			"With -group the person is shown as exported for the group, including the mappings,\n" +
+			"privacy rules and blocklists.",
+		SetFlags: func(flags *flag.FlagSet) {
+			flags.StringVar(&hostname, "instance", "", "the hostname of the instance, required if the config contains several instances")
+			flags.StringVar(&groupName, "group", "", "the name of the group")
+		},
+		Run: func(options cli.GlobalOptions, args []string) int {
+			if len(args) != 1 {
+				fmt.Println("exactly one person id must be set")
+				return cli.ExitUsage
+			}
+			personId, err := strconv.Atoi(args[0])
+			if err != nil {
+				fmt.Printf("the person id '%s' is not a number\n", args[0])
+				return cli.ExitUsage
+			}
+
+			if err := showPerson(options, hostname, groupName, personId); err != nil {
+				fmt.Println(err)
+				return cli.ExitError
+			}
+			return cli.ExitOK
+		},
+	}
+}
+
+func showPerson(options cli.GlobalOptions, hostname string, groupName string, personId int) error {
+	appLogger := logger.NewLogger("")
+
+	cfg, err := config.LoadConfig(options.ConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to load config from path %s: %v", options.ConfigFilePath, err)
+	}
+
+	instance, err := findInstance(*cfg, hostname)
+	if err != nil {
+		return err
+	}
+
+	var group *config.Group
+	if groupName != "" {
+		for i := range instance.Groups {
+			if instance.Groups[i].Name == groupName {
+				group = &instance.Groups[i]
+			}
+		}
+		if group == nil {
+			return fmt.Errorf("the group '%s' is not configured for instance '%s'", groupName, instance.Hostname)
+		}
+	}
+
+	stores, err := secretStores(options, appLogger)
+	if err != nil {
+		return err
+	}
+
+	httpClient, err := instanceClient(stores, instance)
+	if err != nil {
+		return err
+	}
+
+	persons, err := rest.NewPersonsEndpoint(httpClient).GetPerson(personId)
+	if err != nil {
+		return fmt.Errorf("failed to get person %d, %w", personId, err)
+	}
+	if len(persons) == 0 {
+		return fmt.Errorf("the person %d does not exist or is not visible for the token", personId)
+	}
+
+	if group == nil {
+		personJson, err := json.MarshalIndent(persons[0], "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(personJson))
+		return nil
+	}
+
+	privacyProfile := cfg.Privacy()
+	if cfg.UsesPrivacyRule(privacy.Hash) {
+		privacyProfile.Secret, err = secret.Lookup(stores, cfg.PrivacySecretSource, cfg.PrivacySecretName)
+		if err != nil {
+			return fmt.Errorf("failed to get privacy secret with name '%s' from %s, %w", cfg.PrivacySecretName, cfg.GetPrivacySecretSource(), err)
+		}
+	}
+
+	blocklistsDataProvider := data_provider.NewBlockListDataProvider(filepath.Join(options.DataDir, "blocklists"), appLogger)
+	blocklistsDataProvider.SetGroupsEndpoint(instance, rest.NewGroupsEndpoint(httpClient))
+
+	personData, err := csv.NewPersonData(
+		persons[:1],
+		instance,
+		*group,
+		privacyProfile,
+		data_provider.NewFileDataProvider(filepath.Join(options.DataDir, "mappings/persons")),
+		blocklistsDataProvider,
+		appLogger,
+	)
+	if err != nil {
+		return err
+	}
+
+	// The person is either exported or excluded, the header of both is printed
+	// with the values of the person
+	data := csv.CsvData(personData)
+	if len(personData.Records()) == 0 {
+		fmt.Printf("the person is not exported for group '%s'\n", group.Name)
+		data = personData.Excluded()
+	}
+	for _, record := range data.Records() {
+		for i, column := range data.Header() {
+			fmt.Printf("%s: %s\n", column, record[i])
+		}
+	}
+	return nil
+}
+
+func groupsCommand() cli.Command {
+	var hostname string
+	var generateFilePath string
+
+	return cli.Command{
+		Name:  "groups",
+		Usage: "[name pattern...]",
+		Description: "Lists the groups visible for the token of an instance.\n" +
+			"The groups can be selected by name patterns with the wildcards * and ?, e.g. 'Youth*'.\n" +
+			"With -generate a config snippet of the selected groups is written.",
+		SetFlags: func(flags *flag.FlagSet) {
+			flags.StringVar(&hostname, "instance", "", "the hostname of the instance, required if the config contains several instances")
+			flags.StringVar(&generateFilePath, "generate", "", "write a config snippet of the groups to the file, '-' writes it to the console")
+		},
+		Run: func(options cli.GlobalOptions, args []string) int {
+			if err := listGroups(options, hostname, generateFilePath, args); err != nil {
+				fmt.Println(err)
+				return cli.ExitError
+			}
+			return cli.ExitOK
+		},
+	}
+}
+
+func listGroups(options cli.GlobalOptions, hostname string, generateFilePath string, patterns []string) error {
+	if generateFilePath != "" && generateFilePath != "-" {
+		if _, err := os.Stat(generateFilePath); err == nil {
+			return fmt.Errorf("the file '%s' already exists", generateFilePath)
+		}
+	}
+
+	cfg, err := config.LoadConfig(options.ConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to load config from path %s: %v", options.ConfigFilePath, err)
+	}
+
+	instance, err := findInstance(*cfg, hostname)
+	if err != nil {
+		return err
+	}
+
+	stores, err := secretStores(options, logger.NewLogger(""))
+	if err != nil {
+		return err
+	}
+
+	httpClient, err := instanceClient(stores, instance)
+	if err != nil {
+		return err
+	}
+
+	groups, err := app.NewGroupLister().ListGroups(
+		patterns,
+		rest.NewGroupsEndpoint(httpClient),
+		rest.NewDynamicGroupsEndpoint(httpClient),
+	)
+	if err != nil {
+		return err
+	}
+
+	if generateFilePath == "" {
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "ID\tNAME\tTYPE\tMEMBERS\tDYNAMIC\tSTATUS")
+		for _, group := range groups {
+			dynamic := "no"
+			if group.Dynamic {
+				dynamic = "yes"
+			}
+			fmt.Fprintf(writer, "%d\t%s\t%s\t%d\t%s\t%s\n", group.ID, group.Name, group.Type, group.MemberCount, dynamic, group.Status)
+		}
+		writer.Flush()
+		fmt.Printf("found %d groups\n", len(groups))
+		return nil
+	}
+
+	if len(groups) == 0 {
+		return errors.New("no group found, the config snippet is not written")
+	}
+
+	groupNames := make([]string, len(groups))
+	comments := make([]string, len(groups))
+	for i, group := range groups {
+		groupNames[i] = group.Name
+		if !group.IsActive() {
+			comments[i] = fmt.Sprintf("dynamic group is %s, it is skipped during the export", group.Status)
+		}
+	}
+	snippet := config.GroupsSnippet(instance.Hostname, instance.TokenName, groupNames, comments)
+
+	if generateFilePath == "-" {
+		fmt.Print(string(snippet))
+		return nil
+	}
+	if err := os.WriteFile(generateFilePath, snippet, 0644); err != nil {
+		return fmt.Errorf("failed to write config snippet: %v", err)
+	}
+	fmt.Printf("wrote config snippet of %d groups to '%s'\n", len(groups), generateFilePath)
+	return nil
+}
+
+func fieldsCommand() cli.Command {
+	var hostname string
+	var groupName string
+	var limit int
+	var mask bool
+
+	return cli.Command{
+		Name: "fields",
+		Description: "Lists the fields of the person data of an instance or group.\n" +
+			"A sample of persons is requested and every field path is shown with its JSON types, the\n" +
+			"number of persons with a value, example values and the mapping file of the field.",
+		SetFlags: func(flags *flag.FlagSet) {
+			flags.StringVar(&hostname, "instance", "", "the hostname of the instance, required if the config contains several instances")
+			flags.StringVar(&groupName, "group", "", "the name of a group, the sample consists of its members")
+			flags.IntVar(&limit, "limit", 50, "the number of persons in the sample")
+			flags.BoolVar(&mask, "mask", false, "mask the example values")
+		},
+		Run: func(options cli.GlobalOptions, args []string) int {
+			if limit <= 0 {
+				fmt.Println("the limit must be greater than 0")
+				return cli.ExitUsage
+			}
+			if err := listFields(options, hostname, groupName, limit, mask); err != nil {
+				fmt.Println(err)
+				return cli.ExitError
+			}
+			return cli.ExitOK
+		},
+	}
+}
+
+func listFields(options cli.GlobalOptions, hostname string, groupName string, limit int, mask bool) error {
+	cfg, err := config.LoadConfig(options.ConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to load config from path %s: %v", options.ConfigFilePath, err)
+	}
+
+	instance, err := findInstance(*cfg, hostname)
+	if err != nil {
+		return err
+	}
+
+	stores, err := secretStores(options, logger.NewLogger(""))
+	if err != nil {
+		return err
+	}
+
+	httpClient, err := instanceClient(stores, instance)
+	if err != nil {
+		return err
+	}
+	personsEndpoint := rest.NewPersonsEndpoint(httpClient)
+
+	var persons []json.RawMessage
+	if groupName == "" {
+		persons, err = personsEndpoint.GetFirstPersons(limit)
+		if err != nil {
+			return fmt.Errorf("failed to get persons, %w", err)
+		}
+	} else {
+		groupsEndpoint := rest.NewGroupsEndpoint(httpClient)
+		group, err := groupsEndpoint.GetGroup(groupName)
+		if err != nil {
+			return fmt.Errorf("failed to get group '%s', %w", groupName, err)
+		}
+		members, err := groupsEndpoint.GetGroupMembers(group.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get members of group '%s', %w", groupName, err)
+		}
+
+		personIds := make([]int, 0, limit)
+		for _, member := range members[:min(limit, len(members))] {
+			personIds = append(personIds, member.PersonId)
+		}
+		persons, err = personsEndpoint.GetPersons(personIds)
+		if err != nil {
+			return fmt.Errorf("failed to get persons, %w", err)
+		}
+	}
+
+	fields, err := app.AnalyzeFields(persons)
+	if err != nil {
+		return err
+	}
+
+	mappingsDir := filepath.Join(options.DataDir, "mappings", "persons")
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "FIELD\tTYPE\tFILLED\tEXAMPLES\tMAPPING FILE")
+	for _, field := range fields {
+		examples := make([]string, len(field.Examples))
+		for i, example := range field.Examples {
+			examples[i] = example
+			if mask {
+				examples[i], _ = privacy.Mask.Apply(example, "")
+			}
+		}
+
+		mappingFile := ""
+		if len(field.Examples) > 0 {
+			mappingFile, _ = data_provider.FindMappingFile(mappingsDir, field.Path, instance, config.Group{Name: groupName})
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%d/%d\t%s\t%s\n",
+			field.Path,
+			strings.Join(field.Types, "|"),
+			field.Filled,
+			len(persons),
+			strings.Join(examples, ", "),
+			mappingFile,
+		)
+	}
+	writer.Flush()
+	fmt.Printf("found %d fields in %d persons\n", len(fields), len(persons))
+	return nil
+}
+
+func initCommand() cli.Command {
+	var hostname string
+	var tokenName string
+	var force bool
+
+	return cli.Command{
+		Name:        "init",
+		Description: "Creates a config file and the data and output directories.",
+		SetFlags: func(flags *flag.FlagSet) {
+			flags.StringVar(&hostname, "hostname", "my-church.church.tools", "the hostname of the instance")
+			flags.StringVar(&tokenName, "token-name", "my-church", "the name of the KeePass entry that contains the token")
+			flags.BoolVar(&force, "force", false, "overwrite an existing config file")
+		},
+		Run: func(options cli.GlobalOptions, args []string) int {
+			if _, err := os.Stat(options.ConfigFilePath); err == nil && !force {
+				fmt.Printf("the config file '%s' already exists, use -force to overwrite it\n", options.ConfigFilePath)
+				return cli.ExitError
+			}
+
+			err := os.WriteFile(options.ConfigFilePath, config.ExampleConfig(hostname, tokenName), 0644)
+			if err != nil {
+				fmt.Printf("failed to write config file: %v\n", err)
+				return cli.ExitError
+			}
+			fmt.Printf("created config file '%s'\n", options.ConfigFilePath)
+
+			for _, dir := range []string{
+				filepath.Join(options.DataDir, "mappings", "persons"),
+				filepath.Join(options.DataDir, "blocklists"),
+				options.OutputDir,
+			} {
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					fmt.Printf("failed to create directory: %v\n", err)
+					return cli.ExitError
+				}
+				fmt.Printf("created directory '%s'\n", dir)
+			}
+			return cli.ExitOK
+		},
+	}
+}
+
+func versionCommand() cli.Command {
+	return cli.Command{
+		Name:        "version",
+		Description: "Shows the version of ctRestClient.",
+		Run: func(options cli.GlobalOptions, args []string) int {
+			fmt.Printf("ctRestClient %s (%s/%s)\n", version, runtime.GOOS, runtime.GOARCH)
+			return cli.ExitOK
+		},
+	}
+}
+
+// openKeepass reads the password of the KeePass database from the selected
+// source and checks it.
+func openKeepass(options cli.GlobalOptions, stdin *secret.Stdin, appLogger logger.Logger) (app.KeepassCli, error) {
+	passwordSource, err := secret.ParseSource(options.PasswordSource, secret.KeepassPassword, stdin)
+	if err != nil {
+		return nil, fmt.Errorf("invalid password source: %v", err)
+	}
+	appLogger.Info(fmt.Sprintf("reading the Keepass password from %s", passwordSource.Description()))
+
+	keepassDbPassword, err := passwordSource.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password: %v", err)
+	}
+
+	var keepassCli app.KeepassCli
+	switch options.KeepassBackend {
+	case "native":
+		keepassCli, err = app.NewKeepassReader(options.KeepassDbFilePath, keepassDbPassword, options.KeyFilePath, appLogger)
+	case "keepassxc-cli":
+		keepassCli, err = app.NewKeepassCli(options.KeepassDbFilePath, keepassDbPassword, options.KeyFilePath, appLogger)
+	default:
+		return nil, fmt.Errorf("unknown Keepass backend '%s', use native or keepassxc-cli", options.KeepassBackend)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Keepass CLI: %v", err)
+	}
+
+	validPassword, err := keepassCli.IsPasswordValid(keepassDbPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed check keepass password: %v", err)
+	}
+	if !validPassword {
+		return nil, errors.New("the keepass password is invalid")
+	}
+	return keepassCli, nil
+}
+
+// secretStores returns the stores of the token sources. The KeePass
+// database is only opened when a secret is read from it. Stdin is shared by
+// the KeePass password and the token file password, only the one read first
+// gets it.
+func secretStores(options cli.GlobalOptions, appLogger logger.Logger) (secret.Stores, error) {
+	stdin := secret.NewStdin(os.Stdin)
+	tokenFilePassword, err := secret.ParseSource(options.TokenFilePasswordSource, secret.TokenFilePassword, stdin)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token file password source: %v", err)
+	}
+
+	return secret.NewStores(secret.StoresOptions{
+		Keepass: func() (secret.Store, error) {
+			keepassCli, err := openKeepass(options, stdin, appLogger)
+			if err != nil {
+				return nil, err
+			}
+			return secret.StoreFunc(keepassCli.GetPassword), nil
+		},
+		TokenFilePassword: tokenFilePassword,
+		BaseDir:           filepath.Dir(options.ConfigFilePath),
+	}), nil
+}
+
+// instanceClient returns the HTTP client of the instance with the token
+// from its token source.
+func instanceClient(stores secret.Stores, instance config.Instance) (httpclient.HTTPClient, error) {
+	token, err := secret.Lookup(stores, instance.TokenSource, instance.TokenName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token with name '%s' from %s, %w", instance.TokenName, instance.GetTokenSource(), err)
+	}
+	return httpclient.NewHTTPClient(instance.Hostname, token), nil
+}
+
+// findInstance returns the instance with the hostname. The hostname may be
+// empty if the config contains a single instance.
+func findInstance(cfg config.Config, hostname string) (config.Instance, error) {
+	if hostname == "" {
+		if len(cfg.Instances) != 1 {
+			return config.Instance{}, fmt.Errorf("the config contains %d instances, select one with -instance", len(cfg.Instances))
+		}
+		return cfg.Instances[0], nil
+	}
+
+	for _, instance := range cfg.Instances {
+		if instance.Hostname == hostname {
+			return instance, nil
+		}
+	}
+	return config.Instance{}, fmt.Errorf("the instance '%s' is not configured", hostname)
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"ctRestClient/privacy"
+	"ctRestClient/secret"
 	"errors"
 	"fmt"
 	"os"
@@ -10,17 +12,46 @@ import (
 
 type Config struct {
 	Instances []Instance `yaml:"instances"`
+
+	// PrivacyProfiles are named sets of privacy rules by field name,
+	// PrivacyProfile selects the profile that applies to all groups.
+	PrivacyProfiles map[string]map[string]privacy.Rule `yaml:"privacy_profiles"`
+	PrivacyProfile  string                             `yaml:"privacy_profile"`
+
+	// PrivacySecretName is the name of the KeePass entry that contains the
+	// key of hashed values.
+	PrivacySecretName string `yaml:"privacy_secret_name"`
+	// PrivacySecretSource is the token source of the privacy secret, the
+	// KeePass database by default.
+	PrivacySecretSource string `yaml:"privacy_secret_source"`
+
+	// Output defines the paths of the exported files, the retention of the
+	// export directories, the directory of the latest files and the
+	// encryption.
+	Output Output `yaml:"output"`
 }
 
 type Instance struct {
-	Hostname  string  `yaml:"hostname"`
-	TokenName string  `yaml:"token_name"`
-	Groups    []Group `yaml:"groups"`
+	Hostname string `yaml:"hostname"`
+	// Alias is a short name of the instance for the output path, the
+	// hostname if it is not set.
+	Alias     string `yaml:"alias"`
+	TokenName string `yaml:"token_name"`
+	// TokenSource selects the store of the token like 'env' or
+	// 'file:tokens.yml', the KeePass database by default, see secret.Stores.
+	TokenSource string `yaml:"token_source"`
+	// Schedule is the cron schedule of the groups in the serve mode, a group
+	// may override it.
+	Schedule string  `yaml:"schedule"`
+	Groups   []Group `yaml:"groups"`
 }
 
 type FieldInformation struct {
-	FieldName  string `yaml:"fieldname"`
-	ColumnName string `yaml:"columnname"`
+	FieldName  string       `yaml:"fieldname"`
+	ColumnName string       `yaml:"columnname"`
+	Mapping    Mapping      `yaml:"mapping"`
+	Privacy    privacy.Rule `yaml:"privacy"`
+	Mapped     *bool        `yaml:"mapped"`
 }
 
 func LoadConfig(filePath string) (*Config, error) {
@@ -35,7 +66,7 @@ func LoadConfig(filePath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to load invalid config file, %w", err)
 	}
 
-	err = config.validate()
+	err = config.Validate()
 	if err != nil {
 		return nil, fmt.Errorf("failed to validate the config file, %w", err)
 	}
@@ -43,7 +74,9 @@ func LoadConfig(filePath string) (*Config, error) {
 	return &config, nil
 }
 
-func (c Config) validate() error {
+// Validate checks that all mandatory properties are set and that the
+// properties of all groups are valid.
+func (c Config) Validate() error {
 	if len(c.Instances) == 0 {
 		return errors.New("property instances is not set")
 	}
@@ -54,18 +87,111 @@ func (c Config) validate() error {
 		if instance.TokenName == "" {
 			return errors.New("property token_name is not set")
 		}
+		if err := secret.ValidateTokenSource(instance.TokenSource); err != nil {
+			return fmt.Errorf("invalid token_source of instance '%s', %w", instance.Hostname, err)
+		}
+		if instance.Schedule != "" {
+			if _, err := ParseSchedule(instance.Schedule); err != nil {
+				return fmt.Errorf("invalid schedule of instance '%s', %w", instance.Hostname, err)
+			}
+		}
 
 		if len(instance.Groups) == 0 {
 			return errors.New("property groups is not set")
 		}
 		for _, group := range instance.Groups {
-			if group.Name == "" {
-				return errors.New("property name is not set")
+			if err := group.Validate(); err != nil {
+				return err
 			}
-			if len(group.Fields) == 0 {
-				return errors.New("property fields is not set")
+		}
+	}
+	if c.PrivacyProfile != "" {
+		if _, exists := c.PrivacyProfiles[c.PrivacyProfile]; !exists {
+			return fmt.Errorf("privacy profile '%s' is not defined", c.PrivacyProfile)
+		}
+	}
+	if c.UsesPrivacyRule(privacy.Hash) && c.PrivacySecretName == "" {
+		return errors.New("property privacy_secret_name is not set, it is required for hashed fields")
+	}
+	if err := secret.ValidateTokenSource(c.PrivacySecretSource); err != nil {
+		return fmt.Errorf("invalid privacy_secret_source, %w", err)
+	}
+	if err := c.Output.Validate(); err != nil {
+		return fmt.Errorf("invalid output, %w", err)
+	}
+	if c.Output.UsesPlaceholder("group_id") {
+		for _, instance := range c.Instances {
+			for _, group := range instance.Groups {
+				if group.IsVirtual() {
+					return fmt.Errorf("invalid output, property path uses '{group_id}' but the virtual group '%s' has no ID", group.Name)
+				}
 			}
 		}
 	}
 	return nil
 }
+
+// GetAlias returns the alias of the instance, the hostname if it is not set.
+func (i Instance) GetAlias() string {
+	if i.Alias == "" {
+		return i.Hostname
+	}
+	return i.Alias
+}
+
+// GetTokenSource returns the token source, 'keepass' if it is not set.
+func (i Instance) GetTokenSource() string {
+	if i.TokenSource == "" {
+		return secret.KeepassStore
+	}
+	return i.TokenSource
+}
+
+// GetPrivacySecretSource returns the token source of the privacy secret,
+// 'keepass' if it is not set.
+func (c Config) GetPrivacySecretSource() string {
+	if c.PrivacySecretSource == "" {
+		return secret.KeepassStore
+	}
+	return c.PrivacySecretSource
+}
+
+// UsesKeepass returns true if a token, the privacy secret or the secret of the
+// encryption is read from the KeePass database.
+func (c Config) UsesKeepass() bool {
+	for _, instance := range c.Instances {
+		if secret.IsKeepass(instance.TokenSource) {
+			return true
+		}
+	}
+	if c.Output.Encryption.IsSet() && secret.IsKeepass(c.Output.Encryption.SecretSource) {
+		return true
+	}
+	return c.UsesPrivacyRule(privacy.Hash) && secret.IsKeepass(c.PrivacySecretSource)
+}
+
+// Privacy returns the rules of the selected privacy profile. The secret of
+// hashed values is not set.
+func (c Config) Privacy() privacy.Profile {
+	return privacy.Profile{Rules: c.PrivacyProfiles[c.PrivacyProfile]}
+}
+
+// UsesPrivacyRule returns true if a field of any group or the selected
+// privacy profile uses the rule.
+func (c Config) UsesPrivacyRule(rule privacy.Rule) bool {
+	for _, profileRule := range c.PrivacyProfiles[c.PrivacyProfile] {
+		if profileRule == rule {
+			return true
+		}
+	}
+	for _, instance := range c.Instances {
+		for _, group := range instance.Groups {
+			for _, field := range group.Fields {
+				if field.GetPrivacy() == rule {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -2,11 +2,14 @@ package config_test
 
 import (
 	"os"
+	"path/filepath"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"ctRestClient/config"
+	"ctRestClient/privacy"
 	"ctRestClient/testutil"
 )
 
@@ -416,13 +419,512 @@ var _ = Describe("Config", func() {
 
 				cfg, err := config.LoadConfig(tempFile.Name())
 				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(ContainSubstring("failed to load invalid config file, both 'fieldname' and 'columnname' must be set"))
+				Expect(err.Error()).To(ContainSubstring("failed to load invalid config file, line 7: both 'fieldname' and 'columnname' must be set"))
+				Expect(cfg).To(BeNil())
+			})
+		})
+
+		var _ = Describe("privacy properties", func() {
+			It("returns the selected privacy profile", func() {
+				yamlContent := testutil.YamlToByteArray(`
+					---
+					privacy_profile: print_shop
+					privacy_secret_name: privacy
+					privacy_profiles:
+					  print_shop:
+					    email: hash
+					    birthday: month
+					instances:
+					- hostname: foo
+					  token_name: foo
+					  groups:
+					  - name: foo_group_0
+					    fields: [id]
+					`)
+				_, err := tempFile.Write([]byte(yamlContent))
+				Expect(err).ToNot(HaveOccurred())
+				tempFile.Close()
+
+				cfg, err := config.LoadConfig(tempFile.Name())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cfg.Privacy().Rules).To(Equal(map[string]privacy.Rule{"email": privacy.Hash, "birthday": privacy.Month}))
+				Expect(cfg.UsesPrivacyRule(privacy.Hash)).To(BeTrue())
+			})
+
+			It("returns an error if the privacy profile is not defined", func() {
+				yamlContent := testutil.YamlToByteArray(`
+					---
+					privacy_profile: print_shop
+					instances:
+					- hostname: foo
+					  token_name: foo
+					  groups:
+					  - name: foo_group_0
+					    fields: [id]
+					`)
+				_, err := tempFile.Write([]byte(yamlContent))
+				Expect(err).ToNot(HaveOccurred())
+				tempFile.Close()
+
+				cfg, err := config.LoadConfig(tempFile.Name())
+				Expect(err).To(MatchError("failed to validate the config file, privacy profile 'print_shop' is not defined"))
+				Expect(cfg).To(BeNil())
+			})
+
+			It("returns an error if hashed fields have no secret", func() {
+				yamlContent := testutil.YamlToByteArray(`
+					---
+					instances:
+					- hostname: foo
+					  token_name: foo
+					  groups:
+					  - name: foo_group_0
+					    fields:
+					    - {fieldname: email, columnname: email, privacy: hash}
+					`)
+				_, err := tempFile.Write([]byte(yamlContent))
+				Expect(err).ToNot(HaveOccurred())
+				tempFile.Close()
+
+				cfg, err := config.LoadConfig(tempFile.Name())
+				Expect(err).To(MatchError("failed to validate the config file, property privacy_secret_name is not set, it is required for hashed fields"))
+				Expect(cfg).To(BeNil())
+			})
+
+			It("returns an error if a field with a mapping is not mapped", func() {
+				yamlContent := testutil.YamlToByteArray(`
+					---
+					instances:
+					- hostname: foo
+					  token_name: foo
+					  groups:
+					  - name: foo_group_0
+					    fields:
+					    - {fieldname: statusId, columnname: status, mapped: false, mapping: {1: Mitglied}}
+					`)
+				_, err := tempFile.Write([]byte(yamlContent))
+				Expect(err).ToNot(HaveOccurred())
+				tempFile.Close()
+
+				cfg, err := config.LoadConfig(tempFile.Name())
+				Expect(err).To(MatchError("failed to validate the config file, field 'statusId' of group 'foo_group_0' has a mapping but 'mapped' is false"))
+				Expect(cfg).To(BeNil())
+			})
+		})
+
+		var _ = Describe("token source properties", func() {
+			It("reads the token source of the instances", func() {
+				yamlContent := testutil.YamlToByteArray(`
+					---
+					instances:
+					- hostname: foo
+					  token_name: FOO_TOKEN
+					  token_source: env
+					  groups:
+					  - name: foo_group_0
+					    fields: [id]
+					- hostname: bar
+					  token_name: bar
+					  token_source: file:tokens.yml
+					  groups:
+					  - name: bar_group_0
+					    fields: [id]
+					`)
+				_, err := tempFile.Write([]byte(yamlContent))
+				Expect(err).ToNot(HaveOccurred())
+				tempFile.Close()
+
+				cfg, err := config.LoadConfig(tempFile.Name())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cfg.Instances[0].GetTokenSource()).To(Equal("env"))
+				Expect(cfg.Instances[1].GetTokenSource()).To(Equal("file:tokens.yml"))
+				Expect(cfg.GetPrivacySecretSource()).To(Equal("keepass"))
+				Expect(cfg.UsesKeepass()).To(BeFalse())
+			})
+
+			It("uses the KeePass database by default", func() {
+				yamlContent := testutil.YamlToByteArray(`
+					---
+					instances:
+					- hostname: foo
+					  token_name: FOO_TOKEN
+					  token_source: env
+					  groups:
+					  - name: foo_group_0
+					    fields: [id]
+					- hostname: bar
+					  token_name: bar
+					  groups:
+					  - name: bar_group_0
+					    fields: [id]
+					`)
+				_, err := tempFile.Write([]byte(yamlContent))
+				Expect(err).ToNot(HaveOccurred())
+				tempFile.Close()
+
+				cfg, err := config.LoadConfig(tempFile.Name())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cfg.Instances[1].GetTokenSource()).To(Equal("keepass"))
+				Expect(cfg.UsesKeepass()).To(BeTrue())
+			})
+
+			It("returns an error if the token source is invalid", func() {
+				yamlContent := testutil.YamlToByteArray(`
+					---
+					instances:
+					- hostname: foo
+					  token_name: foo
+					  token_source: vault
+					  groups:
+					  - name: foo_group_0
+					    fields: [id]
+					`)
+				_, err := tempFile.Write([]byte(yamlContent))
+				Expect(err).ToNot(HaveOccurred())
+				tempFile.Close()
+
+				cfg, err := config.LoadConfig(tempFile.Name())
+				Expect(err).To(MatchError("failed to validate the config file, invalid token_source of instance 'foo', unknown token source 'vault', use keepass, env, file, encrypted or pass"))
+				Expect(cfg).To(BeNil())
+			})
+		})
+
+		var _ = Describe("set property errors", func() {
+			It("returns an error if more than one set operation is defined", func() {
+				yamlContent := testutil.YamlToByteArray(`
+					---
+					instances:
+					- hostname: foo
+					  token_name: foo
+					  groups:
+					  - name: foo_group_0
+					    set:
+					      union: [a, b]
+					      difference: [a, b]
+					    fields: [id]
+					`)
+				_, err := tempFile.Write([]byte(yamlContent))
+				Expect(err).ToNot(HaveOccurred())
+				tempFile.Close()
+
+				cfg, err := config.LoadConfig(tempFile.Name())
+				Expect(err).To(MatchError("failed to validate the config file, property set of group 'foo_group_0' is invalid, exactly one of union, intersection and difference must be set"))
+				Expect(cfg).To(BeNil())
+			})
+
+			It("returns an error if a set operation has less than two groups", func() {
+				yamlContent := testutil.YamlToByteArray(`
+					---
+					instances:
+					- hostname: foo
+					  token_name: foo
+					  groups:
+					  - name: foo_group_0
+					    set:
+					      intersection: [a]
+					    fields: [id]
+					`)
+				_, err := tempFile.Write([]byte(yamlContent))
+				Expect(err).ToNot(HaveOccurred())
+				tempFile.Close()
+
+				cfg, err := config.LoadConfig(tempFile.Name())
+				Expect(err).To(MatchError("failed to validate the config file, property set of group 'foo_group_0' is invalid, at least two groups must be set"))
+				Expect(cfg).To(BeNil())
+			})
+		})
+
+		var _ = Describe("tags property", func() {
+			It("reads the tags of a group", func() {
+				yamlContent := testutil.YamlToByteArray(`
+					---
+					instances:
+					- hostname: foo
+					  token_name: foo
+					  groups:
+					  - name: foo_group_0
+					    tags: [monthly, christmas-letters]
+					    fields: [id]
+					`)
+				_, err := tempFile.Write([]byte(yamlContent))
+				Expect(err).ToNot(HaveOccurred())
+				tempFile.Close()
+
+				cfg, err := config.LoadConfig(tempFile.Name())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cfg.Instances[0].Groups[0].Tags).To(Equal([]string{"monthly", "christmas-letters"}))
+			})
+
+			It("returns an error if a tag is empty", func() {
+				yamlContent := testutil.YamlToByteArray(`
+					---
+					instances:
+					- hostname: foo
+					  token_name: foo
+					  groups:
+					  - name: foo_group_0
+					    tags: [monthly, ""]
+					    fields: [id]
+					`)
+				_, err := tempFile.Write([]byte(yamlContent))
+				Expect(err).ToNot(HaveOccurred())
+				tempFile.Close()
+
+				cfg, err := config.LoadConfig(tempFile.Name())
+				Expect(err).To(MatchError("failed to validate the config file, property tags of group 'foo_group_0' contains an empty tag"))
+				Expect(cfg).To(BeNil())
+			})
+		})
+
+		var _ = Describe("schedule properties", func() {
+			It("reads the schedules of instances and groups", func() {
+				yamlContent := testutil.YamlToByteArray(`
+					---
+					instances:
+					- hostname: foo
+					  token_name: foo
+					  schedule: 0 6 * * *
+					  groups:
+					  - name: foo_group_0
+					    fields: [id]
+					  - name: foo_group_1
+					    schedule: "@weekly"
+					    fields: [id]
+					`)
+				_, err := tempFile.Write([]byte(yamlContent))
+				Expect(err).ToNot(HaveOccurred())
+				tempFile.Close()
+
+				cfg, err := config.LoadConfig(tempFile.Name())
+				Expect(err).ToNot(HaveOccurred())
+				instance := cfg.Instances[0]
+				Expect(instance.GroupSchedule(instance.Groups[0])).To(Equal("0 6 * * *"))
+				Expect(instance.GroupSchedule(instance.Groups[1])).To(Equal("@weekly"))
+				Expect(cfg.Scheduled()).To(BeTrue())
+			})
+
+			It("returns an error if a schedule is invalid", func() {
+				yamlContent := testutil.YamlToByteArray(`
+					---
+					instances:
+					- hostname: foo
+					  token_name: foo
+					  groups:
+					  - name: foo_group_0
+					    schedule: 0 25 * * *
+					    fields: [id]
+					`)
+				_, err := tempFile.Write([]byte(yamlContent))
+				Expect(err).ToNot(HaveOccurred())
+				tempFile.Close()
+
+				cfg, err := config.LoadConfig(tempFile.Name())
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("failed to validate the config file, property schedule of group 'foo_group_0' is invalid"))
+				Expect(cfg).To(BeNil())
+			})
+		})
+
+		var _ = Describe("output properties", func() {
+			It("reads the retention and the latest directory", func() {
+				yamlContent := testutil.YamlToByteArray(`
+					---
+					instances:
+					- hostname: foo
+					  token_name: foo
+					  groups:
+					  - name: foo_group_0
+					    fields: [id]
+					output:
+					  latest: true
+					  retention:
+					    keep_runs: 10
+					    keep_days: 90
+					    secure_delete: true
+					`)
+				_, err := tempFile.Write([]byte(yamlContent))
+				Expect(err).ToNot(HaveOccurred())
+				tempFile.Close()
+
+				cfg, err := config.LoadConfig(tempFile.Name())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cfg.Output).To(Equal(config.Output{
+					Latest:    true,
+					Retention: config.Retention{KeepRuns: 10, KeepDays: 90, SecureDelete: true},
+				}))
+				Expect(cfg.Output.Retention.IsSet()).To(BeTrue())
+			})
+
+			It("returns an error if a limit is negative", func() {
+				yamlContent := testutil.YamlToByteArray(`
+					---
+					instances:
+					- hostname: foo
+					  token_name: foo
+					  groups:
+					  - name: foo_group_0
+					    fields: [id]
+					output:
+					  retention:
+					    keep_days: -1
+					`)
+				_, err := tempFile.Write([]byte(yamlContent))
+				Expect(err).ToNot(HaveOccurred())
+				tempFile.Close()
+
+				cfg, err := config.LoadConfig(tempFile.Name())
+				Expect(err).To(MatchError("failed to validate the config file, invalid output, property keep_days of retention must not be negative, got -1"))
+				Expect(cfg).To(BeNil())
+			})
+
+			It("returns the paths of the groups", func() {
+				output := config.Output{Path: "{instance}/{year}/{month}/{group}_{group_id}_{date}_{time}.{ext}"}
+				Expect(output.Validate()).To(Succeed())
+
+				path := output.GroupPath(config.PathValues{
+					Instance: config.Instance{Hostname: "foo.church.tools", Alias: "Zürich Süd"},
+					Group:    config.Group{Name: "Chor/Choir"},
+					GroupID:  42,
+					Time:     time.Date(2025, 3, 1, 6, 5, 9, 0, time.Local),
+				})
+				Expect(path).To(Equal(filepath.Join("Zuerich_Sued", "2025", "03", "ChorChoir_42_2025-03-01_06-05-09.csv")))
+				Expect(output.UsesPlaceholder("group_id")).To(BeTrue())
+			})
+
+			It("uses the hostname and the group name by default", func() {
+				path := config.Output{}.GroupPath(config.PathValues{
+					Instance: config.Instance{Hostname: "foo.church.tools"},
+					Group:    config.Group{Name: "Youth Group"},
+				})
+				Expect(path).To(Equal(filepath.Join("foo.church.tools", "Youth_Group.csv")))
+			})
+
+			DescribeTable("returns an error if the path is invalid",
+				func(path string, message string) {
+					Expect(config.Output{Path: path}.Validate()).To(MatchError(message))
+				},
+				Entry("unknown placeholder", "{hostname}/{name}.{ext}", "property path contains the unknown placeholder '{name}'"),
+				Entry("missing extension", "{hostname}/{group}.csv", "property path '{hostname}/{group}.csv' must end with '.{ext}'"),
+				Entry("absolute path", "/exports/{group}.{ext}", "property path '/exports/{group}.{ext}' must be a relative path within the run directory"),
+				Entry("parent directory", "../{group}.{ext}", "property path '../{group}.{ext}' must be a relative path within the run directory"),
+			)
+
+			It("returns an error if a virtual group is exported to a path with the group ID", func() {
+				yamlContent := testutil.YamlToByteArray(`
+					---
+					instances:
+					- hostname: foo
+					  token_name: foo
+					  groups:
+					  - name: all_youth
+					    set:
+					      union: [boys, girls]
+					    fields: [id]
+					output:
+					  path: "{group_id}.{ext}"
+					`)
+				_, err := tempFile.Write([]byte(yamlContent))
+				Expect(err).ToNot(HaveOccurred())
+				tempFile.Close()
+
+				_, err = config.LoadConfig(tempFile.Name())
+				Expect(err).To(MatchError("failed to validate the config file, invalid output, property path uses '{group_id}' but the virtual group 'all_youth' has no ID"))
+			})
+
+			It("reads the encryption", func() {
+				yamlContent := testutil.YamlToByteArray(`
+					---
+					instances:
+					- hostname: foo
+					  token_name: foo
+					  token_source: env
+					  groups:
+					  - name: foo_group_0
+					    fields: [id]
+					output:
+					  encryption:
+					    format: age
+					    secret_name: export_recipient
+					`)
+				_, err := tempFile.Write([]byte(yamlContent))
+				Expect(err).ToNot(HaveOccurred())
+				tempFile.Close()
+
+				cfg, err := config.LoadConfig(tempFile.Name())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cfg.Output.Encryption).To(Equal(config.Encryption{Format: config.EncryptionAge, SecretName: "export_recipient"}))
+				Expect(cfg.Output.Encryption.IsSet()).To(BeTrue())
+				Expect(cfg.Output.Encryption.BundleFileName()).To(Equal("export.zip.age"))
+				Expect(cfg.Output.Encryption.GetSecretSource()).To(Equal("keepass"))
+				Expect(cfg.UsesKeepass()).To(BeTrue())
+			})
+
+			DescribeTable("returns an error if the encryption is invalid",
+				func(output config.Output, message string) {
+					Expect(output.Validate()).To(MatchError(message))
+				},
+				Entry("unknown format", config.Output{Encryption: config.Encryption{Format: "rar", SecretName: "foo"}},
+					"property format of encryption must be 'zip', 'age' or 'openpgp', got 'rar'"),
+				Entry("missing secret name", config.Output{Encryption: config.Encryption{Format: "zip"}},
+					"property secret_name of encryption is not set"),
+				Entry("latest directory", config.Output{Latest: true, Encryption: config.Encryption{Format: "openpgp", SecretName: "foo"}},
+					"property latest cannot be combined with encryption"),
+			)
+		})
+
+		var _ = Describe("filter property errors", func() {
+			It("returns an error if the filter is invalid", func() {
+				yamlContent := testutil.YamlToByteArray(`
+					---
+					instances:
+					- hostname: foo
+					  token_name: foo
+					  groups:
+					  - name: foo_group_0
+					    filter: age(birthday) >
+					    fields: [id]
+					`)
+				_, err := tempFile.Write([]byte(yamlContent))
+				Expect(err).ToNot(HaveOccurred())
+				tempFile.Close()
+
+				cfg, err := config.LoadConfig(tempFile.Name())
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("property filter of group 'foo_group_0' is invalid, unexpected end of expression at position 16"))
 				Expect(cfg).To(BeNil())
 			})
 		})
 	})
 
+	var _ = Describe("ExampleConfig", func() {
+		It("returns a valid config", func() {
+			_, err := tempFile.Write(config.ExampleConfig("foo.church.tools", "foo"))
+			Expect(err).ToNot(HaveOccurred())
+			tempFile.Close()
 
+			cfg, err := config.LoadConfig(tempFile.Name())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cfg.Instances[0].Hostname).To(Equal("foo.church.tools"))
+			Expect(cfg.Instances[0].TokenName).To(Equal("foo"))
+		})
+	})
+
+	var _ = Describe("GroupsSnippet", func() {
+		It("returns a valid config with all groups", func() {
+			snippet := config.GroupsSnippet("foo.church.tools", "foo", []string{"Youth: Teens", "'Bar' Group", "true"}, []string{"", "inactive"})
+			Expect(string(snippet)).To(ContainSubstring("    # inactive\n    - name: '''Bar'' Group'\n"))
 
+			_, err := tempFile.Write(snippet)
+			Expect(err).ToNot(HaveOccurred())
+			tempFile.Close()
 
+			cfg, err := config.LoadConfig(tempFile.Name())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cfg.Instances[0].Groups).To(HaveLen(3))
+			Expect(cfg.Instances[0].Groups[0].Name).To(Equal("Youth: Teens"))
+			Expect(cfg.Instances[0].Groups[1].Name).To(Equal("'Bar' Group"))
+			Expect(cfg.Instances[0].Groups[2].Name).To(Equal("true"))
+			Expect(cfg.Instances[0].Groups[2].Fields).To(HaveLen(7))
+		})
+	})
 })
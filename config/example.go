@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const exampleConfig = `# Configuration of ctRestClient, see the user manual for all options.
+instances:
+  - hostname: %s
+    # name of the KeePass entry that contains the login token
+    token_name: %s
+    groups:
+%s`
+
+const exampleFields = "[id, firstName, lastName, street, zip, city, email]"
+
+// ExampleConfig returns the content of a new config file with a single
+// instance and group.
+func ExampleConfig(hostname string, tokenName string) []byte {
+	return GroupsSnippet(hostname, tokenName, []string{"My Group"}, nil)
+}
+
+// GroupsSnippet returns the config of an instance with the groups. The
+// comments are added above the groups with the same index, e.g. to mark
+// groups that are not active.
+func GroupsSnippet(hostname string, tokenName string, groupNames []string, comments []string) []byte {
+	var groups strings.Builder
+	for i, groupName := range groupNames {
+		if i < len(comments) && comments[i] != "" {
+			fmt.Fprintf(&groups, "    # %s\n", comments[i])
+		}
+		fmt.Fprintf(&groups, "    - name: %s\n", yamlScalar(groupName))
+		fmt.Fprintf(&groups, "      fields: %s\n", exampleFields)
+	}
+	return []byte(fmt.Sprintf(exampleConfig, yamlScalar(hostname), yamlScalar(tokenName), groups.String()))
+}
+
+// yamlScalar quotes a value if it is not a plain YAML string.
+func yamlScalar(value string) string {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%q", value)
+	}
+	return strings.TrimSuffix(string(data), "\n")
+}
@@ -1,6 +1,14 @@
 package config
 
-import "fmt"
+import (
+	"ctRestClient/privacy"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
 
 // A Field can be either a simple string or a structured object
 // with field and column names.
@@ -9,28 +17,53 @@ type Field struct {
 	Object    *FieldInformation
 }
 
-func (f *Field) UnmarshalYAML(unmarshal func(interface{}) error) error {
-	// Try unmarshaling as a simple string
-	var s string
-	if err := unmarshal(&s); err == nil {
+func (f *Field) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		var s string
+		if err := node.Decode(&s); err != nil {
+			return err
+		}
 		f.FieldName = &s
 		return nil
-	}
+	case yaml.MappingNode:
+		// Unknown keys are returned as type errors like the ones of
+		// KnownFields, so that decoding continues and all of them are listed
+		var unknownKeys []string
+		for i := 0; i < len(node.Content); i += 2 {
+			keyNode := node.Content[i]
+			if !slices.Contains(fieldInformationKeys, keyNode.Value) {
+				unknownKeys = append(unknownKeys, fmt.Sprintf("line %d: field %s not found in type config.FieldInformation", keyNode.Line, keyNode.Value))
+			}
+		}
+		if len(unknownKeys) > 0 {
+			return &yaml.TypeError{Errors: unknownKeys}
+		}
 
-	// Try unmarshaling as a structured object
-	var obj FieldInformation
-	if err := unmarshal(&obj); err == nil {
-		// Validate required fields
+		var obj FieldInformation
+		if err := node.Decode(&obj); err != nil {
+			return err
+		}
 		if obj.FieldName == "" || obj.ColumnName == "" {
-			return fmt.Errorf("both 'fieldname' and 'columnname' must be set")
+			return fmt.Errorf("line %d: both 'fieldname' and 'columnname' must be set", node.Line)
 		}
 		f.Object = &obj
 		return nil
+	default:
+		return fmt.Errorf("line %d: field must be a string or an object with 'fieldname' and 'columnname'", node.Line)
 	}
-
-	return fmt.Errorf("field must be a string or an object with 'fieldname' and 'columnname'")
 }
 
+// fieldInformationKeys are the keys of a field object.
+var fieldInformationKeys = func() []string {
+	var keys []string
+	fieldInformation := reflect.TypeOf(FieldInformation{})
+	for i := 0; i < fieldInformation.NumField(); i++ {
+		keys = append(keys, strings.Split(fieldInformation.Field(i).Tag.Get("yaml"), ",")[0])
+	}
+	return keys
+}()
+
 func (f *Field) GetFieldName() string {
 	if f.Object != nil {
 		return f.Object.FieldName
@@ -51,9 +84,29 @@ func (f *Field) GetColumnName() string {
 	return ""
 }
 
+// GetMapping returns the inline mapping of the field or nil if the values
+// are mapped by mapping files.
+func (f *Field) GetMapping() Mapping {
+	if f.Object != nil {
+		return f.Object.Mapping
+	}
+	return nil
+}
+
+// GetPrivacy returns the privacy rule of the field.
+func (f *Field) GetPrivacy() privacy.Rule {
+	if f.Object != nil {
+		return f.Object.Privacy
+	}
+	return privacy.None
+}
+
+// IsMappedData returns true if the values of the field are mapped. The
+// values of objects are mapped unless 'mapped' is false, a privacy rule is
+// applied to the mapped value.
 func (f *Field) IsMappedData() bool {
 	if f.FieldName == nil && f.Object != nil {
-		return true
+		return f.Object.Mapped == nil || *f.Object.Mapped
 	}
 	return false
 }
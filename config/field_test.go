@@ -167,5 +167,103 @@ var _ = Describe("Field", func() {
 
 			Expect(cfg.Instances[0].Groups[0].Fields[0].IsMappedData()).To(Equal(true))
 		})
+
+		It("returns true if the field object has a privacy rule", func() {
+			yamlContent := testutil.YamlToByteArray(`
+				---
+				instances:
+				- hostname: foo
+				  token_name: foo
+				  groups:
+				  - name: foo
+				    fields:
+				    - {fieldname: sexId, columnname: sex, privacy: mask}
+				    - {fieldname: email, columnname: email, privacy: mask, mapped: false}
+				`)
+
+			_, err := tempFile.Write([]byte(yamlContent))
+			Expect(err).ToNot(HaveOccurred())
+			tempFile.Close()
+
+			cfg, err := config.LoadConfig(tempFile.Name())
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cfg.Instances[0].Groups[0].Fields[0].IsMappedData()).To(Equal(true))
+			Expect(cfg.Instances[0].Groups[0].Fields[1].IsMappedData()).To(Equal(false))
+		})
+	})
+
+	var _ = Describe("GetMapping", func() {
+		It("returns nil if no inline mapping is defined", func() {
+			yamlContent := testutil.YamlToByteArray(`
+				---
+				instances:
+				- hostname: foo
+				  token_name: foo
+				  groups:
+				  - name: foo
+				    fields:
+				    - foo_field_1
+				    - {fieldname: foo_field_2, columnname: foo_column_2}
+				`)
+			_, err := tempFile.Write(yamlContent)
+			Expect(err).ToNot(HaveOccurred())
+			tempFile.Close()
+
+			cfg, err := config.LoadConfig(tempFile.Name())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cfg.Instances[0].Groups[0].Fields[0].GetMapping()).To(BeNil())
+			Expect(cfg.Instances[0].Groups[0].Fields[1].GetMapping()).To(BeNil())
+		})
+
+		It("returns the typed inline mapping", func() {
+			yamlContent := testutil.YamlToByteArray(`
+				---
+				instances:
+				- hostname: foo
+				  token_name: foo
+				  groups:
+				  - name: foo
+				    fields:
+				    - fieldname: statusId
+				      columnname: status
+				      mapping:
+				        1: Mitglied
+				        "2": Gast
+				`)
+			_, err := tempFile.Write(yamlContent)
+			Expect(err).ToNot(HaveOccurred())
+			tempFile.Close()
+
+			cfg, err := config.LoadConfig(tempFile.Name())
+			Expect(err).ToNot(HaveOccurred())
+
+			field := cfg.Instances[0].Groups[0].Fields[0]
+			Expect(field.IsMappedData()).To(BeTrue())
+			Expect(field.GetMapping()).To(Equal(config.Mapping{
+				{Tag: "!!int", Value: "1"}: "Mitglied",
+				{Tag: "!!str", Value: "2"}: "Gast",
+			}))
+		})
+
+		It("returns an error if the inline mapping is not a map", func() {
+			yamlContent := testutil.YamlToByteArray(`
+				---
+				instances:
+				- hostname: foo
+				  token_name: foo
+				  groups:
+				  - name: foo
+				    fields:
+				    - {fieldname: statusId, columnname: status, mapping: [1, 2]}
+				`)
+			_, err := tempFile.Write(yamlContent)
+			Expect(err).ToNot(HaveOccurred())
+			tempFile.Close()
+
+			cfg, err := config.LoadConfig(tempFile.Name())
+			Expect(err).To(HaveOccurred())
+			Expect(cfg).To(BeNil())
+		})
 	})
 })
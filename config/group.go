@@ -1,24 +1,187 @@
 package config
 
 import (
+	"ctRestClient/filter"
+	"ctRestClient/jsonpath"
+	"errors"
+	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 )
 
 type Group struct {
 	Name   string  `yaml:"name"`
 	Fields []Field `yaml:"fields"`
+
+	// Tags label the group, so that a run can select groups by tag, e.g.
+	// 'monthly' or 'christmas-letters'.
+	Tags []string `yaml:"tags"`
+
+	// Schedule is the cron schedule of the group in the serve mode, it
+	// overrides the schedule of the instance.
+	Schedule string `yaml:"schedule"`
+
+	// Filter is an expression that selects the exported persons of the
+	// group, see filter.Parse.
+	Filter string `yaml:"filter"`
+
+	// ReplaceWithParents exports the households of the parents instead of
+	// the group members, e.g. for letters to the parents of a youth group.
+	ReplaceWithParents bool `yaml:"replace_with_parents"`
+
+	// Set defines a virtual group that contains the members of other
+	// ChurchTools groups combined by a set operation.
+	Set *GroupSet `yaml:"set"`
+
+	// InheritBlocklists can be set to false to ignore the global and the
+	// instance blocklists for the group.
+	InheritBlocklists *bool `yaml:"inherit_blocklists"`
+}
+
+// Validate checks the properties of the group.
+func (g Group) Validate() error {
+	if g.Name == "" {
+		return errors.New("property name is not set")
+	}
+	if len(g.Fields) == 0 {
+		return errors.New("property fields is not set")
+	}
+	for _, field := range g.Fields {
+		if field.GetMapping() != nil && !field.IsMappedData() {
+			return fmt.Errorf("field '%s' of group '%s' has a mapping but 'mapped' is false", field.GetFieldName(), g.Name)
+		}
+	}
+	for _, tag := range g.Tags {
+		if strings.TrimSpace(tag) == "" {
+			return fmt.Errorf("property tags of group '%s' contains an empty tag", g.Name)
+		}
+	}
+	if g.Schedule != "" {
+		if _, err := ParseSchedule(g.Schedule); err != nil {
+			return fmt.Errorf("property schedule of group '%s' is invalid, %w", g.Name, err)
+		}
+	}
+	if _, err := g.FilterExpression(); err != nil {
+		return fmt.Errorf("property filter of group '%s' is invalid, %w", g.Name, err)
+	}
+	if g.IsVirtual() {
+		if err := g.Set.validate(); err != nil {
+			return fmt.Errorf("property set of group '%s' is invalid, %w", g.Name, err)
+		}
+	}
+	return nil
+}
+
+// A GroupSet combines the members of ChurchTools groups by their person ID.
+// Exactly one of the operations must be set.
+type GroupSet struct {
+	// Union contains the persons that are members of any of the groups.
+	Union []string `yaml:"union"`
+	// Intersection contains the persons that are members of all groups.
+	Intersection []string `yaml:"intersection"`
+	// Difference contains the members of the first group that are not
+	// members of any of the other groups.
+	Difference []string `yaml:"difference"`
+}
+
+// IsVirtual returns true if the group is not a ChurchTools group but is
+// defined by a set operation of other groups.
+func (g Group) IsVirtual() bool {
+	return g.Set != nil
+}
+
+func (s GroupSet) validate() error {
+	operations := 0
+	for _, groups := range [][]string{s.Union, s.Intersection, s.Difference} {
+		if groups != nil {
+			operations++
+		}
+	}
+	if operations != 1 {
+		return errors.New("exactly one of union, intersection and difference must be set")
+	}
+
+	if len(s.Union) == 1 || len(s.Intersection) == 1 || len(s.Difference) == 1 || len(s.Union)+len(s.Intersection)+len(s.Difference) == 0 {
+		return errors.New("at least two groups must be set")
+	}
+	for _, groupName := range slices.Concat(s.Union, s.Intersection, s.Difference) {
+		if groupName == "" {
+			return errors.New("group names must not be empty")
+		}
+	}
+	return nil
+}
+
+// InheritsBlocklists returns true if the global and the instance blocklists
+// apply to the group.
+func (g Group) InheritsBlocklists() bool {
+	return g.InheritBlocklists == nil || *g.InheritBlocklists
+}
+
+// UsesNamespace returns true if a field or the filter of the group uses a
+// path within the given namespace, e.g. 'member' for 'member.memberStartDate'.
+func (g Group) UsesNamespace(namespace string) bool {
+	for _, field := range g.Fields {
+		if jsonpath.Namespace(field.GetFieldName()) == namespace {
+			return true
+		}
+	}
+	if expression, err := g.FilterExpression(); err == nil && expression != nil {
+		for _, fieldName := range expression.Fields() {
+			if jsonpath.Namespace(fieldName) == namespace {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FilterExpression returns the parsed filter of the group or nil if the
+// group has no filter.
+func (g Group) FilterExpression() (*filter.Expression, error) {
+	if g.Filter == "" {
+		return nil, nil
+	}
+	return filter.Parse(g.Filter)
 }
 
 func (g Group) CSVFileName() string {
 	return g.sanitizedGroupName() + ".csv"
 }
 
+// IDColumn returns the column name of the person id or false if the group
+// does not export the id.
+func (g Group) IDColumn() (string, bool) {
+	for _, field := range g.Fields {
+		if field.GetFieldName() == "id" {
+			return field.GetColumnName(), true
+		}
+	}
+	return "", false
+}
+
 func (g Group) BlocklistFileName() string {
 	return g.sanitizedGroupName() + ".yml"
 }
 
-func (g Group) sanitizedGroupName() string {
+// SanitizedName returns the group name in a form that can be used as file
+// or directory name.
+func (g Group) SanitizedName() string {
+	return g.sanitizedGroupName()
+}
+
+// LegacyBlocklistFileName returns the name of the blocklist file of earlier
+// versions, see LegacySanitizedName.
+func (g Group) LegacyBlocklistFileName() string {
+	return g.LegacySanitizedName() + ".yml"
+}
+
+// LegacySanitizedName returns the sanitized group name of earlier versions.
+// They removed letters like 'ß' or 'é' instead of writing them out, e.g.
+// 'Groe_Jugend' for 'Große Jugend', so the blocklists and mapping
+// directories of existing groups may still use this name.
+func (g Group) LegacySanitizedName() string {
 	fileName := g.Name
 	fileName = strings.ReplaceAll(fileName, " ", "_")
 	fileName = strings.ReplaceAll(fileName, ",", ".")
@@ -29,9 +192,14 @@ func (g Group) sanitizedGroupName() string {
 	fileName = strings.ReplaceAll(fileName, "Ö", "Oe")
 	fileName = strings.ReplaceAll(fileName, "Ü", "Ue")
 
-	re := regexp.MustCompile(`[^\w\-.]`)
-	fileName = re.ReplaceAllString(fileName, "")
+	fileName = legacyInvalidFileNameCharacters.ReplaceAllString(fileName, "")
 	fileName = strings.ReplaceAll(fileName, "__", "_")
 
 	return fileName
 }
+
+var legacyInvalidFileNameCharacters = regexp.MustCompile(`[^\w\-.]`)
+
+func (g Group) sanitizedGroupName() string {
+	return SanitizeFileName(g.Name)
+}
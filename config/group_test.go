@@ -76,4 +76,45 @@ var _ = Describe("Group", func() {
 			Expect(cfg.Instances[0].Groups[0].BlocklistFileName()).To(Equal("foo-_.aeoeueAeOeUe-group.yml"))
 		})
 	})
+
+	var _ = Describe("LegacySanitizedName", func() {
+		It("removes letters that are written out by the current name", func() {
+			for name, legacyName := range map[string]string{
+				"Große Jugend":  "Groe_Jugend",
+				"Café Treff":    "Caf_Treff",
+				"Jugend, Chor": "Jugend._Chor",
+			} {
+				Expect(config.Group{Name: name}.LegacySanitizedName()).To(Equal(legacyName))
+			}
+			Expect(config.Group{Name: "Große Jugend"}.SanitizedName()).To(Equal("Grosse_Jugend"))
+			Expect(config.Group{Name: "Café Treff"}.LegacyBlocklistFileName()).To(Equal("Caf_Treff.yml"))
+		})
+	})
+
+	var _ = Describe("InheritsBlocklists", func() {
+		It("inherits blocklists unless the group opts out", func() {
+			yamlContent := testutil.YamlToByteArray(`
+				---
+				instances:
+				- hostname: foo
+				  token_name: foo
+				  groups:
+				  - name: foo_group_0
+				    fields: [id]
+				  - name: foo_group_1
+				    inherit_blocklists: false
+				    fields: [id]
+				`)
+
+			_, err := tempFile.Write(yamlContent)
+			Expect(err).ToNot(HaveOccurred())
+			tempFile.Close()
+
+			cfg, err := config.LoadConfig(tempFile.Name())
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cfg.Instances[0].Groups[0].InheritsBlocklists()).To(BeTrue())
+			Expect(cfg.Instances[0].Groups[1].InheritsBlocklists()).To(BeFalse())
+		})
+	})
 })
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A MappingKey is a typed YAML key of a mapping. The tag is kept so that
+// the integer 1 and the string "1" can be mapped to different values.
+type MappingKey struct {
+	Tag   string
+	Value string
+}
+
+// A Mapping translates ChurchTools values into output values. It can be
+// defined inline on a field in the config file.
+type Mapping map[MappingKey]string
+
+func (m *Mapping) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: 'mapping' must be a map of values", node.Line)
+	}
+
+	mapping := make(Mapping)
+	for i := 0; i < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		valueNode := node.Content[i+1]
+
+		if valueNode.Kind != yaml.ScalarNode {
+			return fmt.Errorf("line %d: the mapped value of '%s' must be a scalar", valueNode.Line, keyNode.Value)
+		}
+
+		mapping[MappingKey{
+			Tag:   keyNode.Tag,
+			Value: keyNode.Value,
+		}] = valueNode.Value
+	}
+
+	*m = mapping
+	return nil
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"ctRestClient/secret"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPath is the path template of the csv file of a group in the run
+// directory.
+const DefaultPath = "{hostname}/{group}.{ext}"
+
+// Output defines the paths of the exported files and how the export
+// directories are maintained.
+type Output struct {
+	// Path is the template of the path of the csv file of a group in the run
+	// directory, DefaultPath if it is not set. The placeholders are listed
+	// in PathValues.
+	Path string `yaml:"path"`
+	// Latest maintains the directory 'latest' in the output directory with
+	// the newest files of each group.
+	Latest    bool      `yaml:"latest"`
+	Retention Retention `yaml:"retention"`
+	// Encryption writes the files of a run into an encrypted bundle instead
+	// of the run directory.
+	Encryption Encryption `yaml:"encryption"`
+}
+
+// The formats of an encrypted bundle.
+const (
+	// EncryptionZIP is a ZIP file with AES-256 encrypted entries, it is
+	// opened with the passphrase by 7-Zip, WinZip and similar tools.
+	EncryptionZIP = "zip"
+	// EncryptionAge is a ZIP file encrypted by age for a recipient key or a
+	// passphrase.
+	EncryptionAge = "age"
+	// EncryptionOpenPGP is a ZIP file encrypted by OpenPGP for a public key
+	// or a passphrase.
+	EncryptionOpenPGP = "openpgp"
+)
+
+var bundleFileNames = map[string]string{
+	EncryptionZIP:     "export.zip",
+	EncryptionAge:     "export.zip.age",
+	EncryptionOpenPGP: "export.zip.gpg",
+}
+
+// Encryption defines the encrypted bundle of a run.
+type Encryption struct {
+	// Format is one of EncryptionZIP, EncryptionAge and EncryptionOpenPGP.
+	Format string `yaml:"format"`
+	// SecretName is the name of the passphrase or the recipient key in the
+	// secret store.
+	SecretName string `yaml:"secret_name"`
+	// SecretSource is the token source of the secret, the KeePass database
+	// by default.
+	SecretSource string `yaml:"secret_source"`
+}
+
+// IsSet returns true if the files of a run are encrypted.
+func (e Encryption) IsSet() bool {
+	return e.Format != ""
+}
+
+// GetSecretSource returns the token source of the secret, 'keepass' if it is
+// not set.
+func (e Encryption) GetSecretSource() string {
+	if e.SecretSource == "" {
+		return secret.KeepassStore
+	}
+	return e.SecretSource
+}
+
+// BundleFileName returns the name of the bundle in the run directory, e.g.
+// 'export.zip.age'.
+func (e Encryption) BundleFileName() string {
+	return bundleFileNames[e.Format]
+}
+
+// Validate checks the format and the secret of the bundle.
+func (e Encryption) Validate() error {
+	if !e.IsSet() {
+		return nil
+	}
+	if _, exists := bundleFileNames[e.Format]; !exists {
+		return fmt.Errorf("property format of encryption must be '%s', '%s' or '%s', got '%s'", EncryptionZIP, EncryptionAge, EncryptionOpenPGP, e.Format)
+	}
+	if e.SecretName == "" {
+		return errors.New("property secret_name of encryption is not set")
+	}
+	if err := secret.ValidateTokenSource(e.SecretSource); err != nil {
+		return fmt.Errorf("invalid secret_source of encryption, %w", err)
+	}
+	return nil
+}
+
+// Retention defines which export directories are kept, all are kept if
+// neither limit is set. A run is deleted as soon as it exceeds one of the
+// limits.
+type Retention struct {
+	// KeepRuns is the number of newest runs that are kept, the current run
+	// included.
+	KeepRuns int `yaml:"keep_runs"`
+	// KeepDays is the number of days a run is kept.
+	KeepDays int `yaml:"keep_days"`
+	// SecureDelete overwrites the files before they are deleted.
+	SecureDelete bool `yaml:"secure_delete"`
+}
+
+// IsSet returns true if runs are deleted.
+func (r Retention) IsSet() bool {
+	return r.KeepRuns > 0 || r.KeepDays > 0
+}
+
+// PathValues are the values of the placeholders of the path template. The
+// values are sanitized, so that they never contain a path separator.
+type PathValues struct {
+	// Instance is the source of {instance}, its alias or hostname, and of
+	// {hostname}.
+	Instance Instance
+	// Group is the source of {group}.
+	Group Group
+	// GroupID is the ChurchTools ID of the group, the value of {group_id}.
+	GroupID int
+	// Time is the start of the run, the source of {year}, {month}, {day},
+	// {date} like 2025-03-01 and {time} like 06-00-00.
+	Time time.Time
+}
+
+var placeholderPattern = regexp.MustCompile(`\{([^{}]*)\}`)
+
+var placeholders = map[string]func(values PathValues) string{
+	"instance": func(values PathValues) string { return SanitizeFileName(values.Instance.GetAlias()) },
+	"hostname": func(values PathValues) string { return SanitizeFileName(values.Instance.Hostname) },
+	"group":    func(values PathValues) string { return values.Group.SanitizedName() },
+	"group_id": func(values PathValues) string { return strconv.Itoa(values.GroupID) },
+	"year":     func(values PathValues) string { return values.Time.Format("2006") },
+	"month":    func(values PathValues) string { return values.Time.Format("01") },
+	"day":      func(values PathValues) string { return values.Time.Format("02") },
+	"date":     func(values PathValues) string { return values.Time.Format("2006-01-02") },
+	"time":     func(values PathValues) string { return values.Time.Format("15-04-05") },
+	"ext":      func(values PathValues) string { return "csv" },
+}
+
+// GetPath returns the path template, DefaultPath if it is not set.
+func (o Output) GetPath() string {
+	if o.Path == "" {
+		return DefaultPath
+	}
+	return o.Path
+}
+
+// UsesPlaceholder returns true if the path template contains the
+// placeholder, e.g. 'group_id'.
+func (o Output) UsesPlaceholder(name string) bool {
+	return strings.Contains(o.GetPath(), "{"+name+"}")
+}
+
+// GroupPath returns the path of the csv file of a group relative to the run
+// directory.
+func (o Output) GroupPath(values PathValues) string {
+	path := placeholderPattern.ReplaceAllStringFunc(o.GetPath(), func(placeholder string) string {
+		if value, exists := placeholders[placeholder[1:len(placeholder)-1]]; exists {
+			return value(values)
+		}
+		return placeholder
+	})
+	return filepath.FromSlash(path)
+}
+
+// Validate checks the path template, that the limits of the retention are
+// not negative and the encryption.
+func (o Output) Validate() error {
+	for _, match := range placeholderPattern.FindAllStringSubmatch(o.GetPath(), -1) {
+		if _, exists := placeholders[match[1]]; !exists {
+			return fmt.Errorf("property path contains the unknown placeholder '%s'", match[0])
+		}
+	}
+	if !strings.HasSuffix(o.GetPath(), ".{ext}") {
+		return fmt.Errorf("property path '%s' must end with '.{ext}'", o.Path)
+	}
+	example := o.GroupPath(PathValues{Instance: Instance{Hostname: "host"}, Group: Group{Name: "group"}, Time: time.Now()})
+	if !filepath.IsLocal(example) {
+		return fmt.Errorf("property path '%s' must be a relative path within the run directory", o.Path)
+	}
+	if o.Retention.KeepRuns < 0 {
+		return fmt.Errorf("property keep_runs of retention must not be negative, got %d", o.Retention.KeepRuns)
+	}
+	if o.Retention.KeepDays < 0 {
+		return fmt.Errorf("property keep_days of retention must not be negative, got %d", o.Retention.KeepDays)
+	}
+	if err := o.Encryption.Validate(); err != nil {
+		return err
+	}
+	// The latest directory would keep the files of an encrypted run in
+	// plain text
+	if o.Latest && o.Encryption.IsSet() {
+		return errors.New("property latest cannot be combined with encryption")
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// transliterations are replaced before the accents are removed, so that
+// 'ä' becomes 'ae' instead of 'a'.
+var transliterations = strings.NewReplacer(
+	"ä", "ae", "ö", "oe", "ü", "ue",
+	"Ä", "Ae", "Ö", "Oe", "Ü", "Ue",
+	"ß", "ss", "ẞ", "SS",
+)
+
+// windowsReservedNames cannot be used as file names on Windows, not even
+// with an extension.
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// SanitizeFileName returns the name in a form that can be used as file or
+// directory name on all systems. Spaces become '_' and commas '.', accents
+// of latin letters are removed and letters and digits of other scripts are
+// kept. All other characters are removed.
+func SanitizeFileName(name string) string {
+	name = transliterations.Replace(name)
+
+	var sanitized strings.Builder
+	previousLatin := false
+	for _, r := range norm.NFKD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r) && previousLatin:
+			// the accent of a latin letter, e.g. of 'é'
+			continue
+		case unicode.IsSpace(r):
+			sanitized.WriteRune('_')
+		case r == ',':
+			sanitized.WriteRune('.')
+		case unicode.IsLetter(r), unicode.IsDigit(r), unicode.IsMark(r), r == '_', r == '-', r == '.':
+			sanitized.WriteRune(r)
+		}
+		previousLatin = unicode.Is(unicode.Latin, r)
+	}
+
+	fileName := norm.NFC.String(sanitized.String())
+	for strings.Contains(fileName, "__") {
+		fileName = strings.ReplaceAll(fileName, "__", "_")
+	}
+	// Windows ignores trailing dots, names of dots only are no files
+	fileName = strings.TrimRight(fileName, ".")
+	if fileName == "" {
+		return "_"
+	}
+	if windowsReservedNames[strings.ToUpper(strings.SplitN(fileName, ".", 2)[0])] {
+		fileName = "_" + fileName
+	}
+	return fileName
+}
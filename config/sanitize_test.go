@@ -0,0 +1,27 @@
+package config_test
+
+import (
+	"ctRestClient/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SanitizeFileName", func() {
+	DescribeTable("returns a name that can be used on all systems",
+		func(name string, expected string) {
+			Expect(config.SanitizeFileName(name)).To(Equal(expected))
+		},
+		Entry("German umlauts", "Chor Äöü ß", "Chor_Aeoeue_ss"),
+		Entry("accents of latin letters", "Café Señor Łódź", "Cafe_Senor_Łodz"),
+		Entry("other scripts", "Хор 合唱団 합창단", "Хор_合唱団_합창단"),
+		Entry("compatibility characters", "ﬁnance Ⅳ", "finance_IV"),
+		Entry("path separators and special characters", `a/b\c:d*e?f"g<h>i|j`, "abcdefghij"),
+		Entry("commas and repeated spaces", "Youth,  Kids", "Youth._Kids"),
+		Entry("trailing dots", "Group...", "Group"),
+		Entry("reserved names of Windows", "con", "_con"),
+		Entry("reserved names with extension", "NUL.old", "_NUL.old"),
+		Entry("names without valid characters", "???", "_"),
+		Entry("emoji", "Kids 🎉", "Kids_"),
+	)
+})
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"github.com/robfig/cron/v3"
+)
+
+// ParseSchedule parses a cron schedule with five fields like '0 6 * * 1' or a
+// descriptor like '@daily'. The time zone is the local one unless it is set
+// with a prefix like 'CRON_TZ=Europe/Berlin'.
+func ParseSchedule(spec string) (cron.Schedule, error) {
+	return cron.ParseStandard(spec)
+}
+
+// GroupSchedule returns the schedule of the group, or the schedule of the
+// instance if the group has none. It is empty if the group is not scheduled.
+func (i Instance) GroupSchedule(group Group) string {
+	if group.Schedule != "" {
+		return group.Schedule
+	}
+	return i.Schedule
+}
+
+// Scheduled returns true if any group has a schedule.
+func (c Config) Scheduled() bool {
+	for _, instance := range c.Instances {
+		for _, group := range instance.Groups {
+			if instance.GroupSchedule(group) != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
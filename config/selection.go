@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// A Selection restricts a run to some instances and groups. Each list
+// contains glob patterns like 'jugend-*', see path.Match, or tags. An empty
+// list selects everything. An entry is selected if it matches any pattern of
+// each list.
+type Selection struct {
+	// Instances are patterns of the hostnames.
+	Instances []string
+	// Groups are patterns of the group names.
+	Groups []string
+	// Tags are patterns of the tags of the groups.
+	Tags []string
+}
+
+// IsEmpty returns true if the selection selects everything.
+func (s Selection) IsEmpty() bool {
+	return len(s.Instances) == 0 && len(s.Groups) == 0 && len(s.Tags) == 0
+}
+
+// Validate checks the syntax of the patterns.
+func (s Selection) Validate() error {
+	for _, patterns := range [][]string{s.Instances, s.Groups, s.Tags} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid pattern '%s', %w", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// MatchesInstance returns true if the hostname of the instance matches.
+func (s Selection) MatchesInstance(instance Instance) bool {
+	return len(s.Instances) == 0 || matchesAny(s.Instances, instance.Hostname)
+}
+
+// MatchesGroup returns true if the name and the tags of the group match.
+func (s Selection) MatchesGroup(group Group) bool {
+	if len(s.Groups) > 0 && !matchesAny(s.Groups, group.Name) {
+		return false
+	}
+	if len(s.Tags) == 0 {
+		return true
+	}
+	for _, tag := range group.Tags {
+		if matchesAny(s.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Selection) String() string {
+	var parts []string
+	for _, list := range []struct {
+		name     string
+		patterns []string
+	}{{"instances", s.Instances}, {"groups", s.Groups}, {"tags", s.Tags}} {
+		if len(list.patterns) > 0 {
+			parts = append(parts, fmt.Sprintf("%s %s", list.name, strings.Join(list.patterns, ", ")))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns a copy of the config with the selected instances and
+// groups. Instances without selected groups are removed. It returns an error
+// if nothing is selected, e.g. because of a typo in a pattern.
+func (c Config) Select(selection Selection) (Config, error) {
+	if err := selection.Validate(); err != nil {
+		return Config{}, err
+	}
+	if selection.IsEmpty() {
+		return c, nil
+	}
+
+	selected := c.Filter(func(instance Instance, group Group) bool {
+		return selection.MatchesInstance(instance) && selection.MatchesGroup(group)
+	})
+	if len(selected.Instances) == 0 {
+		return Config{}, fmt.Errorf("no group matches the selection (%s)", selection)
+	}
+	return selected, nil
+}
+
+// Filter returns a copy of the config with the groups for which keep returns
+// true. Instances without groups are removed.
+func (c Config) Filter(keep func(instance Instance, group Group) bool) Config {
+	filtered := c
+	filtered.Instances = nil
+	for _, instance := range c.Instances {
+		var groups []Group
+		for _, group := range instance.Groups {
+			if keep(instance, group) {
+				groups = append(groups, group)
+			}
+		}
+		if len(groups) > 0 {
+			instance.Groups = groups
+			filtered.Instances = append(filtered.Instances, instance)
+		}
+	}
+	return filtered
+}
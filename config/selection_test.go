@@ -0,0 +1,106 @@
+package config_test
+
+import (
+	"ctRestClient/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Selection", func() {
+	var cfg config.Config
+
+	BeforeEach(func() {
+		cfg = config.Config{
+			Instances: []config.Instance{
+				{
+					Hostname: "cumulus.church.tools",
+					Groups: []config.Group{
+						{Name: "Jugend Nord", Tags: []string{"monthly"}},
+						{Name: "Jugend Süd"},
+						{Name: "Chor", Tags: []string{"christmas-letters", "monthly"}},
+					},
+				},
+				{
+					Hostname: "stratus.church.tools",
+					Groups: []config.Group{
+						{Name: "Jugend"},
+						{Name: "Senioren", Tags: []string{"christmas-letters"}},
+					},
+				},
+			},
+		}
+	})
+
+	groupNames := func(cfg config.Config) []string {
+		var names []string
+		for _, instance := range cfg.Instances {
+			for _, group := range instance.Groups {
+				names = append(names, instance.Hostname+"/"+group.Name)
+			}
+		}
+		return names
+	}
+
+	It("selects everything if it is empty", func() {
+		selected, err := cfg.Select(config.Selection{})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(selected).To(Equal(cfg))
+	})
+
+	It("selects the instances by hostname", func() {
+		selected, err := cfg.Select(config.Selection{Instances: []string{"stratus.*"}})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(groupNames(selected)).To(Equal([]string{"stratus.church.tools/Jugend", "stratus.church.tools/Senioren"}))
+	})
+
+	It("selects the groups matching any pattern", func() {
+		selected, err := cfg.Select(config.Selection{Groups: []string{"Jugend *", "Senioren"}})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(groupNames(selected)).To(Equal([]string{
+			"cumulus.church.tools/Jugend Nord",
+			"cumulus.church.tools/Jugend Süd",
+			"stratus.church.tools/Senioren",
+		}))
+	})
+
+	It("selects the groups by tag", func() {
+		selected, err := cfg.Select(config.Selection{Tags: []string{"christmas-*"}})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(groupNames(selected)).To(Equal([]string{"cumulus.church.tools/Chor", "stratus.church.tools/Senioren"}))
+	})
+
+	It("selects the groups matching all lists", func() {
+		selected, err := cfg.Select(config.Selection{
+			Instances: []string{"cumulus.church.tools"},
+			Tags:      []string{"monthly"},
+			Groups:    []string{"Jugend*"},
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(groupNames(selected)).To(Equal([]string{"cumulus.church.tools/Jugend Nord"}))
+	})
+
+	It("does not change the config", func() {
+		_, err := cfg.Select(config.Selection{Groups: []string{"Chor"}})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.Instances[0].Groups).To(HaveLen(3))
+	})
+
+	It("returns an error if nothing is selected", func() {
+		_, err := cfg.Select(config.Selection{Instances: []string{"stratus.*"}, Tags: []string{"monthly"}})
+
+		Expect(err).To(MatchError("no group matches the selection (instances stratus.*; tags monthly)"))
+	})
+
+	It("returns an error for an invalid pattern", func() {
+		_, err := cfg.Select(config.Selection{Groups: []string{"Jugend["}})
+
+		Expect(err).To(MatchError("invalid pattern 'Jugend[', syntax error in pattern"))
+	})
+})
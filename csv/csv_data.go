@@ -3,4 +3,11 @@ package csv
 type CsvData interface {
 	Records() [][]string
 	Header() []string
-}
\ No newline at end of file
+}
+
+// PersonData contains the exported persons of a group and the persons that
+// were excluded by blocklists or the filter of the group.
+type PersonData interface {
+	CsvData
+	Excluded() CsvData
+}
@@ -0,0 +1,32 @@
+package csv
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// ReadFile reads a csv file written by the CSVFileWriter.
+func ReadFile(csvFilePath string) (CsvData, error) {
+	file, err := os.Open(csvFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open csv file: %w", err)
+	}
+	defer file.Close()
+
+	utf16Reader := transform.NewReader(file, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder())
+	csvReader := csv.NewReader(utf16Reader)
+	csvReader.Comma = ';'
+
+	rows, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv file '%s': %w", csvFilePath, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("the csv file '%s' has no header", csvFilePath)
+	}
+	return &personData{header: rows[0], records: rows[1:]}, nil
+}
@@ -0,0 +1,40 @@
+package csv_test
+
+import (
+	"ctRestClient/csv"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReadFile", func() {
+	It("reads a file of the CSVFileWriter", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "group.csv")
+		records := [][]string{
+			{"1", "Jöhn", "Main Street; 1"},
+			{"2", "Jane", "multi\nline"},
+		}
+		Expect(csv.NewCSVFileWriter().Write(path, []string{"id", "firstName", "street"}, records)).To(Succeed())
+
+		data, err := csv.ReadFile(path)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data.Header()).To(Equal([]string{"id", "firstName", "street"}))
+		Expect(data.Records()).To(Equal(records))
+	})
+
+	It("returns an error if the file does not exist", func() {
+		_, err := csv.ReadFile(filepath.Join(GinkgoT().TempDir(), "missing.csv"))
+		Expect(err).To(MatchError(ContainSubstring("failed to open csv file")))
+	})
+
+	It("returns an error if the file is empty", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "empty.csv")
+		Expect(os.WriteFile(path, nil, 0644)).To(Succeed())
+
+		_, err := csv.ReadFile(path)
+		Expect(err).To(MatchError(ContainSubstring("has no header")))
+	})
+})
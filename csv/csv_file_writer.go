@@ -3,6 +3,7 @@ package csv
 import (
 	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
 
 	"golang.org/x/text/encoding/unicode"
@@ -27,11 +28,16 @@ func (w csvWriter) Write(csvFilePath string, csvHeader []string, csvRecords [][]
 	}
 	defer file.Close()
 
-	utf16Writer := transform.NewWriter(file, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder())
+	return Encode(file, csvHeader, csvRecords)
+}
+
+// Encode writes the header and the records as UTF-16 with a byte order mark
+// and semicolons, the format that Excel opens without an import dialog.
+func Encode(writer io.Writer, csvHeader []string, csvRecords [][]string) error {
+	utf16Writer := transform.NewWriter(writer, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder())
 	csvWriter := csv.NewWriter(utf16Writer)
 	// Set the delimiter to semicolon
 	csvWriter.Comma = ';'
-	defer csvWriter.Flush()
 
 	if err := csvWriter.Write(csvHeader); err != nil {
 		return fmt.Errorf("failed to write csv header: %v", err)
@@ -43,5 +49,9 @@ func (w csvWriter) Write(csvFilePath string, csvHeader []string, csvRecords [][]
 		}
 	}
 
-	return nil
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("failed to write csv records: %v", err)
+	}
+	return utf16Writer.Close()
 }
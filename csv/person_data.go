@@ -3,25 +3,57 @@ package csv
 import (
 	"ctRestClient/config"
 	"ctRestClient/data_provider"
+	"ctRestClient/jsonpath"
 	"ctRestClient/logger"
+	"ctRestClient/privacy"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 )
 
+// ExcludedHeader is the header of the audit file of excluded persons.
+var ExcludedHeader = []string{"id", "firstName", "lastName", "reason", "blocklist", "entry", "fields"}
+
+// ReasonFilter is the reason of persons excluded by the filter of the group,
+// the reason of blocked persons names the layer of the blocklist.
+const ReasonFilter = "filter"
+
 type personData struct {
-	header  []string
-	records [][]string
+	header   []string
+	records  [][]string
+	excluded [][]string
 }
 
 func NewPersonData(
 	persons []json.RawMessage,
+	instance config.Instance,
 	group config.Group,
+	privacyProfile privacy.Profile,
 	fileDataProvider data_provider.FileDataProvider,
 	blocklistsDataProvider data_provider.BlockListDataProvider,
-	logger logger.Logger) (CsvData, error) {
+	logger logger.Logger) (PersonData, error) {
 	csvRecords := make([][]string, 0)
+	excludedRecords := make([][]string, 0)
 	fields := group.Fields
 	blockCount := 0
+	filterCount := 0
+	missingMappingFiles := make(map[string]bool)
+
+	filterExpression, err := group.FilterExpression()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse filter: %v", err)
+	}
+
+	mapper := func(fieldName string, value json.RawMessage) (string, error) {
+		for _, field := range fields {
+			if field.GetFieldName() == fieldName && field.IsMappedData() {
+				return fileDataProvider.GetData(field, value, instance, group)
+			}
+		}
+		return fileDataProvider.GetData(config.Field{FieldName: &fieldName}, value, instance, group)
+	}
 
 	for _, person := range persons {
 		var personJson map[string]json.RawMessage
@@ -30,21 +62,44 @@ func NewPersonData(
 			return nil, fmt.Errorf("failed to read person information raw json: %v", err)
 		}
 
-		isBlocked, err := blocklistsDataProvider.IsBlocked(personJson, group)
+		// A person that cannot be checked may be blocked, so the group is
+		// not exported at all
+		blockMatch, err := blocklistsDataProvider.IsBlocked(personJson, instance, group)
 		if err != nil {
-			logger.Error(fmt.Sprintf("      failed to check if person is blocked: '%v'", err))
+			return nil, fmt.Errorf("failed to check if person is blocked: %w", err)
 		}
-		if isBlocked {
+		if blockMatch != nil {
 			blockCount++
-			logger.Info(fmt.Sprintf("      -> %s %s will not be added to csv file", personJson["firstName"], personJson["lastName"]))
+			logger.Info(fmt.Sprintf("      -> %s %s will not be added to csv file (%s)", personJson["firstName"], personJson["lastName"], blockMatch))
+			excludedRecords = append(excludedRecords, excludedRecord(
+				personJson,
+				fields,
+				privacyProfile,
+				blockMatch.Layer+" blocklist",
+				blockMatch.File,
+				strconv.Itoa(blockMatch.Entry),
+				blockMatch.Fields,
+			))
 			continue
 		}
 
+		if filterExpression != nil {
+			matches, err := filterExpression.Matches(personJson, mapper)
+			if err != nil {
+				logger.Error(fmt.Sprintf("      failed to evaluate filter for %s %s: '%v'", personJson["firstName"], personJson["lastName"], err))
+			}
+			if !matches {
+				filterCount++
+				excludedRecords = append(excludedRecords, excludedRecord(personJson, fields, privacyProfile, ReasonFilter, "", "", filterExpression.Fields()))
+				continue
+			}
+		}
+
 		record := make([]string, len(fields))
 
 		for i, field := range fields {
 			fieldName := field.GetFieldName()
-			rawValue, exists := personJson[fieldName]
+			rawValue, exists := jsonpath.Lookup(personJson, fieldName)
 
 			value := ""
 
@@ -57,23 +112,35 @@ func NewPersonData(
 				if !field.IsMappedData() {
 					value = convertToString(rawValue)
 				} else {
-					value, err = fileDataProvider.GetData(fieldName, rawValue)
+					value, err = fileDataProvider.GetData(field, rawValue, instance, group)
 					if err != nil {
-						logger.Error(fmt.Sprintf("     failed to get data for field '%s': %v", fieldName, err))
+						// A missing mapping file is logged once, not for every person
+						missingMappingFile := errors.Is(err, data_provider.ErrMappingFileNotFound)
+						if !missingMappingFile || !missingMappingFiles[fieldName] {
+							logger.Error(fmt.Sprintf("     failed to get data for field '%s': %v", fieldName, err))
+						}
+						missingMappingFiles[fieldName] = missingMappingFiles[fieldName] || missingMappingFile
 						value = ""
 					}
 				}
 			}
-			record[i] = value
-
+			// The rule returns an empty value on errors, so a raw value is never exported
+			record[i], err = privacyProfile.RuleFor(fieldName, field.GetPrivacy()).Apply(value, privacyProfile.Secret)
+			if err != nil {
+				logger.Error(fmt.Sprintf("     failed to apply privacy rule to field '%s': %v", fieldName, err))
+			}
 		}
 		csvRecords = append(csvRecords, record)
 	}
 
-	if blocklistsDataProvider.BlockListExists(group) {
+	if len(blocklistsDataProvider.BlockListFiles(instance, group)) > 0 {
 		logger.Info(fmt.Sprintf("      blocked %d persons", blockCount))
 	}
 
+	if filterExpression != nil {
+		logger.Info(fmt.Sprintf("      filtered %d persons", filterCount))
+	}
+
 	// Extract field names for the header
 	csvHeader := make([]string, len(fields))
 	for i, field := range fields {
@@ -81,11 +148,29 @@ func NewPersonData(
 	}
 
 	return &personData{
-		header:  csvHeader,
-		records: csvRecords,
+		header:   csvHeader,
+		records:  csvRecords,
+		excluded: excludedRecords,
 	}, nil
 }
 
+// excludedRecord returns the audit record of an excluded person. The privacy
+// rules of the group fields also apply to the person data of the record.
+func excludedRecord(personJson map[string]json.RawMessage, groupFields []config.Field, privacyProfile privacy.Profile, reason string, blocklist string, entry string, fields []string) []string {
+	record := make([]string, 0, len(ExcludedHeader))
+	for _, fieldName := range []string{"id", "firstName", "lastName"} {
+		fieldRule := privacy.None
+		for _, field := range groupFields {
+			if field.GetFieldName() == fieldName && field.GetPrivacy() != privacy.None {
+				fieldRule = field.GetPrivacy()
+			}
+		}
+		value, _ := privacyProfile.RuleFor(fieldName, fieldRule).Apply(convertToString(personJson[fieldName]), privacyProfile.Secret)
+		record = append(record, value)
+	}
+	return append(record, reason, blocklist, entry, strings.Join(fields, ", "))
+}
+
 // Helper function to convert JSON values to strings
 func convertToString(value json.RawMessage) string {
 	// Parse the raw message to get the actual value
@@ -124,3 +209,12 @@ func (p *personData) Records() [][]string {
 func (p *personData) Header() []string {
 	return p.header
 }
+
+// Excluded returns the persons that were blocked or filtered together with
+// the rule that excluded them.
+func (p *personData) Excluded() CsvData {
+	return &personData{
+		header:  ExcludedHeader,
+		records: p.excluded,
+	}
+}
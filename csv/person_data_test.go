@@ -3,10 +3,13 @@ package csv_test
 import (
 	"ctRestClient/config"
 	"ctRestClient/csv"
+	"ctRestClient/data_provider"
 	"ctRestClient/data_provider/data_providerfakes"
 	"ctRestClient/logger/loggerfakes"
+	"ctRestClient/privacy"
 	"encoding/json"
 	"errors"
+	"fmt"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -23,6 +26,7 @@ var _ = Describe("PersonData", func() {
 		fileDataProvider       *data_providerfakes.FakeFileDataProvider
 		blocklistsDataProvider *data_providerfakes.FakeBlockListDataProvider
 		logger                 *loggerfakes.FakeLogger
+		instance               config.Instance
 	)
 
 	BeforeEach(func() {
@@ -42,12 +46,13 @@ var _ = Describe("PersonData", func() {
 		fileDataProvider = &data_providerfakes.FakeFileDataProvider{}
 		blocklistsDataProvider = &data_providerfakes.FakeBlockListDataProvider{}
 		logger = &loggerfakes.FakeLogger{}
+		instance = config.Instance{Hostname: "foo"}
 	})
 
 	var _ = Describe("NewPersonData", func() {
 		It("returns persons", func() {
 			group := config.Group{Fields: []config.Field{{FieldName: ptr("id")}, {FieldName: ptr("firstName")}, {FieldName: ptr("lastName")}, {FieldName: ptr("height")}}}
-			data, err := csv.NewPersonData(persons, group, fileDataProvider, blocklistsDataProvider, logger)
+			data, err := csv.NewPersonData(persons, instance, group, privacy.Profile{}, fileDataProvider, blocklistsDataProvider, logger)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(data.Header()).To(Equal([]string{"id", "firstName", "lastName", "height"}))
@@ -60,20 +65,20 @@ var _ = Describe("PersonData", func() {
 			persons := []json.RawMessage{json.RawMessage(`[]`)}
 
 			group := config.Group{Fields: []config.Field{{FieldName: ptr("id")}, {FieldName: ptr("firstName")}, {FieldName: ptr("lastName")}}}
-			data, err := csv.NewPersonData(persons, group, fileDataProvider, blocklistsDataProvider, logger)
+			data, err := csv.NewPersonData(persons, instance, group, privacy.Profile{}, fileDataProvider, blocklistsDataProvider, logger)
 			Expect(data).To(BeNil())
 			Expect(err.Error()).To(ContainSubstring("failed to read person information raw json"))
 		})
 
 		It("skips blocked persons", func() {
-			blocklistsDataProvider.IsBlockedReturnsOnCall(0, true, nil)
-			blocklistsDataProvider.IsBlockedReturnsOnCall(1, false, nil)
+			blocklistsDataProvider.IsBlockedReturnsOnCall(0, &data_provider.BlockMatch{Layer: "global", File: "_all.yml", Entry: 2}, nil)
+			blocklistsDataProvider.IsBlockedReturnsOnCall(1, nil, nil)
 
 			group := config.Group{Name: "test", Fields: []config.Field{{FieldName: ptr("id")}, {FieldName: ptr("unknown")}, {FieldName: ptr("lastName")}}}
-			data, err := csv.NewPersonData(persons, group, fileDataProvider, blocklistsDataProvider, logger)
+			data, err := csv.NewPersonData(persons, instance, group, privacy.Profile{}, fileDataProvider, blocklistsDataProvider, logger)
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(logger.InfoArgsForCall(0)).To(Equal("      -> \"foo_firstname\" \"foo_lastname\" will not be added to csv file"))
+			Expect(logger.InfoArgsForCall(0)).To(Equal("      -> \"foo_firstname\" \"foo_lastname\" will not be added to csv file (global blocklist '_all.yml', entry 2)"))
 			Expect(logger.WarnArgsForCall(0)).To(Equal("      Field 'unknown' does not exist"))
 
 			Expect(data.Header()).To(Equal([]string{"id", "unknown", "lastName"}))
@@ -81,26 +86,19 @@ var _ = Describe("PersonData", func() {
 			Expect(data.Records()[0]).To(Equal([]string{"2", "", "bar_lastname"}))
 		})
 
-		It("does not skip persons if an error occurs while checking blocklists", func() {
-			blocklistsDataProvider.IsBlockedReturnsOnCall(0, false, nil)
-			blocklistsDataProvider.IsBlockedReturnsOnCall(1, false, errors.New("boom"))
+		It("returns an error if a person cannot be checked against the blocklists", func() {
+			blocklistsDataProvider.IsBlockedReturnsOnCall(0, nil, nil)
+			blocklistsDataProvider.IsBlockedReturnsOnCall(1, nil, errors.New("boom"))
 
-			group := config.Group{Name: "test", Fields: []config.Field{{FieldName: ptr("id")}, {FieldName: ptr("unknown")}, {FieldName: ptr("lastName")}}}
-			data, err := csv.NewPersonData(persons, group, fileDataProvider, blocklistsDataProvider, logger)
-			Expect(err).NotTo(HaveOccurred())
-
-			Expect(logger.ErrorArgsForCall(0)).To(Equal("      failed to check if person is blocked: 'boom'"))
-			Expect(logger.WarnArgsForCall(0)).To(Equal("      Field 'unknown' does not exist"))
-
-			Expect(data.Header()).To(Equal([]string{"id", "unknown", "lastName"}))
-			Expect(data.Records()).To(HaveLen(2))
-			Expect(data.Records()[0]).To(Equal([]string{"1", "", "foo_lastname"}))
-			Expect(data.Records()[1]).To(Equal([]string{"2", "", "bar_lastname"}))
+			group := config.Group{Name: "test", Fields: []config.Field{{FieldName: ptr("id")}, {FieldName: ptr("lastName")}}}
+			data, err := csv.NewPersonData(persons, instance, group, privacy.Profile{}, fileDataProvider, blocklistsDataProvider, logger)
+			Expect(err).To(MatchError("failed to check if person is blocked: boom"))
+			Expect(data).To(BeNil())
 		})
 
 		It("sets unknown fields to empty string", func() {
 			group := config.Group{Fields: []config.Field{{FieldName: ptr("id")}, {FieldName: ptr("unknown")}, {FieldName: ptr("lastName")}}}
-			data, err := csv.NewPersonData(persons, group, fileDataProvider, blocklistsDataProvider, logger)
+			data, err := csv.NewPersonData(persons, instance, group, privacy.Profile{}, fileDataProvider, blocklistsDataProvider, logger)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(logger.WarnArgsForCall(0)).To(Equal("      Field 'unknown' does not exist"))
@@ -118,7 +116,7 @@ var _ = Describe("PersonData", func() {
         	}`
 			persons = []json.RawMessage{json.RawMessage(person)}
 			group := config.Group{Fields: []config.Field{{FieldName: ptr("id")}, {FieldName: ptr("date")}}}
-			data, err := csv.NewPersonData(persons, group, fileDataProvider, blocklistsDataProvider, logger)
+			data, err := csv.NewPersonData(persons, instance, group, privacy.Profile{}, fileDataProvider, blocklistsDataProvider, logger)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(data.Header()).To(Equal([]string{"id", "date"}))
@@ -133,7 +131,7 @@ var _ = Describe("PersonData", func() {
 			}`
 			persons = []json.RawMessage{json.RawMessage(person)}
 			group := config.Group{Fields: []config.Field{{FieldName: ptr("id")}, {FieldName: ptr("height")}}}
-			data, err := csv.NewPersonData(persons, group, fileDataProvider, blocklistsDataProvider, logger)
+			data, err := csv.NewPersonData(persons, instance, group, privacy.Profile{}, fileDataProvider, blocklistsDataProvider, logger)
 
 			Expect(err).NotTo(HaveOccurred())
 			Expect(data.Header()).To(Equal([]string{"id", "height"}))
@@ -148,7 +146,7 @@ var _ = Describe("PersonData", func() {
         	}`
 			persons = []json.RawMessage{json.RawMessage(person)}
 			group := config.Group{Fields: []config.Field{{FieldName: ptr("id")}, {FieldName: ptr("isSet")}}}
-			data, err := csv.NewPersonData(persons, group, fileDataProvider, blocklistsDataProvider, logger)
+			data, err := csv.NewPersonData(persons, instance, group, privacy.Profile{}, fileDataProvider, blocklistsDataProvider, logger)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(data.Header()).To(Equal([]string{"id", "isSet"}))
@@ -158,12 +156,30 @@ var _ = Describe("PersonData", func() {
 
 		It("sets unknown fields to empty string", func() {
 			group := config.Group{Fields: []config.Field{{FieldName: ptr("id")}, {FieldName: ptr("unknown")}}}
-			_, err := csv.NewPersonData(persons, group, fileDataProvider, blocklistsDataProvider, logger)
+			_, err := csv.NewPersonData(persons, instance, group, privacy.Profile{}, fileDataProvider, blocklistsDataProvider, logger)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(logger.WarnArgsForCall(0)).To(Equal("      Field 'unknown' does not exist"))
 		})
 
+		It("returns nested values of membership attributes", func() {
+			person := `{
+				"id": 1,
+				"member": {
+					"memberStartDate": "2024-01-01",
+					"fields": {"allergies": "nuts"}
+				}
+			}`
+			persons = []json.RawMessage{json.RawMessage(person)}
+			group := config.Group{Fields: []config.Field{{FieldName: ptr("id")}, {FieldName: ptr("member.memberStartDate")}, {FieldName: ptr("member.fields.allergies")}}}
+			data, err := csv.NewPersonData(persons, instance, group, privacy.Profile{}, fileDataProvider, blocklistsDataProvider, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(data.Header()).To(Equal([]string{"id", "member.memberStartDate", "member.fields.allergies"}))
+			Expect(data.Records()).To(HaveLen(1))
+			Expect(data.Records()[0]).To(Equal([]string{"1", "2024-01-01", "nuts"}))
+		})
+
 		It("returns mapped data for string key fields", func() {
 			person1 := `{
 				"id": 1,
@@ -174,13 +190,13 @@ var _ = Describe("PersonData", func() {
 			fileDataProvider.GetDataReturnsOnCall(0, "mapped_value", nil)
 
 			group := config.Group{Fields: []config.Field{{FieldName: ptr("id")}, {Object: &config.FieldInformation{FieldName: "key", ColumnName: "mappedColumn"}}}}
-			data, err := csv.NewPersonData(persons, group, fileDataProvider, blocklistsDataProvider, logger)
+			data, err := csv.NewPersonData(persons, instance, group, privacy.Profile{}, fileDataProvider, blocklistsDataProvider, logger)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fileDataProvider.GetDataCallCount()).To(Equal(1))
 
-			fieldName, fieldValue := fileDataProvider.GetDataArgsForCall(0)
-			Expect(fieldName).To(Equal("key"))
+			field, fieldValue, _, _ := fileDataProvider.GetDataArgsForCall(0)
+			Expect(field.GetFieldName()).To(Equal("key"))
 			Expect(fieldValue).To(Equal(json.RawMessage(`"value"`)))
 
 			Expect(data.Header()).To(Equal([]string{"id", "mappedColumn"}))
@@ -199,13 +215,13 @@ var _ = Describe("PersonData", func() {
 			fileDataProvider.GetDataReturnsOnCall(0, "", errors.New("not found"))
 
 			group := config.Group{Fields: []config.Field{{FieldName: ptr("id")}, {Object: &config.FieldInformation{FieldName: "key", ColumnName: "mappedColumn"}}}}
-			data, err := csv.NewPersonData(persons, group, fileDataProvider, blocklistsDataProvider, logger)
+			data, err := csv.NewPersonData(persons, instance, group, privacy.Profile{}, fileDataProvider, blocklistsDataProvider, logger)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fileDataProvider.GetDataCallCount()).To(Equal(1))
 
-			fieldName, fieldValue := fileDataProvider.GetDataArgsForCall(0)
-			Expect(fieldName).To(Equal("key"))
+			field, fieldValue, _, _ := fileDataProvider.GetDataArgsForCall(0)
+			Expect(field.GetFieldName()).To(Equal("key"))
 			Expect(fieldValue).To(Equal(json.RawMessage(`"value"`)))
 
 			Expect(data.Header()).To(Equal([]string{"id", "mappedColumn"}))
@@ -214,6 +230,24 @@ var _ = Describe("PersonData", func() {
 
 		})
 
+		It("logs a missing mapping file once", func() {
+			persons = []json.RawMessage{
+				json.RawMessage(`{"id": 1, "key": "a"}`),
+				json.RawMessage(`{"id": 2, "key": "b"}`),
+				json.RawMessage(`{"id": 3, "key": "c"}`),
+			}
+
+			fileDataProvider.GetDataReturns("", fmt.Errorf("%w, searched 'key.yml'", data_provider.ErrMappingFileNotFound))
+
+			group := config.Group{Fields: []config.Field{{FieldName: ptr("id")}, {Object: &config.FieldInformation{FieldName: "key", ColumnName: "mappedColumn"}}}}
+			data, err := csv.NewPersonData(persons, instance, group, privacy.Profile{}, fileDataProvider, blocklistsDataProvider, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(data.Records()).To(Equal([][]string{{"1", ""}, {"2", ""}, {"3", ""}}))
+			Expect(logger.ErrorCallCount()).To(Equal(1))
+			Expect(logger.ErrorArgsForCall(0)).To(Equal("     failed to get data for field 'key': the mapping file could not be found, searched 'key.yml'"))
+		})
+
 		It("returns mapped data for float64 key fields", func() {
 			person1 := `{
 				"id": 1,
@@ -224,13 +258,13 @@ var _ = Describe("PersonData", func() {
 			fileDataProvider.GetDataReturnsOnCall(0, "mapped_value", nil)
 
 			group := config.Group{Fields: []config.Field{{FieldName: ptr("id")}, {Object: &config.FieldInformation{FieldName: "key", ColumnName: "mappedColumn"}}}}
-			data, err := csv.NewPersonData(persons, group, fileDataProvider, blocklistsDataProvider, logger)
+			data, err := csv.NewPersonData(persons, instance, group, privacy.Profile{}, fileDataProvider, blocklistsDataProvider, logger)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fileDataProvider.GetDataCallCount()).To(Equal(1))
 
-			fieldName, fieldValue := fileDataProvider.GetDataArgsForCall(0)
-			Expect(fieldName).To(Equal("key"))
+			field, fieldValue, _, _ := fileDataProvider.GetDataArgsForCall(0)
+			Expect(field.GetFieldName()).To(Equal("key"))
 			Expect(fieldValue).To(Equal(json.RawMessage(`1.2`)))
 
 			Expect(data.Header()).To(Equal([]string{"id", "mappedColumn"}))
@@ -249,13 +283,13 @@ var _ = Describe("PersonData", func() {
 			fileDataProvider.GetDataReturnsOnCall(0, "", errors.New("not found"))
 
 			group := config.Group{Fields: []config.Field{{FieldName: ptr("id")}, {Object: &config.FieldInformation{FieldName: "key", ColumnName: "mappedColumn"}}}}
-			data, err := csv.NewPersonData(persons, group, fileDataProvider, blocklistsDataProvider, logger)
+			data, err := csv.NewPersonData(persons, instance, group, privacy.Profile{}, fileDataProvider, blocklistsDataProvider, logger)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fileDataProvider.GetDataCallCount()).To(Equal(1))
 
-			fieldName, fieldValue := fileDataProvider.GetDataArgsForCall(0)
-			Expect(fieldName).To(Equal("key"))
+			field, fieldValue, _, _ := fileDataProvider.GetDataArgsForCall(0)
+			Expect(field.GetFieldName()).To(Equal("key"))
 			Expect(fieldValue).To(Equal(json.RawMessage(`1.2`)))
 
 			Expect(data.Header()).To(Equal([]string{"id", "mappedColumn"}))
@@ -263,5 +297,125 @@ var _ = Describe("PersonData", func() {
 			Expect(data.Records()[0]).To(Equal([]string{"1", ""}))
 
 		})
+
+		It("skips persons that do not match the filter", func() {
+			group := config.Group{Filter: "height > 2", Fields: []config.Field{{FieldName: ptr("id")}, {FieldName: ptr("lastName")}}}
+			data, err := csv.NewPersonData(persons, instance, group, privacy.Profile{}, fileDataProvider, blocklistsDataProvider, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(data.Records()).To(Equal([][]string{{"1", "foo_lastname"}}))
+			Expect(logger.InfoArgsForCall(logger.InfoCallCount() - 1)).To(Equal("      filtered 1 persons"))
+		})
+
+		It("uses mapped values of the group fields in the filter", func() {
+			fileDataProvider.GetDataStub = func(field config.Field, value json.RawMessage, _ config.Instance, _ config.Group) (string, error) {
+				if string(value) == `"foo_lastname"` {
+					return "Foo", nil
+				}
+				return "Bar", nil
+			}
+
+			group := config.Group{
+				Filter: "mapped(lastName) == 'Bar'",
+				Fields: []config.Field{{FieldName: ptr("id")}, {Object: &config.FieldInformation{FieldName: "lastName", ColumnName: "name"}}},
+			}
+			data, err := csv.NewPersonData(persons, instance, group, privacy.Profile{}, fileDataProvider, blocklistsDataProvider, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(data.Records()).To(Equal([][]string{{"2", "Bar"}}))
+			field, _, filterInstance, filterGroup := fileDataProvider.GetDataArgsForCall(0)
+			Expect(field.GetColumnName()).To(Equal("name"))
+			Expect(filterInstance).To(Equal(instance))
+			Expect(filterGroup.Filter).To(Equal(group.Filter))
+		})
+
+		It("skips persons if the filter cannot be evaluated", func() {
+			fileDataProvider.GetDataReturns("", errors.New("not found"))
+
+			group := config.Group{Filter: "mapped(lastName) == 'Bar'", Fields: []config.Field{{FieldName: ptr("id")}}}
+			data, err := csv.NewPersonData(persons, instance, group, privacy.Profile{}, fileDataProvider, blocklistsDataProvider, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(data.Records()).To(BeEmpty())
+			Expect(logger.ErrorArgsForCall(0)).To(Equal("      failed to evaluate filter for \"foo_firstname\" \"foo_lastname\": 'not found'"))
+		})
+
+		It("returns an error if the filter is invalid", func() {
+			group := config.Group{Filter: "height >", Fields: []config.Field{{FieldName: ptr("id")}}}
+			data, err := csv.NewPersonData(persons, instance, group, privacy.Profile{}, fileDataProvider, blocklistsDataProvider, logger)
+			Expect(data).To(BeNil())
+			Expect(err.Error()).To(ContainSubstring("failed to parse filter"))
+		})
+
+		It("returns the excluded persons with the rule that excluded them", func() {
+			persons = append(persons, json.RawMessage(`{"id": 3, "firstName": "baz_firstname", "lastName": "baz_lastname", "height": 1.5}`))
+			blocklistsDataProvider.IsBlockedReturnsOnCall(0, &data_provider.BlockMatch{Layer: "group", File: "test.yml", Entry: 3, Fields: []string{"zip", "city"}}, nil)
+
+			group := config.Group{Name: "test", Filter: "height > 1.2", Fields: []config.Field{{FieldName: ptr("id")}}}
+			data, err := csv.NewPersonData(persons, instance, group, privacy.Profile{}, fileDataProvider, blocklistsDataProvider, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(data.Records()).To(Equal([][]string{{"3"}}))
+			Expect(data.Excluded().Header()).To(Equal([]string{"id", "firstName", "lastName", "reason", "blocklist", "entry", "fields"}))
+			Expect(data.Excluded().Records()).To(Equal([][]string{
+				{"1", "foo_firstname", "foo_lastname", "group blocklist", "test.yml", "3", "zip, city"},
+				{"2", "bar_firstname", "bar_lastname", "filter", "", "", "height"},
+			}))
+		})
+
+		It("applies the privacy rules of the fields and the profile", func() {
+			persons = []json.RawMessage{json.RawMessage(`{"id": 1, "firstName": "John", "email": "john@example.com", "birthday": "2001-05-17"}`)}
+			profile := privacy.Profile{Rules: map[string]privacy.Rule{"birthday": privacy.Month, "email": privacy.Hash}, Secret: "secret"}
+			notMapped := false
+
+			group := config.Group{Fields: []config.Field{
+				{FieldName: ptr("id")},
+				{Object: &config.FieldInformation{FieldName: "email", ColumnName: "email", Privacy: privacy.Mask, Mapped: &notMapped}},
+				{FieldName: ptr("birthday")},
+			}}
+			data, err := csv.NewPersonData(persons, instance, group, profile, fileDataProvider, blocklistsDataProvider, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fileDataProvider.GetDataCallCount()).To(Equal(0))
+			Expect(data.Records()).To(Equal([][]string{{"1", "j***@example.com", "2001-05"}}))
+		})
+
+		It("applies the privacy rule of a field to the mapped value", func() {
+			persons = []json.RawMessage{json.RawMessage(`{"id": 1, "sexId": 2}`)}
+			fileDataProvider.GetDataReturns("female", nil)
+
+			group := config.Group{Fields: []config.Field{
+				{FieldName: ptr("id")},
+				{Object: &config.FieldInformation{FieldName: "sexId", ColumnName: "sex", Privacy: privacy.Mask}},
+			}}
+			data, err := csv.NewPersonData(persons, instance, group, privacy.Profile{}, fileDataProvider, blocklistsDataProvider, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fileDataProvider.GetDataCallCount()).To(Equal(1))
+			Expect(data.Records()).To(Equal([][]string{{"1", "f***"}}))
+		})
+
+		It("exports an empty value if a privacy rule cannot be applied", func() {
+			persons = []json.RawMessage{json.RawMessage(`{"id": 1, "birthday": "unknown"}`)}
+			profile := privacy.Profile{Rules: map[string]privacy.Rule{"birthday": privacy.DropYear}}
+
+			group := config.Group{Fields: []config.Field{{FieldName: ptr("id")}, {FieldName: ptr("birthday")}}}
+			data, err := csv.NewPersonData(persons, instance, group, profile, fileDataProvider, blocklistsDataProvider, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(data.Records()).To(Equal([][]string{{"1", ""}}))
+			Expect(logger.ErrorArgsForCall(0)).To(Equal("     failed to apply privacy rule to field 'birthday': the value is not a date"))
+		})
+
+		It("applies the privacy rules to the excluded persons", func() {
+			blocklistsDataProvider.IsBlockedReturnsOnCall(0, &data_provider.BlockMatch{Layer: "group", File: "test.yml", Entry: 1, Fields: []string{"id"}}, nil)
+			profile := privacy.Profile{Rules: map[string]privacy.Rule{"lastName": privacy.Mask}}
+
+			group := config.Group{Fields: []config.Field{{FieldName: ptr("id")}, {Object: &config.FieldInformation{FieldName: "firstName", ColumnName: "name", Privacy: privacy.Mask}}}}
+			data, err := csv.NewPersonData(persons, instance, group, profile, fileDataProvider, blocklistsDataProvider, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(data.Excluded().Records()).To(Equal([][]string{{"1", "f***", "f***", "group blocklist", "test.yml", "1", "id"}}))
+		})
 	})
 })
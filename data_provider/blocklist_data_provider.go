@@ -1,82 +1,245 @@
 package data_provider
 
 import (
-	"bytes"
 	"ctRestClient/config"
 	"ctRestClient/logger"
+	"ctRestClient/rest"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"gopkg.in/yaml.v3"
 )
 
 //counterfeiter:generate . BlockListDataProvider
 type BlockListDataProvider interface {
-	IsBlocked(personJson map[string]json.RawMessage, group config.Group) (bool, error)
+	IsBlocked(personJson map[string]json.RawMessage, instance config.Instance, group config.Group) (*BlockMatch, error)
 
-	BlockListExists(group config.Group) bool
+	BlockListFiles(instance config.Instance, group config.Group) []string
+
+	// UnmatchedEntries returns the entries of all checked blocklists that
+	// did not block any person.
+	UnmatchedEntries() []UnmatchedEntry
+
+	// SetGroupsEndpoint sets the endpoint that resolves the members of the
+	// groups referenced in blocklists of an instance.
+	SetGroupsEndpoint(instance config.Instance, groupsEndpoint rest.GroupsEndpoint)
+}
+
+// Layers of blocklists, the global and instance blocklists are inherited by
+// all groups.
+const (
+	GlobalLayer   = "global"
+	InstanceLayer = "instance"
+	GroupLayer    = "group"
+)
+
+// InheritedBlocklistFileName is the file name of the global blocklist and of
+// the blocklists of an instance.
+const InheritedBlocklistFileName = "_all.yml"
+
+// A BlockMatch describes the blocklist entry that blocked a person.
+type BlockMatch struct {
+	Layer string
+	File  string
+	// Entry is the 1-based index of the entry in the blocklist file.
+	Entry int
+	// Fields are the names of the matching fields of the entry.
+	Fields []string
+}
+
+func (m BlockMatch) String() string {
+	return fmt.Sprintf("%s blocklist '%s', entry %d", m.Layer, m.File, m.Entry)
 }
 
-type addressData map[string]interface{}
+// An UnmatchedEntry is a blocklist entry that did not block any person.
+type UnmatchedEntry struct {
+	File string
+	// Entry is the 1-based index of the entry in the blocklist file.
+	Entry      int
+	Line       int
+	Definition string
+}
+
+type blocklistFile struct {
+	layer string
+	name  string
+}
 
 type cacheEntry struct {
-	data []addressData
-	err  error
+	data    []blocklistEntry
+	matched []bool
+	err     error
+}
+
+// groupMembers are the person IDs of a group referenced in a blocklist or
+// the error of their lookup.
+type groupMembers struct {
+	ids []int
+	err error
 }
 
 type blockListDataProvider struct {
-	dataDir   string
-	dataCache map[string]cacheEntry
-	logger    logger.Logger
+	dataDir        string
+	dataCache      map[string]cacheEntry
+	groupsEndpoint map[string]rest.GroupsEndpoint
+	groupMembers   map[string]groupMembers
+	// groupBlocklists are the resolved names of the group blocklists, see
+	// groupBlocklistName
+	groupBlocklists map[string]string
+	logger          logger.Logger
 }
 
+// NewBlockListDataProvider creates a provider for blocklists. The following
+// blocklists apply to a group, all of them are checked:
+//
+//	<dataDir>/_all.yml                global blocklist
+//	<dataDir>/<hostname>/_all.yml     blocklist of the instance
+//	<dataDir>/<hostname>/<group>.yml  blocklist of the group
+//	<dataDir>/<group>.yml             blocklist of the group
+//
+// Groups with 'inherit_blocklists: false' only use their own blocklists.
 func NewBlockListDataProvider(dataDir string, logger logger.Logger) BlockListDataProvider {
 	return &blockListDataProvider{
-		dataDir:   dataDir,
-		dataCache: make(map[string]cacheEntry),
-		logger:    logger,
+		dataDir:         dataDir,
+		dataCache:       make(map[string]cacheEntry),
+		groupsEndpoint:  make(map[string]rest.GroupsEndpoint),
+		groupMembers:    make(map[string]groupMembers),
+		groupBlocklists: make(map[string]string),
+		logger:          logger,
+	}
+}
+
+func (bp *blockListDataProvider) SetGroupsEndpoint(instance config.Instance, groupsEndpoint rest.GroupsEndpoint) {
+	bp.groupsEndpoint[instance.Hostname] = groupsEndpoint
+}
+
+// groupMemberIds returns the IDs of the members of a ChurchTools group of an
+// instance. The members are requested once per instance and group, a failed
+// lookup is not repeated but returns the same error for every person.
+func (bp *blockListDataProvider) groupMemberIds(instance config.Instance, groupName string) ([]int, error) {
+	cacheKey := instance.Hostname + "/" + groupName
+	if members, ok := bp.groupMembers[cacheKey]; ok {
+		return members.ids, members.err
 	}
+
+	memberIds, err := bp.requestGroupMemberIds(instance, groupName)
+	bp.groupMembers[cacheKey] = groupMembers{ids: memberIds, err: err}
+	return memberIds, err
 }
 
-func (bp *blockListDataProvider) IsBlocked(personJson map[string]json.RawMessage, group config.Group) (bool, error) {
-	var entry cacheEntry
-	entry, err := bp.loadBlocklist(group.BlocklistFileName())
+func (bp *blockListDataProvider) requestGroupMemberIds(instance config.Instance, groupName string) ([]int, error) {
+	groupsEndpoint, ok := bp.groupsEndpoint[instance.Hostname]
+	if !ok {
+		return nil, fmt.Errorf("the members of group '%s' cannot be resolved for instance '%s'", groupName, instance.Hostname)
+	}
+
+	ctGroup, err := groupsEndpoint.GetGroup(groupName)
 	if err != nil {
-		return false, fmt.Errorf("failed to load blocklist %s: %w", group.BlocklistFileName(), err)
+		return nil, fmt.Errorf("failed to get blocked group '%s', %w", groupName, err)
 	}
 
-	for _, blockedAddress := range entry.data {
-		matched := true
+	members, err := groupsEndpoint.GetGroupMembers(ctGroup.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get members of blocked group '%s', %w", groupName, err)
+	}
+
+	memberIds := make([]int, 0, len(members))
+	for _, member := range members {
+		memberIds = append(memberIds, member.PersonId)
+	}
+	return memberIds, nil
+}
 
-		for fieldName, blockedFieldValue := range blockedAddress {
-			if personJsonFieldValue, exists := personJson[fieldName]; !exists {
-				bp.logger.Warn(fmt.Sprintf("      Ignoring blocklist element %v since field '%s' is not available in the person data", blockedAddress, fieldName))
-				matched = false
-				break
-			} else {
+// IsBlocked returns the first entry that matches the person. All entries are
+// checked, so that entries shadowed by an earlier entry or layer are not
+// reported as unmatched.
+func (bp *blockListDataProvider) IsBlocked(personJson map[string]json.RawMessage, instance config.Instance, group config.Group) (*BlockMatch, error) {
+	var match *BlockMatch
+	for _, file := range bp.blocklistFiles(instance, group) {
+		entry, err := bp.loadBlocklist(file.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load blocklist %s: %w", file.name, err)
+		}
 
-				// Unescape the json data that contains (\u00df and \u00fc instead of ß and ü) for later string comparison with data from the blocklist.
-				personJsonValue, err := unescapeUnicodeCharacters(personJsonFieldValue)
+		for i, blocklistEntry := range entry.data {
+			var groupMemberIds []int
+			if blocklistEntry.groupName != "" {
+				groupMemberIds, err = bp.groupMemberIds(instance, blocklistEntry.groupName)
 				if err != nil {
-					return false, fmt.Errorf("failed to unescape unicode characters for field %s: %w", fieldName, err)
+					return nil, err
 				}
+			}
 
-				if !bytes.Equal([]byte(personJsonValue), blockedFieldValue.(json.RawMessage)) {
-					matched = false
-					break
+			matched, missingField, err := blocklistEntry.matches(personJson, groupMemberIds)
+			if err != nil {
+				return nil, err
+			}
+			if missingField != "" {
+				bp.logger.Warn(fmt.Sprintf("      Ignoring blocklist element %v since field '%s' is not available in the person data", blocklistEntry, missingField))
+				continue
+			}
+			if matched {
+				entry.matched[i] = true
+				if match == nil {
+					match = &BlockMatch{
+						Layer:  file.layer,
+						File:   file.name,
+						Entry:  i + 1,
+						Fields: blocklistEntry.fieldNames(),
+					}
 				}
 			}
 		}
-		if matched {
-			return true, nil
+	}
+
+	return match, nil
+}
+
+// blocklistFiles returns the blocklists of a group in the order they are
+// checked.
+func (bp *blockListDataProvider) blocklistFiles(instance config.Instance, group config.Group) []blocklistFile {
+	var files []blocklistFile
+	if group.InheritsBlocklists() {
+		files = append(files, blocklistFile{layer: GlobalLayer, name: InheritedBlocklistFileName})
+		if instance.Hostname != "" {
+			files = append(files, blocklistFile{layer: InstanceLayer, name: filepath.Join(instance.Hostname, InheritedBlocklistFileName)})
 		}
 	}
+	if instance.Hostname != "" {
+		files = append(files, blocklistFile{layer: GroupLayer, name: bp.groupBlocklistName(instance.Hostname, group)})
+	}
+	files = append(files, blocklistFile{layer: GroupLayer, name: bp.groupBlocklistName("", group)})
+	return files
+}
 
-	return false, nil
+// groupBlocklistName returns the name of the blocklist of the group in the
+// directory. If it does not exist but the blocklist with the name of earlier
+// versions does, that one is used with a warning, so that no blocked person
+// is exported after an update.
+func (bp *blockListDataProvider) groupBlocklistName(dir string, group config.Group) string {
+	name := filepath.Join(dir, group.BlocklistFileName())
+	if resolvedName, ok := bp.groupBlocklists[name]; ok {
+		return resolvedName
+	}
+
+	resolvedName := name
+	legacyName := filepath.Join(dir, group.LegacyBlocklistFileName())
+	if legacyName != name && !bp.blocklistExists(name) && bp.blocklistExists(legacyName) {
+		bp.logger.Warn(fmt.Sprintf("      using the blocklist '%s' of an earlier version, rename it to '%s'", legacyName, name))
+		resolvedName = legacyName
+	}
+	bp.groupBlocklists[name] = resolvedName
+	return resolvedName
+}
+
+func (bp *blockListDataProvider) blocklistExists(name string) bool {
+	_, err := os.Stat(filepath.Join(bp.dataDir, name))
+	return err == nil
 }
 
 func (bp *blockListDataProvider) loadBlocklist(name string) (cacheEntry, error) {
@@ -101,48 +264,90 @@ func (bp *blockListDataProvider) loadBlocklist(name string) (cacheEntry, error)
 		return entry, err
 	}
 
-	if len(yamlData) == 0 {
-		entry := cacheEntry{data: []addressData{}}
-		bp.dataCache[name] = entry
-		return entry, nil
+	blocked, err := parseBlocklist(yamlData)
+	entry := cacheEntry{data: blocked, matched: make([]bool, len(blocked)), err: err}
+	bp.dataCache[name] = entry
+
+	return entry, err
+}
+
+// ReadBlocklistFile reads a blocklist file and returns the number of its
+// entries.
+func ReadBlocklistFile(path string) (int, error) {
+	yamlData, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
 	}
+	blocked, err := parseBlocklist(yamlData)
+	return len(blocked), err
+}
 
-	var blocked []addressData
-	if err := yaml.Unmarshal(yamlData, &blocked); err != nil {
-		entry := cacheEntry{err: err}
-		bp.dataCache[name] = entry
-		return entry, err
+// parseBlocklist parses the entries of a blocklist file.
+func parseBlocklist(yamlData []byte) ([]blocklistEntry, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(yamlData, &document); err != nil {
+		return nil, err
 	}
 
-	for i, addr := range blocked {
-		for key, val := range addr {
-			jsonBytes, err := json.Marshal(val)
-			if err != nil {
-				return cacheEntry{}, fmt.Errorf("failed to marshal blocklist value for key %s: %w", key, err)
-			}
-			addr[key] = json.RawMessage(jsonBytes)
-		}
-		blocked[i] = addr
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 || document.Content[0].Tag == "!!null" {
+		return []blocklistEntry{}, nil
 	}
 
-	entry := cacheEntry{data: blocked}
-	bp.dataCache[name] = entry
+	listNode := document.Content[0]
+	if listNode.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("line %d: blocklist must be a list of entries", listNode.Line)
+	}
+
+	blocked := make([]blocklistEntry, 0, len(listNode.Content))
+	for _, entryNode := range listNode.Content {
+		entry, err := parseBlocklistEntry(entryNode)
+		if err != nil {
+			return nil, err
+		}
+		blocked = append(blocked, entry)
+	}
 
-	return entry, nil
+	return blocked, nil
 }
 
-func (bp *blockListDataProvider) BlockListExists(group config.Group) bool {
-	blocklistFilePath := filepath.Join(bp.dataDir, group.BlocklistFileName())
-	_, err := os.Stat(blocklistFilePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return false
-		} else {
-			bp.logger.Error(fmt.Sprintf("      failed to evaluate blocklist existence: %v", err))
+func (bp *blockListDataProvider) UnmatchedEntries() []UnmatchedEntry {
+	names := make([]string, 0, len(bp.dataCache))
+	for name := range bp.dataCache {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var unmatched []UnmatchedEntry
+	for _, name := range names {
+		entry := bp.dataCache[name]
+		for i, blocklistEntry := range entry.data {
+			if !entry.matched[i] {
+				unmatched = append(unmatched, UnmatchedEntry{
+					File:       name,
+					Entry:      i + 1,
+					Line:       blocklistEntry.line,
+					Definition: blocklistEntry.String(),
+				})
+			}
 		}
 	}
+	return unmatched
+}
 
-	return true
+// BlockListFiles returns the existing blocklists of a group.
+func (bp *blockListDataProvider) BlockListFiles(instance config.Instance, group config.Group) []string {
+	var existingFiles []string
+	for _, file := range bp.blocklistFiles(instance, group) {
+		_, err := os.Stat(filepath.Join(bp.dataDir, file.name))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				bp.logger.Error(fmt.Sprintf("      failed to evaluate blocklist existence: %v", err))
+			}
+			continue
+		}
+		existingFiles = append(existingFiles, file.name)
+	}
+	return existingFiles
 }
 
 func unescapeUnicodeCharacters(jsonRaw json.RawMessage) (json.RawMessage, error) {
@@ -4,8 +4,11 @@ import (
 	"ctRestClient/config"
 	"ctRestClient/data_provider"
 	"ctRestClient/logger/loggerfakes"
+	"ctRestClient/rest"
+	"ctRestClient/rest/restfakes"
 	"ctRestClient/testutil"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 
@@ -50,9 +53,9 @@ var _ = Describe("BlocklistDataProvider", func() {
 	var _ = Describe("IsBlocked", func() {
 		It("returns false if blocklist is not existing", func() {
 
-			result, err := dp.IsBlocked(personJson, config.Group{Name: "not_existing_blocklist"})
+			result, err := dp.IsBlocked(personJson, config.Instance{}, config.Group{Name: "not_existing_blocklist"})
 			Expect(err).ToNot(HaveOccurred())
-			Expect(result).To(Equal(false))
+			Expect(result).To(BeNil())
 		})
 
 		It("returns false if blocklist is empty", func() {
@@ -60,9 +63,9 @@ var _ = Describe("BlocklistDataProvider", func() {
 			err = os.WriteFile(blocklistFilePath, []byte(``), 0644)
 			Expect(err).ToNot(HaveOccurred())
 
-			result, err := dp.IsBlocked(personJson, group)
+			result, err := dp.IsBlocked(personJson, config.Instance{}, group)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(result).To(Equal(false))
+			Expect(result).To(BeNil())
 		})
 
 		It("returns false if blocklist has no blocked addresses", func() {
@@ -70,9 +73,9 @@ var _ = Describe("BlocklistDataProvider", func() {
 			err = os.WriteFile(blocklistFilePath, []byte(`---`), 0644)
 			Expect(err).ToNot(HaveOccurred())
 
-			result, err := dp.IsBlocked(personJson, group)
+			result, err := dp.IsBlocked(personJson, config.Instance{}, group)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(result).To(Equal(false))
+			Expect(result).To(BeNil())
 		})
 
 		It("returns false if blocklist is not matching the person json - zipcode", func() {
@@ -84,9 +87,9 @@ var _ = Describe("BlocklistDataProvider", func() {
 			err = os.WriteFile(blocklistFilePath, []byte(yamlContent), 0644)
 			Expect(err).ToNot(HaveOccurred())
 
-			result, err := dp.IsBlocked(personJson, group)
+			result, err := dp.IsBlocked(personJson, config.Instance{}, group)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(result).To(Equal(false))
+			Expect(result).To(BeNil())
 		})
 
 		It("returns false if blocklist is not matching the person json - age", func() {
@@ -98,9 +101,9 @@ var _ = Describe("BlocklistDataProvider", func() {
 			err = os.WriteFile(blocklistFilePath, []byte(yamlContent), 0644)
 			Expect(err).ToNot(HaveOccurred())
 
-			result, err := dp.IsBlocked(personJson, group)
+			result, err := dp.IsBlocked(personJson, config.Instance{}, group)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(result).To(Equal(false))
+			Expect(result).To(BeNil())
 		})
 
 		It("returns false if blocklist is not matching the person json - isDead", func() {
@@ -112,9 +115,9 @@ var _ = Describe("BlocklistDataProvider", func() {
 			err = os.WriteFile(blocklistFilePath, []byte(yamlContent), 0644)
 			Expect(err).ToNot(HaveOccurred())
 
-			result, err := dp.IsBlocked(personJson, group)
+			result, err := dp.IsBlocked(personJson, config.Instance{}, group)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(result).To(Equal(false))
+			Expect(result).To(BeNil())
 		})
 
 		It("returns false if blocklist is not matching the person json - sexId", func() {
@@ -126,9 +129,9 @@ var _ = Describe("BlocklistDataProvider", func() {
 			err = os.WriteFile(blocklistFilePath, []byte(yamlContent), 0644)
 			Expect(err).ToNot(HaveOccurred())
 
-			result, err := dp.IsBlocked(personJson, group)
+			result, err := dp.IsBlocked(personJson, config.Instance{}, group)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(result).To(Equal(false))
+			Expect(result).To(BeNil())
 		})
 
 		It("returns false if blocklist is not matching the person json - weddingDate", func() {
@@ -140,9 +143,9 @@ var _ = Describe("BlocklistDataProvider", func() {
 			err = os.WriteFile(blocklistFilePath, []byte(yamlContent), 0644)
 			Expect(err).ToNot(HaveOccurred())
 
-			result, err := dp.IsBlocked(personJson, group)
+			result, err := dp.IsBlocked(personJson, config.Instance{}, group)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(result).To(Equal(false))
+			Expect(result).To(BeNil())
 		})
 
 		It("returns false if blocklist is not fully matching the person json", func() {
@@ -156,9 +159,9 @@ var _ = Describe("BlocklistDataProvider", func() {
 			err = os.WriteFile(blocklistFilePath, []byte(yamlContent), 0644)
 			Expect(err).ToNot(HaveOccurred())
 
-			result, err := dp.IsBlocked(personJson, group)
+			result, err := dp.IsBlocked(personJson, config.Instance{}, group)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(result).To(Equal(false))
+			Expect(result).To(BeNil())
 		})
 
 		It("returns true if blocklist is matching the person json", func() {
@@ -172,9 +175,9 @@ var _ = Describe("BlocklistDataProvider", func() {
 			err = os.WriteFile(blocklistFilePath, []byte(yamlContent), 0644)
 			Expect(err).ToNot(HaveOccurred())
 
-			result, err := dp.IsBlocked(personJson, group)
+			result, err := dp.IsBlocked(personJson, config.Instance{}, group)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(result).To(Equal(true))
+			Expect(result).NotTo(BeNil())
 		})
 
 		It("returns true if blocklist is matching the person json (comparing all data)", func() {
@@ -192,9 +195,499 @@ var _ = Describe("BlocklistDataProvider", func() {
 			err = os.WriteFile(blocklistFilePath, []byte(yamlContent), 0644)
 			Expect(err).ToNot(HaveOccurred())
 
-			result, err := dp.IsBlocked(personJson, group)
+			result, err := dp.IsBlocked(personJson, config.Instance{}, group)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(result).To(Equal(true))
+			Expect(result).NotTo(BeNil())
 		})
+
+		var _ = Describe("match options", func() {
+			writeBlocklist := func(content string) {
+				yamlContent := testutil.YamlToByteArray(content)
+				err := os.WriteFile(filepath.Join(tempDataDir, "mappedField.yml"), []byte(yamlContent), 0644)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			BeforeEach(func() {
+				personJson["street"] = json.RawMessage(`"Hauptstra\u00dfe  1 "`)
+				personJson["city"] = json.RawMessage(`"Gl\u00fccksstadt"`)
+			})
+
+			It("matches case-insensitive", func() {
+				writeBlocklist(`
+					---
+					- ignore_case: true
+					  fields:
+					    city: GLÜCKSSTADT
+					`)
+
+				result, err := dp.IsBlocked(personJson, config.Instance{}, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).NotTo(BeNil())
+			})
+
+			It("matches with normalized whitespace", func() {
+				writeBlocklist(`
+					---
+					- normalize_whitespace: true
+					  fields:
+					    street: "Hauptstraße 1"
+					`)
+
+				result, err := dp.IsBlocked(personJson, config.Instance{}, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).NotTo(BeNil())
+			})
+
+			It("matches with normalized street abbreviations", func() {
+				writeBlocklist(`
+					---
+					- normalize_streets: true
+					  normalize_whitespace: true
+					  fields:
+					    street: "Hauptstr.1"
+					`)
+
+				result, err := dp.IsBlocked(personJson, config.Instance{}, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).NotTo(BeNil())
+			})
+
+			It("does not normalize streets starting with 'str' followed by a non-ASCII letter", func() {
+				personJson["street"] = json.RawMessage(`"Strähleweg 4"`)
+				writeBlocklist(`
+					---
+					- match: regex
+					  normalize_streets: true
+					  fields:
+					    street: 'Strähleweg \d+'
+					`)
+
+				result, err := dp.IsBlocked(personJson, config.Instance{}, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).NotTo(BeNil())
+			})
+
+			It("does not normalize street abbreviations by default", func() {
+				writeBlocklist(`
+					---
+					- normalize_whitespace: true
+					  fields:
+					    street: "Hauptstr. 1"
+					`)
+
+				result, err := dp.IsBlocked(personJson, config.Instance{}, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(BeNil())
+			})
+
+			It("matches glob patterns", func() {
+				writeBlocklist(`
+					---
+					- match: glob
+					  fields:
+					    street: "Haupt*"
+					    zip: "123??"
+					`)
+
+				result, err := dp.IsBlocked(personJson, config.Instance{}, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).NotTo(BeNil())
+			})
+
+			It("matches regular expressions against the whole value", func() {
+				writeBlocklist(`
+					---
+					- match: regex
+					  fields:
+					    zip: "123"
+					- match: regex
+					  ignore_case: true
+					  fields:
+					    city: "gl.*stadt"
+					    age: "[0-9]+"
+					`)
+
+				result, err := dp.IsBlocked(personJson, config.Instance{}, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).NotTo(BeNil())
+			})
+
+			It("keeps the AND semantics of the fields", func() {
+				writeBlocklist(`
+					---
+					- match: glob
+					  fields:
+					    street: "Haupt*"
+					    city: "Berlin"
+					`)
+
+				result, err := dp.IsBlocked(personJson, config.Instance{}, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(BeNil())
+			})
+
+			It("logs entries with fields that do not exist in the person data", func() {
+				writeBlocklist(`
+					---
+					- match: glob
+					  fields:
+					    unknown: "*"
+					`)
+
+				result, err := dp.IsBlocked(personJson, config.Instance{}, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(BeNil())
+				Expect(logger.WarnArgsForCall(0)).To(Equal("      Ignoring blocklist element {unknown: \"*\"} since field 'unknown' is not available in the person data"))
+			})
+
+			It("returns an error for unknown match modes", func() {
+				writeBlocklist(`
+					---
+					- match: fuzzy
+					  fields:
+					    city: Glücksstadt
+					`)
+
+				_, err := dp.IsBlocked(personJson, config.Instance{}, group)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("line 2: unknown match mode 'fuzzy'"))
+			})
+
+			It("returns an error for unknown options", func() {
+				writeBlocklist(`
+					---
+					- ignore_whitespace: true
+					  fields:
+					    city: Glücksstadt
+					`)
+
+				_, err := dp.IsBlocked(personJson, config.Instance{}, group)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("line 2: unknown option 'ignore_whitespace'"))
+			})
+
+			It("returns an error for invalid regular expressions", func() {
+				writeBlocklist(`
+					---
+					- match: regex
+					  fields:
+					    city: "(Gl"
+					`)
+
+				_, err := dp.IsBlocked(personJson, config.Instance{}, group)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("line 4: invalid value of field 'city'"))
+			})
+
+			It("returns an error for duplicate keys", func() {
+				writeBlocklist(`
+					---
+					- city: Glücksstadt
+					  city: Musterstadt
+					`)
+
+				_, err := dp.IsBlocked(personJson, config.Instance{}, group)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("line 3: duplicate key 'city'"))
+			})
+		})
+
+		var _ = Describe("inherited blocklists", func() {
+			var instance config.Instance
+
+			writeBlocklist := func(name string, content string) {
+				path := filepath.Join(tempDataDir, name)
+				Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+				err := os.WriteFile(path, []byte(testutil.YamlToByteArray(content)), 0644)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			BeforeEach(func() {
+				instance = config.Instance{Hostname: "foo.church.tools"}
+			})
+
+			It("blocks persons of the global blocklist", func() {
+				writeBlocklist("_all.yml", `
+					---
+					- zip: "99999"
+					- city: "Anytown"
+					  zip: "12345"
+					`)
+
+				result, err := dp.IsBlocked(personJson, instance, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(*result).To(Equal(data_provider.BlockMatch{Layer: "global", File: "_all.yml", Entry: 2, Fields: []string{"city", "zip"}}))
+			})
+
+			It("blocks persons of the instance blocklist", func() {
+				writeBlocklist("foo.church.tools/_all.yml", `
+					---
+					- city: "Anytown"
+					`)
+
+				result, err := dp.IsBlocked(personJson, instance, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.Layer).To(Equal("instance"))
+				Expect(result.File).To(Equal(filepath.Join("foo.church.tools", "_all.yml")))
+			})
+
+			It("does not use blocklists of other instances", func() {
+				writeBlocklist("bar.church.tools/_all.yml", `
+					---
+					- city: "Anytown"
+					`)
+
+				result, err := dp.IsBlocked(personJson, instance, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(BeNil())
+			})
+
+			It("blocks persons of the instance specific group blocklist", func() {
+				writeBlocklist("foo.church.tools/mappedField.yml", `
+					---
+					- city: "Anytown"
+					`)
+
+				result, err := dp.IsBlocked(personJson, instance, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.Layer).To(Equal("group"))
+			})
+
+			It("ignores inherited blocklists if the group opts out", func() {
+				writeBlocklist("_all.yml", `
+					---
+					- city: "Anytown"
+					`)
+				writeBlocklist("foo.church.tools/_all.yml", `
+					---
+					- city: "Anytown"
+					`)
+				inherit := false
+				group.InheritBlocklists = &inherit
+
+				result, err := dp.IsBlocked(personJson, instance, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(BeNil())
+				Expect(dp.BlockListFiles(instance, group)).To(BeEmpty())
+			})
+
+			It("uses the group blocklist of earlier versions with a warning", func() {
+				writeBlocklist("foo.church.tools/Groe_Jugend.yml", `
+					---
+					- city: "Anytown"
+					`)
+				group = config.Group{Name: "Große Jugend"}
+
+				result, err := dp.IsBlocked(personJson, instance, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.File).To(Equal(filepath.Join("foo.church.tools", "Groe_Jugend.yml")))
+				Expect(logger.WarnCallCount()).To(Equal(1))
+				Expect(logger.WarnArgsForCall(0)).To(ContainSubstring("rename it to '" + filepath.Join("foo.church.tools", "Grosse_Jugend.yml") + "'"))
+
+				_, err = dp.IsBlocked(personJson, instance, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(logger.WarnCallCount()).To(Equal(1))
+			})
+
+			It("prefers the group blocklist with the current name", func() {
+				writeBlocklist("Groe_Jugend.yml", `
+					---
+					- city: "Anytown"
+					`)
+				writeBlocklist("Grosse_Jugend.yml", `[]`)
+
+				result, err := dp.IsBlocked(personJson, instance, config.Group{Name: "Große Jugend"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(BeNil())
+				Expect(logger.WarnCallCount()).To(Equal(0))
+			})
+
+			It("returns the existing blocklists of a group", func() {
+				writeBlocklist("_all.yml", `[]`)
+				writeBlocklist("foo.church.tools/_all.yml", `[]`)
+				writeBlocklist("mappedField.yml", `[]`)
+
+				Expect(dp.BlockListFiles(instance, group)).To(Equal([]string{
+					"_all.yml",
+					filepath.Join("foo.church.tools", "_all.yml"),
+					"mappedField.yml",
+				}))
+			})
+		})
+
+		var _ = Describe("person and group entries", func() {
+			var (
+				instance       config.Instance
+				groupsEndpoint *restfakes.FakeGroupsEndpoint
+			)
+
+			writeBlocklist := func(content string) {
+				yamlContent := testutil.YamlToByteArray(content)
+				err := os.WriteFile(filepath.Join(tempDataDir, "mappedField.yml"), []byte(yamlContent), 0644)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			BeforeEach(func() {
+				personJson["id"] = json.RawMessage(`42`)
+				instance = config.Instance{Hostname: "foo.church.tools"}
+				groupsEndpoint = &restfakes.FakeGroupsEndpoint{}
+				groupsEndpoint.GetGroupReturns(rest.GroupsResponse{ID: 7, Name: "Keine Post"}, nil)
+				groupsEndpoint.GetGroupMembersReturns([]rest.GroupsMembersResponse{{PersonId: 41}, {PersonId: 42}}, nil)
+			})
+
+			It("blocks persons by ID", func() {
+				writeBlocklist(`
+					---
+					- person_ids: [1, 42]
+					`)
+
+				result, err := dp.IsBlocked(personJson, instance, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.Fields).To(Equal([]string{"id"}))
+			})
+
+			It("blocks persons by a single ID", func() {
+				writeBlocklist(`
+					---
+					- person_ids: 43
+					- person_ids: 42
+					`)
+
+				result, err := dp.IsBlocked(personJson, instance, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.Entry).To(Equal(2))
+			})
+
+			It("blocks members of a ChurchTools group", func() {
+				writeBlocklist(`
+					---
+					- group: Keine Post
+					`)
+				dp.SetGroupsEndpoint(instance, groupsEndpoint)
+
+				result, err := dp.IsBlocked(personJson, instance, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.Fields).To(Equal([]string{"group"}))
+
+				Expect(groupsEndpoint.GetGroupArgsForCall(0)).To(Equal("Keine Post"))
+				Expect(groupsEndpoint.GetGroupMembersArgsForCall(0)).To(Equal(7))
+			})
+
+			It("requests the members of a group once per instance", func() {
+				writeBlocklist(`
+					---
+					- group: Keine Post
+					`)
+				dp.SetGroupsEndpoint(instance, groupsEndpoint)
+
+				personJson["id"] = json.RawMessage(`43`)
+				result, err := dp.IsBlocked(personJson, instance, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(BeNil())
+
+				personJson["id"] = json.RawMessage(`41`)
+				result, err = dp.IsBlocked(personJson, instance, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).NotTo(BeNil())
+
+				Expect(groupsEndpoint.GetGroupMembersCallCount()).To(Equal(1))
+			})
+
+			It("returns an error if the group members cannot be resolved", func() {
+				writeBlocklist(`
+					---
+					- group: Keine Post
+					`)
+
+				_, err := dp.IsBlocked(personJson, instance, group)
+				Expect(err).To(MatchError("the members of group 'Keine Post' cannot be resolved for instance 'foo.church.tools'"))
+			})
+
+			It("returns an error if the group cannot be found", func() {
+				writeBlocklist(`
+					---
+					- group: Keine Post
+					`)
+				groupsEndpoint.GetGroupReturns(rest.GroupsResponse{}, errors.New("not found"))
+				dp.SetGroupsEndpoint(instance, groupsEndpoint)
+
+				_, err := dp.IsBlocked(personJson, instance, group)
+				Expect(err).To(MatchError("failed to get blocked group 'Keine Post', not found"))
+			})
+
+			It("returns the error of a failed member lookup for every person", func() {
+				writeBlocklist(`
+					---
+					- group: Keine Post
+					`)
+				groupsEndpoint.GetGroupReturns(rest.GroupsResponse{ID: 7, Name: "Keine Post"}, nil)
+				groupsEndpoint.GetGroupMembersReturns(nil, errors.New("timeout"))
+				dp.SetGroupsEndpoint(instance, groupsEndpoint)
+
+				for range 2 {
+					result, err := dp.IsBlocked(personJson, instance, group)
+					Expect(err).To(MatchError("failed to get members of blocked group 'Keine Post', timeout"))
+					Expect(result).To(BeNil())
+				}
+				Expect(groupsEndpoint.GetGroupMembersCallCount()).To(Equal(1))
+			})
+
+			It("returns an error for invalid person IDs", func() {
+				writeBlocklist(`
+					---
+					- person_ids: [foo]
+					`)
+
+				_, err := dp.IsBlocked(personJson, instance, group)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("line 2: 'person_ids' must be a list of person IDs"))
+			})
+		})
+
+	var _ = Describe("UnmatchedEntries", func() {
+		It("returns the entries of checked blocklists that did not block any person", func() {
+			yamlContent := testutil.YamlToByteArray(`
+				---
+				- zip: "99999"
+				- city: "Anytown"
+				- person_ids: [1]
+				`)
+			err = os.WriteFile(filepath.Join(tempDataDir, "mappedField.yml"), []byte(yamlContent), 0644)
+			Expect(err).ToNot(HaveOccurred())
+
+			result, err := dp.IsBlocked(personJson, config.Instance{}, group)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).NotTo(BeNil())
+
+			Expect(dp.UnmatchedEntries()).To(Equal([]data_provider.UnmatchedEntry{
+				{File: "mappedField.yml", Entry: 1, Line: 2, Definition: `{zip: "99999"}`},
+				{File: "mappedField.yml", Entry: 3, Line: 4, Definition: "{person_ids: [1]}"},
+			}))
+		})
+
+		It("does not return entries shadowed by an earlier entry or layer", func() {
+			err = os.WriteFile(filepath.Join(tempDataDir, "_all.yml"), testutil.YamlToByteArray(`
+				---
+				- city: "Anytown"
+				`), 0644)
+			Expect(err).ToNot(HaveOccurred())
+			err = os.WriteFile(filepath.Join(tempDataDir, "mappedField.yml"), testutil.YamlToByteArray(`
+				---
+				- zip: "12345"
+				- city: "Anytown"
+				`), 0644)
+			Expect(err).ToNot(HaveOccurred())
+
+			result, err := dp.IsBlocked(personJson, config.Instance{}, group)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*result).To(Equal(data_provider.BlockMatch{Layer: "global", File: "_all.yml", Entry: 1, Fields: []string{"city"}}))
+
+			Expect(dp.UnmatchedEntries()).To(BeEmpty())
+		})
+
+		It("returns no entries if no blocklist was checked", func() {
+			Expect(dp.UnmatchedEntries()).To(BeEmpty())
+		})
+	})
 	})
 })
@@ -0,0 +1,327 @@
+package data_provider
+
+import (
+	"bytes"
+	"ctRestClient/jsonpath"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Match modes of blocklist entries.
+const (
+	MatchExact = "exact"
+	MatchGlob  = "glob"
+	MatchRegex = "regex"
+)
+
+// matchOptions configure how the values of a blocklist entry are compared
+// with the person data. The normalizations are applied to both values before
+// they are compared, regular expressions are only applied to the person data.
+type matchOptions struct {
+	Match               string `yaml:"match"`
+	IgnoreCase          bool   `yaml:"ignore_case"`
+	NormalizeWhitespace bool   `yaml:"normalize_whitespace"`
+	NormalizeStreets    bool   `yaml:"normalize_streets"`
+}
+
+func (o matchOptions) isExact() bool {
+	return o.Match == MatchExact && !o.IgnoreCase && !o.NormalizeWhitespace && !o.NormalizeStreets
+}
+
+// normalize applies the configured normalizations to a value.
+func (o matchOptions) normalize(value string) string {
+	if o.NormalizeStreets {
+		value = normalizeStreet(value)
+	}
+	if o.NormalizeWhitespace {
+		value = strings.Join(strings.Fields(value), " ")
+	}
+	if o.IgnoreCase {
+		value = strings.ToLower(value)
+	}
+	return value
+}
+
+type fieldMatcher struct {
+	fieldName string
+	value     json.RawMessage
+	text      string
+	pattern   *regexp.Regexp
+}
+
+// A blocklistEntry blocks a person if all of its fields match the person
+// data. An entry is either a map of field names and values that are compared
+// exactly, or a map with match options and the compared 'fields':
+//
+//	- street: Hauptstraße 1
+//	  city: Glücksstadt
+//	- match: glob
+//	  ignore_case: true
+//	  fields:
+//	    street: Haupt*
+//
+// Persons can also be blocked by their ID or by their membership in a
+// ChurchTools group:
+//
+//	- person_ids: [12, 34]
+//	- group: Keine Post
+type blocklistEntry struct {
+	line    int
+	options matchOptions
+	fields  []fieldMatcher
+
+	personIds []int
+	groupName string
+}
+
+func parseBlocklistEntry(node *yaml.Node) (blocklistEntry, error) {
+	entry := blocklistEntry{line: node.Line, options: matchOptions{Match: MatchExact}}
+
+	if node.Kind != yaml.MappingNode {
+		return entry, fmt.Errorf("line %d: blocklist entry must be a map of fields", node.Line)
+	}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		for j := 0; j < i; j += 2 {
+			if node.Content[i].Value == node.Content[j].Value {
+				return entry, fmt.Errorf("line %d: duplicate key '%s'", node.Content[i].Line, node.Content[i].Value)
+			}
+		}
+	}
+
+	if len(node.Content) == 2 {
+		keyNode, valueNode := node.Content[0], node.Content[1]
+		switch keyNode.Value {
+		case "person_ids":
+			if valueNode.Kind == yaml.ScalarNode {
+				valueNode = &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{valueNode}}
+			}
+			if err := valueNode.Decode(&entry.personIds); err != nil || len(entry.personIds) == 0 {
+				return entry, fmt.Errorf("line %d: 'person_ids' must be a list of person IDs", valueNode.Line)
+			}
+			return entry, nil
+		case "group":
+			if valueNode.Kind != yaml.ScalarNode || valueNode.Value == "" {
+				return entry, fmt.Errorf("line %d: 'group' must be the name of a ChurchTools group", valueNode.Line)
+			}
+			entry.groupName = valueNode.Value
+			return entry, nil
+		}
+	}
+
+	fieldsNode := node
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value == "fields" && node.Content[i+1].Kind == yaml.MappingNode {
+			fieldsNode = node.Content[i+1]
+		}
+	}
+
+	if fieldsNode != node {
+		for i := 0; i < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			if keyNode.Value == "fields" {
+				continue
+			}
+			switch keyNode.Value {
+			case "match", "ignore_case", "normalize_whitespace", "normalize_streets":
+			default:
+				return entry, fmt.Errorf("line %d: unknown option '%s'", keyNode.Line, keyNode.Value)
+			}
+			option := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{keyNode, valueNode}}
+			if err := option.Decode(&entry.options); err != nil {
+				return entry, fmt.Errorf("line %d: invalid option '%s', %w", keyNode.Line, keyNode.Value, err)
+			}
+		}
+		switch entry.options.Match {
+		case MatchExact, MatchGlob, MatchRegex:
+		default:
+			return entry, fmt.Errorf("line %d: unknown match mode '%s'", node.Line, entry.options.Match)
+		}
+	}
+
+	if len(fieldsNode.Content) == 0 {
+		return entry, fmt.Errorf("line %d: blocklist entry has no fields", node.Line)
+	}
+
+	for i := 0; i < len(fieldsNode.Content); i += 2 {
+		keyNode, valueNode := fieldsNode.Content[i], fieldsNode.Content[i+1]
+		for j := 0; j < i; j += 2 {
+			if fieldsNode.Content[j].Value == keyNode.Value {
+				return entry, fmt.Errorf("line %d: duplicate key '%s'", keyNode.Line, keyNode.Value)
+			}
+		}
+
+		matcher, err := entry.options.newFieldMatcher(keyNode.Value, valueNode)
+		if err != nil {
+			return entry, fmt.Errorf("line %d: invalid value of field '%s', %w", valueNode.Line, keyNode.Value, err)
+		}
+		entry.fields = append(entry.fields, matcher)
+	}
+
+	return entry, nil
+}
+
+func (o matchOptions) newFieldMatcher(fieldName string, node *yaml.Node) (fieldMatcher, error) {
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return fieldMatcher{}, err
+	}
+	jsonBytes, err := json.Marshal(value)
+	if err != nil {
+		return fieldMatcher{}, err
+	}
+
+	matcher := fieldMatcher{fieldName: fieldName, value: jsonBytes}
+	if node.Kind == yaml.ScalarNode && node.Tag != "!!null" {
+		matcher.text = node.Value
+	}
+
+	switch o.Match {
+	case MatchRegex:
+		expression := matcher.text
+		if o.IgnoreCase {
+			expression = "(?i)" + expression
+		}
+		matcher.pattern, err = regexp.Compile("^(?:" + expression + ")$")
+	case MatchGlob:
+		matcher.pattern, err = regexp.Compile("^" + globToRegex(o.normalize(matcher.text)) + "$")
+	default:
+		matcher.text = o.normalize(matcher.text)
+	}
+
+	return matcher, err
+}
+
+// globToRegex converts a glob pattern with the wildcards * and ? into a
+// regular expression.
+func globToRegex(glob string) string {
+	var expression strings.Builder
+	for _, r := range glob {
+		switch r {
+		case '*':
+			expression.WriteString(".*")
+		case '?':
+			expression.WriteString(".")
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return expression.String()
+}
+
+func (f fieldMatcher) matches(personValue json.RawMessage, options matchOptions) (bool, error) {
+	if options.isExact() {
+		// Unescape the json data that contains (\u00df and \u00fc instead of ß and ü) for later string comparison with data from the blocklist.
+		unescapedValue, err := unescapeUnicodeCharacters(personValue)
+		if err != nil {
+			return false, fmt.Errorf("failed to unescape unicode characters for field %s: %w", f.fieldName, err)
+		}
+		return bytes.Equal(unescapedValue, f.value), nil
+	}
+
+	text := jsonValueToText(personValue)
+
+	if f.pattern != nil {
+		if options.Match == MatchRegex {
+			options.IgnoreCase = false
+		}
+		return f.pattern.MatchString(options.normalize(text)), nil
+	}
+
+	return options.normalize(text) == f.text, nil
+}
+
+// matches returns true if all fields of the entry match the person data.
+// The name of a field that does not exist in the person data is returned.
+// The IDs of the members of the group of a group entry must be given.
+func (e blocklistEntry) matches(personJson map[string]json.RawMessage, groupMemberIds []int) (bool, string, error) {
+	if e.personIds != nil || e.groupName != "" {
+		var personId int
+		if err := json.Unmarshal(personJson["id"], &personId); err != nil {
+			return false, "id", nil
+		}
+		return slices.Contains(e.personIds, personId) || slices.Contains(groupMemberIds, personId), "", nil
+	}
+
+	for _, field := range e.fields {
+		personValue, exists := jsonpath.Lookup(personJson, field.fieldName)
+		if !exists {
+			return false, field.fieldName, nil
+		}
+
+		matched, err := field.matches(personValue, e.options)
+		if err != nil || !matched {
+			return false, "", err
+		}
+	}
+	return true, "", nil
+}
+
+func (e blocklistEntry) fieldNames() []string {
+	if e.groupName != "" {
+		return []string{"group"}
+	}
+	if e.personIds != nil {
+		return []string{"id"}
+	}
+	names := make([]string, 0, len(e.fields))
+	for _, field := range e.fields {
+		names = append(names, field.fieldName)
+	}
+	return names
+}
+
+func (e blocklistEntry) String() string {
+	if e.groupName != "" {
+		return fmt.Sprintf("{group: %s}", e.groupName)
+	}
+	if e.personIds != nil {
+		return fmt.Sprintf("{person_ids: %v}", e.personIds)
+	}
+	parts := make([]string, 0, len(e.fields))
+	for _, field := range e.fields {
+		parts = append(parts, fmt.Sprintf("%s: %s", field.fieldName, field.value))
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func jsonValueToText(value json.RawMessage) string {
+	var parsedValue interface{}
+	if err := json.Unmarshal(value, &parsedValue); err != nil {
+		return string(value)
+	}
+	switch v := parsedValue.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		return string(bytes.TrimSpace(value))
+	}
+}
+
+var streetAbbreviations = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?i)(s)trasse`), "${1}traße"},
+	{regexp.MustCompile(`(?i)(s)tr(?:\.|($|[^\pL.]))`), "${1}traße${2}"},
+	{regexp.MustCompile(`(?i)(p)l\.`), "${1}latz"},
+}
+
+// normalizeStreet replaces common German street abbreviations, e.g.
+// 'Hauptstr. 1' and 'Hauptstrasse 1' become 'Hauptstraße 1'. A missing
+// space before the house number is inserted.
+func normalizeStreet(street string) string {
+	for _, abbreviation := range streetAbbreviations {
+		street = abbreviation.pattern.ReplaceAllString(street, abbreviation.replacement)
+	}
+	return houseNumberPattern.ReplaceAllString(street, "$1 $2")
+}
+
+var houseNumberPattern = regexp.MustCompile(`(\pL)(\d)`)
@@ -0,0 +1,13 @@
+package data_provider_test
+
+import (
+    "testing"
+
+    . "github.com/onsi/ginkgo/v2"
+    . "github.com/onsi/gomega"
+)
+
+func TestClient(t *testing.T) {
+    RegisterFailHandler(Fail)
+    RunSpecs(t, "Data Provider Suite")
+}
\ No newline at end of file
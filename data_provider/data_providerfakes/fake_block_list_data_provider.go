@@ -4,52 +4,72 @@ package data_providerfakes
 import (
 	"ctRestClient/config"
 	"ctRestClient/data_provider"
+	"ctRestClient/rest"
 	"encoding/json"
 	"sync"
 )
 
 type FakeBlockListDataProvider struct {
-	BlockListExistsStub        func(config.Group) bool
-	blockListExistsMutex       sync.RWMutex
-	blockListExistsArgsForCall []struct {
-		arg1 config.Group
+	BlockListFilesStub        func(config.Instance, config.Group) []string
+	blockListFilesMutex       sync.RWMutex
+	blockListFilesArgsForCall []struct {
+		arg1 config.Instance
+		arg2 config.Group
 	}
-	blockListExistsReturns struct {
-		result1 bool
+	blockListFilesReturns struct {
+		result1 []string
 	}
-	blockListExistsReturnsOnCall map[int]struct {
-		result1 bool
+	blockListFilesReturnsOnCall map[int]struct {
+		result1 []string
 	}
-	IsBlockedStub        func(map[string]json.RawMessage, config.Group) (bool, error)
+	IsBlockedStub        func(map[string]json.RawMessage, config.Instance, config.Group) (*data_provider.BlockMatch, error)
 	isBlockedMutex       sync.RWMutex
 	isBlockedArgsForCall []struct {
 		arg1 map[string]json.RawMessage
-		arg2 config.Group
+		arg2 config.Instance
+		arg3 config.Group
 	}
 	isBlockedReturns struct {
-		result1 bool
+		result1 *data_provider.BlockMatch
 		result2 error
 	}
 	isBlockedReturnsOnCall map[int]struct {
-		result1 bool
+		result1 *data_provider.BlockMatch
 		result2 error
 	}
+	SetGroupsEndpointStub        func(config.Instance, rest.GroupsEndpoint)
+	setGroupsEndpointMutex       sync.RWMutex
+	setGroupsEndpointArgsForCall []struct {
+		arg1 config.Instance
+		arg2 rest.GroupsEndpoint
+	}
+	UnmatchedEntriesStub        func() []data_provider.UnmatchedEntry
+	unmatchedEntriesMutex       sync.RWMutex
+	unmatchedEntriesArgsForCall []struct {
+	}
+	unmatchedEntriesReturns struct {
+		result1 []data_provider.UnmatchedEntry
+	}
+	unmatchedEntriesReturnsOnCall map[int]struct {
+		result1 []data_provider.UnmatchedEntry
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeBlockListDataProvider) BlockListExists(arg1 config.Group) bool {
-	fake.blockListExistsMutex.Lock()
-	ret, specificReturn := fake.blockListExistsReturnsOnCall[len(fake.blockListExistsArgsForCall)]
-	fake.blockListExistsArgsForCall = append(fake.blockListExistsArgsForCall, struct {
-		arg1 config.Group
-	}{arg1})
-	stub := fake.BlockListExistsStub
-	fakeReturns := fake.blockListExistsReturns
-	fake.recordInvocation("BlockListExists", []interface{}{arg1})
-	fake.blockListExistsMutex.Unlock()
+func (fake *FakeBlockListDataProvider) BlockListFiles(arg1 config.Instance, arg2 config.Group) []string {
+	fake.blockListFilesMutex.Lock()
+	ret, specificReturn := fake.blockListFilesReturnsOnCall[len(fake.blockListFilesArgsForCall)]
+	fake.blockListFilesArgsForCall = append(fake.blockListFilesArgsForCall, struct {
+		arg1 config.Instance
+		arg2 config.Group
+	}{arg1, arg2})
+	stub := fake.BlockListFilesStub
+	fakeReturns := fake.blockListFilesReturns
+	fake.recordInvocation("BlockListFiles", []interface{}{arg1, arg2})
+	fake.blockListFilesMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
@@ -57,61 +77,62 @@ func (fake *FakeBlockListDataProvider) BlockListExists(arg1 config.Group) bool {
 	return fakeReturns.result1
 }
 
-func (fake *FakeBlockListDataProvider) BlockListExistsCallCount() int {
-	fake.blockListExistsMutex.RLock()
-	defer fake.blockListExistsMutex.RUnlock()
-	return len(fake.blockListExistsArgsForCall)
+func (fake *FakeBlockListDataProvider) BlockListFilesCallCount() int {
+	fake.blockListFilesMutex.RLock()
+	defer fake.blockListFilesMutex.RUnlock()
+	return len(fake.blockListFilesArgsForCall)
 }
 
-func (fake *FakeBlockListDataProvider) BlockListExistsCalls(stub func(config.Group) bool) {
-	fake.blockListExistsMutex.Lock()
-	defer fake.blockListExistsMutex.Unlock()
-	fake.BlockListExistsStub = stub
+func (fake *FakeBlockListDataProvider) BlockListFilesCalls(stub func(config.Instance, config.Group) []string) {
+	fake.blockListFilesMutex.Lock()
+	defer fake.blockListFilesMutex.Unlock()
+	fake.BlockListFilesStub = stub
 }
 
-func (fake *FakeBlockListDataProvider) BlockListExistsArgsForCall(i int) config.Group {
-	fake.blockListExistsMutex.RLock()
-	defer fake.blockListExistsMutex.RUnlock()
-	argsForCall := fake.blockListExistsArgsForCall[i]
-	return argsForCall.arg1
+func (fake *FakeBlockListDataProvider) BlockListFilesArgsForCall(i int) (config.Instance, config.Group) {
+	fake.blockListFilesMutex.RLock()
+	defer fake.blockListFilesMutex.RUnlock()
+	argsForCall := fake.blockListFilesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
 }
 
-func (fake *FakeBlockListDataProvider) BlockListExistsReturns(result1 bool) {
-	fake.blockListExistsMutex.Lock()
-	defer fake.blockListExistsMutex.Unlock()
-	fake.BlockListExistsStub = nil
-	fake.blockListExistsReturns = struct {
-		result1 bool
+func (fake *FakeBlockListDataProvider) BlockListFilesReturns(result1 []string) {
+	fake.blockListFilesMutex.Lock()
+	defer fake.blockListFilesMutex.Unlock()
+	fake.BlockListFilesStub = nil
+	fake.blockListFilesReturns = struct {
+		result1 []string
 	}{result1}
 }
 
-func (fake *FakeBlockListDataProvider) BlockListExistsReturnsOnCall(i int, result1 bool) {
-	fake.blockListExistsMutex.Lock()
-	defer fake.blockListExistsMutex.Unlock()
-	fake.BlockListExistsStub = nil
-	if fake.blockListExistsReturnsOnCall == nil {
-		fake.blockListExistsReturnsOnCall = make(map[int]struct {
-			result1 bool
+func (fake *FakeBlockListDataProvider) BlockListFilesReturnsOnCall(i int, result1 []string) {
+	fake.blockListFilesMutex.Lock()
+	defer fake.blockListFilesMutex.Unlock()
+	fake.BlockListFilesStub = nil
+	if fake.blockListFilesReturnsOnCall == nil {
+		fake.blockListFilesReturnsOnCall = make(map[int]struct {
+			result1 []string
 		})
 	}
-	fake.blockListExistsReturnsOnCall[i] = struct {
-		result1 bool
+	fake.blockListFilesReturnsOnCall[i] = struct {
+		result1 []string
 	}{result1}
 }
 
-func (fake *FakeBlockListDataProvider) IsBlocked(arg1 map[string]json.RawMessage, arg2 config.Group) (bool, error) {
+func (fake *FakeBlockListDataProvider) IsBlocked(arg1 map[string]json.RawMessage, arg2 config.Instance, arg3 config.Group) (*data_provider.BlockMatch, error) {
 	fake.isBlockedMutex.Lock()
 	ret, specificReturn := fake.isBlockedReturnsOnCall[len(fake.isBlockedArgsForCall)]
 	fake.isBlockedArgsForCall = append(fake.isBlockedArgsForCall, struct {
 		arg1 map[string]json.RawMessage
-		arg2 config.Group
-	}{arg1, arg2})
+		arg2 config.Instance
+		arg3 config.Group
+	}{arg1, arg2, arg3})
 	stub := fake.IsBlockedStub
 	fakeReturns := fake.isBlockedReturns
-	fake.recordInvocation("IsBlocked", []interface{}{arg1, arg2})
+	fake.recordInvocation("IsBlocked", []interface{}{arg1, arg2, arg3})
 	fake.isBlockedMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -125,52 +146,142 @@ func (fake *FakeBlockListDataProvider) IsBlockedCallCount() int {
 	return len(fake.isBlockedArgsForCall)
 }
 
-func (fake *FakeBlockListDataProvider) IsBlockedCalls(stub func(map[string]json.RawMessage, config.Group) (bool, error)) {
+func (fake *FakeBlockListDataProvider) IsBlockedCalls(stub func(map[string]json.RawMessage, config.Instance, config.Group) (*data_provider.BlockMatch, error)) {
 	fake.isBlockedMutex.Lock()
 	defer fake.isBlockedMutex.Unlock()
 	fake.IsBlockedStub = stub
 }
 
-func (fake *FakeBlockListDataProvider) IsBlockedArgsForCall(i int) (map[string]json.RawMessage, config.Group) {
+func (fake *FakeBlockListDataProvider) IsBlockedArgsForCall(i int) (map[string]json.RawMessage, config.Instance, config.Group) {
 	fake.isBlockedMutex.RLock()
 	defer fake.isBlockedMutex.RUnlock()
 	argsForCall := fake.isBlockedArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
-func (fake *FakeBlockListDataProvider) IsBlockedReturns(result1 bool, result2 error) {
+func (fake *FakeBlockListDataProvider) IsBlockedReturns(result1 *data_provider.BlockMatch, result2 error) {
 	fake.isBlockedMutex.Lock()
 	defer fake.isBlockedMutex.Unlock()
 	fake.IsBlockedStub = nil
 	fake.isBlockedReturns = struct {
-		result1 bool
+		result1 *data_provider.BlockMatch
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeBlockListDataProvider) IsBlockedReturnsOnCall(i int, result1 bool, result2 error) {
+func (fake *FakeBlockListDataProvider) IsBlockedReturnsOnCall(i int, result1 *data_provider.BlockMatch, result2 error) {
 	fake.isBlockedMutex.Lock()
 	defer fake.isBlockedMutex.Unlock()
 	fake.IsBlockedStub = nil
 	if fake.isBlockedReturnsOnCall == nil {
 		fake.isBlockedReturnsOnCall = make(map[int]struct {
-			result1 bool
+			result1 *data_provider.BlockMatch
 			result2 error
 		})
 	}
 	fake.isBlockedReturnsOnCall[i] = struct {
-		result1 bool
+		result1 *data_provider.BlockMatch
 		result2 error
 	}{result1, result2}
 }
 
+func (fake *FakeBlockListDataProvider) SetGroupsEndpoint(arg1 config.Instance, arg2 rest.GroupsEndpoint) {
+	fake.setGroupsEndpointMutex.Lock()
+	fake.setGroupsEndpointArgsForCall = append(fake.setGroupsEndpointArgsForCall, struct {
+		arg1 config.Instance
+		arg2 rest.GroupsEndpoint
+	}{arg1, arg2})
+	stub := fake.SetGroupsEndpointStub
+	fake.recordInvocation("SetGroupsEndpoint", []interface{}{arg1, arg2})
+	fake.setGroupsEndpointMutex.Unlock()
+	if stub != nil {
+		fake.SetGroupsEndpointStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeBlockListDataProvider) SetGroupsEndpointCallCount() int {
+	fake.setGroupsEndpointMutex.RLock()
+	defer fake.setGroupsEndpointMutex.RUnlock()
+	return len(fake.setGroupsEndpointArgsForCall)
+}
+
+func (fake *FakeBlockListDataProvider) SetGroupsEndpointCalls(stub func(config.Instance, rest.GroupsEndpoint)) {
+	fake.setGroupsEndpointMutex.Lock()
+	defer fake.setGroupsEndpointMutex.Unlock()
+	fake.SetGroupsEndpointStub = stub
+}
+
+func (fake *FakeBlockListDataProvider) SetGroupsEndpointArgsForCall(i int) (config.Instance, rest.GroupsEndpoint) {
+	fake.setGroupsEndpointMutex.RLock()
+	defer fake.setGroupsEndpointMutex.RUnlock()
+	argsForCall := fake.setGroupsEndpointArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeBlockListDataProvider) UnmatchedEntries() []data_provider.UnmatchedEntry {
+	fake.unmatchedEntriesMutex.Lock()
+	ret, specificReturn := fake.unmatchedEntriesReturnsOnCall[len(fake.unmatchedEntriesArgsForCall)]
+	fake.unmatchedEntriesArgsForCall = append(fake.unmatchedEntriesArgsForCall, struct {
+	}{})
+	stub := fake.UnmatchedEntriesStub
+	fakeReturns := fake.unmatchedEntriesReturns
+	fake.recordInvocation("UnmatchedEntries", []interface{}{})
+	fake.unmatchedEntriesMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeBlockListDataProvider) UnmatchedEntriesCallCount() int {
+	fake.unmatchedEntriesMutex.RLock()
+	defer fake.unmatchedEntriesMutex.RUnlock()
+	return len(fake.unmatchedEntriesArgsForCall)
+}
+
+func (fake *FakeBlockListDataProvider) UnmatchedEntriesCalls(stub func() []data_provider.UnmatchedEntry) {
+	fake.unmatchedEntriesMutex.Lock()
+	defer fake.unmatchedEntriesMutex.Unlock()
+	fake.UnmatchedEntriesStub = stub
+}
+
+func (fake *FakeBlockListDataProvider) UnmatchedEntriesReturns(result1 []data_provider.UnmatchedEntry) {
+	fake.unmatchedEntriesMutex.Lock()
+	defer fake.unmatchedEntriesMutex.Unlock()
+	fake.UnmatchedEntriesStub = nil
+	fake.unmatchedEntriesReturns = struct {
+		result1 []data_provider.UnmatchedEntry
+	}{result1}
+}
+
+func (fake *FakeBlockListDataProvider) UnmatchedEntriesReturnsOnCall(i int, result1 []data_provider.UnmatchedEntry) {
+	fake.unmatchedEntriesMutex.Lock()
+	defer fake.unmatchedEntriesMutex.Unlock()
+	fake.UnmatchedEntriesStub = nil
+	if fake.unmatchedEntriesReturnsOnCall == nil {
+		fake.unmatchedEntriesReturnsOnCall = make(map[int]struct {
+			result1 []data_provider.UnmatchedEntry
+		})
+	}
+	fake.unmatchedEntriesReturnsOnCall[i] = struct {
+		result1 []data_provider.UnmatchedEntry
+	}{result1}
+}
+
 func (fake *FakeBlockListDataProvider) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.blockListExistsMutex.RLock()
-	defer fake.blockListExistsMutex.RUnlock()
+	fake.blockListFilesMutex.RLock()
+	defer fake.blockListFilesMutex.RUnlock()
 	fake.isBlockedMutex.RLock()
 	defer fake.isBlockedMutex.RUnlock()
+	fake.setGroupsEndpointMutex.RLock()
+	defer fake.setGroupsEndpointMutex.RUnlock()
+	fake.unmatchedEntriesMutex.RLock()
+	defer fake.unmatchedEntriesMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
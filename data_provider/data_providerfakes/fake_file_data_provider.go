@@ -2,17 +2,20 @@
 package data_providerfakes
 
 import (
+	"ctRestClient/config"
 	"ctRestClient/data_provider"
 	"encoding/json"
 	"sync"
 )
 
 type FakeFileDataProvider struct {
-	GetDataStub        func(string, json.RawMessage) (string, error)
+	GetDataStub        func(config.Field, json.RawMessage, config.Instance, config.Group) (string, error)
 	getDataMutex       sync.RWMutex
 	getDataArgsForCall []struct {
-		arg1 string
+		arg1 config.Field
 		arg2 json.RawMessage
+		arg3 config.Instance
+		arg4 config.Group
 	}
 	getDataReturns struct {
 		result1 string
@@ -26,19 +29,21 @@ type FakeFileDataProvider struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeFileDataProvider) GetData(arg1 string, arg2 json.RawMessage) (string, error) {
+func (fake *FakeFileDataProvider) GetData(arg1 config.Field, arg2 json.RawMessage, arg3 config.Instance, arg4 config.Group) (string, error) {
 	fake.getDataMutex.Lock()
 	ret, specificReturn := fake.getDataReturnsOnCall[len(fake.getDataArgsForCall)]
 	fake.getDataArgsForCall = append(fake.getDataArgsForCall, struct {
-		arg1 string
+		arg1 config.Field
 		arg2 json.RawMessage
-	}{arg1, arg2})
+		arg3 config.Instance
+		arg4 config.Group
+	}{arg1, arg2, arg3, arg4})
 	stub := fake.GetDataStub
 	fakeReturns := fake.getDataReturns
-	fake.recordInvocation("GetData", []interface{}{arg1, arg2})
+	fake.recordInvocation("GetData", []interface{}{arg1, arg2, arg3, arg4})
 	fake.getDataMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -52,17 +57,17 @@ func (fake *FakeFileDataProvider) GetDataCallCount() int {
 	return len(fake.getDataArgsForCall)
 }
 
-func (fake *FakeFileDataProvider) GetDataCalls(stub func(string, json.RawMessage) (string, error)) {
+func (fake *FakeFileDataProvider) GetDataCalls(stub func(config.Field, json.RawMessage, config.Instance, config.Group) (string, error)) {
 	fake.getDataMutex.Lock()
 	defer fake.getDataMutex.Unlock()
 	fake.GetDataStub = stub
 }
 
-func (fake *FakeFileDataProvider) GetDataArgsForCall(i int) (string, json.RawMessage) {
+func (fake *FakeFileDataProvider) GetDataArgsForCall(i int) (config.Field, json.RawMessage, config.Instance, config.Group) {
 	fake.getDataMutex.RLock()
 	defer fake.getDataMutex.RUnlock()
 	argsForCall := fake.getDataArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
 func (fake *FakeFileDataProvider) GetDataReturns(result1 string, result2 error) {
@@ -1,82 +1,302 @@
 package data_provider
 
 import (
+	"bytes"
+	"ctRestClient/config"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"unicode/utf8"
 
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
 	"gopkg.in/yaml.v3"
 )
 
 //counterfeiter:generate . FileDataProvider
 type FileDataProvider interface {
-	GetData(ctFieldName string, ctFieldValue json.RawMessage) (string, error)
+	GetData(field config.Field, ctFieldValue json.RawMessage, instance config.Instance, group config.Group) (string, error)
 }
 
-type typedValue struct {
-	Tag   string
-	Value string
+type typedValue = config.MappingKey
+
+// A mappingTable contains the mapped values of a mapping file. Values read
+// from CSV files are untyped and stored with an empty tag.
+type mappingTable map[typedValue]string
+
+func (t mappingTable) lookup(key typedValue) (string, bool) {
+	if value, exists := t[key]; exists {
+		return value, true
+	}
+	value, exists := t[typedValue{Value: key.Value}]
+	return value, exists
 }
 
+// ErrMappingFileNotFound is returned if a mapped field has no mapping file.
+var ErrMappingFileNotFound = errors.New("the mapping file could not be found")
+
 type fileDataProvider struct {
-	dataDir   string
-	dataCache map[string]map[typedValue]string
+	dataDir       string
+	dataCache     map[string]mappingTable
+	resolvedFiles map[string]string
+	missingFiles  map[string]error
 }
 
+// NewFileDataProvider creates a provider for mapping files. A mapping file
+// is looked up in the following order, the first existing file is used:
+//
+//	<dataDir>/<hostname>/<group>/<field>.yml|.csv
+//	<dataDir>/<hostname>/<field>.yml|.csv
+//	<dataDir>/<field>.yml|.csv
+//
+// The group directory is also looked up with the group name of earlier
+// versions, see config.Group.LegacySanitizedName.
+//
+// Mappings defined inline on a field in the config take precedence over
+// all mapping files.
 func NewFileDataProvider(dataDir string) FileDataProvider {
 	return &fileDataProvider{
-		dataDir:   dataDir,
-		dataCache: make(map[string]map[typedValue]string),
+		dataDir:       dataDir,
+		dataCache:     make(map[string]mappingTable),
+		resolvedFiles: make(map[string]string),
+		missingFiles:  make(map[string]error),
 	}
 }
 
-func (dp *fileDataProvider) GetData(ctFieldName string, ctFieldValue json.RawMessage) (string, error) {
-	dataFilePath := filepath.Join(dp.dataDir, ctFieldName+".yml")
+func (dp *fileDataProvider) GetData(field config.Field, ctFieldValue json.RawMessage, instance config.Instance, group config.Group) (string, error) {
+	ctFieldName := field.GetFieldName()
+	typedValue := dp.createYamlKeyFromJSON(ctFieldValue)
+
+	if inlineMapping := field.GetMapping(); inlineMapping != nil {
+		if mappedValue, exists := mappingTable(inlineMapping).lookup(typedValue); exists {
+			return mappedValue, nil
+		}
+		return "", fmt.Errorf("the value %s is not in the inline mapping of field '%s'", typedValue.Value, ctFieldName)
+	}
+
+	dataFilePath, err := dp.resolveDataFile(ctFieldName, instance, group)
+	if err != nil {
+		return "", err
+	}
 
-	data, exists := dp.dataCache[ctFieldName]
+	data, exists := dp.dataCache[dataFilePath]
 
 	if !exists {
-		yamlData, err := os.ReadFile(dataFilePath)
+		if filepath.Ext(dataFilePath) == ".csv" {
+			data, err = readCSVMappingFile(dataFilePath)
+		} else {
+			data, err = readYAMLMappingFile(dataFilePath)
+		}
 		if err != nil {
 			return "", err
 		}
 
-		var yamlNode yaml.Node
-		if err := yaml.Unmarshal(yamlData, &yamlNode); err != nil {
+		// Fill the cache with the mapping data
+		dp.dataCache[dataFilePath] = data
+	}
+
+	if mappedValue, exists := data.lookup(typedValue); exists {
+		return mappedValue, nil
+	}
+
+	return "", fmt.Errorf("the value %s is not in '%s'", typedValue.Value, dataFilePath)
+}
+
+// mappingFileCandidates returns the possible mapping file paths for a field
+// in lookup order.
+func mappingFileCandidates(dataDir string, ctFieldName string, instance config.Instance, group config.Group) []string {
+	var dirs []string
+	if instance.Hostname != "" {
+		if group.Name != "" {
+			dirs = append(dirs, filepath.Join(dataDir, instance.Hostname, group.SanitizedName()))
+			if legacyName := group.LegacySanitizedName(); legacyName != group.SanitizedName() {
+				dirs = append(dirs, filepath.Join(dataDir, instance.Hostname, legacyName))
+			}
+		}
+		dirs = append(dirs, filepath.Join(dataDir, instance.Hostname))
+	}
+	dirs = append(dirs, dataDir)
+
+	candidates := make([]string, 0, len(dirs)*2)
+	for _, dir := range dirs {
+		candidates = append(candidates,
+			filepath.Join(dir, ctFieldName+".yml"),
+			filepath.Join(dir, ctFieldName+".csv"),
+		)
+	}
+	return candidates
+}
+
+func (dp *fileDataProvider) resolveDataFile(ctFieldName string, instance config.Instance, group config.Group) (string, error) {
+	scopeKey := strings.Join([]string{instance.Hostname, group.SanitizedName(), ctFieldName}, "/")
+	if path, ok := dp.resolvedFiles[scopeKey]; ok {
+		return path, nil
+	}
+	// A missing mapping file is not searched again for every person
+	if err, ok := dp.missingFiles[scopeKey]; ok {
+		return "", err
+	}
+
+	path, err := FindMappingFile(dp.dataDir, ctFieldName, instance, group)
+	if errors.Is(err, ErrMappingFileNotFound) {
+		dp.missingFiles[scopeKey] = err
+	}
+	if err != nil {
+		return "", err
+	}
+	dp.resolvedFiles[scopeKey] = path
+	return path, nil
+}
+
+// FindMappingFile returns the path of the mapping file of a field that is
+// used for a group of an instance. If there is none, the error lists all
+// searched paths.
+func FindMappingFile(dataDir string, ctFieldName string, instance config.Instance, group config.Group) (string, error) {
+	candidates := mappingFileCandidates(dataDir, ctFieldName, instance, group)
+	for _, candidate := range candidates {
+		_, err := os.Stat(candidate)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
 			return "", err
 		}
+		return candidate, nil
+	}
 
-		dataMap := make(map[typedValue]string)
+	return "", fmt.Errorf("%w, searched '%s'", ErrMappingFileNotFound, strings.Join(candidates, "', '"))
+}
+
+// ReadMappingFile reads a YAML or CSV mapping file and returns the number of
+// mapped values.
+func ReadMappingFile(dataFilePath string) (int, error) {
+	var data mappingTable
+	var err error
+	if filepath.Ext(dataFilePath) == ".csv" {
+		data, err = readCSVMappingFile(dataFilePath)
+	} else {
+		data, err = readYAMLMappingFile(dataFilePath)
+	}
+	return len(data), err
+}
+
+func readYAMLMappingFile(dataFilePath string) (mappingTable, error) {
+	yamlData, err := os.ReadFile(dataFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var yamlNode yaml.Node
+	if err := yaml.Unmarshal(yamlData, &yamlNode); err != nil {
+		return nil, err
+	}
+
+	dataMap := make(mappingTable)
 
-		if yamlNode.Kind == yaml.DocumentNode && len(yamlNode.Content) > 0 {
-			mapNode := yamlNode.Content[0]
-			if mapNode.Kind == yaml.MappingNode {
-				for i := 0; i < len(mapNode.Content); i += 2 {
-					keyNode := mapNode.Content[i]
-					valueNode := mapNode.Content[i+1]
+	if yamlNode.Kind == yaml.DocumentNode && len(yamlNode.Content) > 0 {
+		mapNode := yamlNode.Content[0]
+		if mapNode.Kind == yaml.MappingNode {
+			for i := 0; i < len(mapNode.Content); i += 2 {
+				keyNode := mapNode.Content[i]
+				valueNode := mapNode.Content[i+1]
 
-					dataMap[typedValue{
-						Tag:   keyNode.Tag,
-						Value: keyNode.Value,
-					}] = valueNode.Value
-				}
+				dataMap[typedValue{
+					Tag:   keyNode.Tag,
+					Value: keyNode.Value,
+				}] = valueNode.Value
 			}
 		}
+	}
+
+	return dataMap, nil
+}
 
-		// Fill the cache with the YAML data
-		dp.dataCache[ctFieldName] = dataMap
-		data = dataMap
+// readCSVMappingFile reads a mapping file with two columns, the ChurchTools
+// value and the mapped value. The first line is a header line and is ignored.
+// The file may be UTF-8, UTF-16 with BOM or Windows-1252 encoded and may use
+// semicolons, commas or tabs as delimiter, as written by Excel.
+func readCSVMappingFile(dataFilePath string) (mappingTable, error) {
+	rawData, err := os.ReadFile(dataFilePath)
+	if err != nil {
+		return nil, err
 	}
 
-	typedValue := dp.createYamlKeyFromJSON(ctFieldValue)
+	csvData, err := decodeCSVMappingData(rawData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode '%s': %w", dataFilePath, err)
+	}
 
-	if mappedValue, exists := data[typedValue]; exists {
-		return mappedValue, nil
+	reader := csv.NewReader(bytes.NewReader(csvData))
+	reader.Comma = detectCSVDelimiter(csvData)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	dataMap := make(mappingTable)
+	isHeader := true
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read '%s': %w", dataFilePath, err)
+		}
+		if isHeader {
+			isHeader = false
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(record) < 2 {
+			return nil, fmt.Errorf("line %d of '%s' must contain a value and a mapped value", line, dataFilePath)
+		}
+
+		key := strings.TrimSpace(record[0])
+		if _, exists := dataMap[typedValue{Value: key}]; exists {
+			return nil, fmt.Errorf("line %d of '%s' contains the duplicate value '%s'", line, dataFilePath, key)
+		}
+		dataMap[typedValue{Value: key}] = strings.TrimSpace(record[1])
 	}
 
-	return "", fmt.Errorf("the value %s is not in '%s'", typedValue.Value, dataFilePath)
+	return dataMap, nil
+}
+
+func decodeCSVMappingData(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}), bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		decoder := unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM).NewDecoder()
+		decoded, _, err := transform.Bytes(decoder, data)
+		return decoded, err
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return data[3:], nil
+	case utf8.Valid(data):
+		return data, nil
+	default:
+		decoded, _, err := transform.Bytes(charmap.Windows1252.NewDecoder(), data)
+		return decoded, err
+	}
+}
+
+func detectCSVDelimiter(data []byte) rune {
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+
+	delimiter := ';'
+	maxCount := bytes.Count(firstLine, []byte(";"))
+	for _, candidate := range []rune{',', '\t'} {
+		if count := bytes.Count(firstLine, []byte(string(candidate))); count > maxCount {
+			delimiter = candidate
+			maxCount = count
+		}
+	}
+	return delimiter
 }
 
 func (dp *fileDataProvider) createYamlKeyFromJSON(ctFieldValue json.RawMessage) typedValue {
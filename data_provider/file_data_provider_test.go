@@ -1,10 +1,12 @@
 package data_provider_test
 
 import (
+	"ctRestClient/config"
 	"ctRestClient/data_provider"
 	"ctRestClient/testutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -17,6 +19,9 @@ var _ = Describe("FileDataProvider", func() {
 		tempDataDir    string
 		dp             data_provider.FileDataProvider
 		mappedFilePath string
+		field          config.Field
+		instance       config.Instance
+		group          config.Group
 	)
 
 	BeforeEach(func() {
@@ -38,6 +43,10 @@ var _ = Describe("FileDataProvider", func() {
 		err = os.WriteFile(mappedFilePath, []byte(yamlContent), 0644)
 		Expect(err).ToNot(HaveOccurred())
 		dp = data_provider.NewFileDataProvider(tempDataDir)
+
+		field = config.Field{Object: &config.FieldInformation{FieldName: "mappedField", ColumnName: "mappedColumn"}}
+		instance = config.Instance{Hostname: "foo.church.tools"}
+		group = config.Group{Name: "foo group"}
 	})
 
 	AfterEach(func() {
@@ -46,35 +55,189 @@ var _ = Describe("FileDataProvider", func() {
 
 	var _ = Describe("GetData", func() {
 		It("returns mapped data for int keys", func() {
-			result, _ := dp.GetData("mappedField", []byte("1"))
+			result, _ := dp.GetData(field, []byte("1"), instance, group)
 			Expect(result).To(Equal("number one"))
-			result, _ = dp.GetData("mappedField", []byte("2"))
+			result, _ = dp.GetData(field, []byte("2"), instance, group)
 			Expect(result).To(Equal("number two"))
 		})
 
 		It("returns mapped data for float keys", func() {
-			result, _ := dp.GetData("mappedField", []byte("1.1"))
+			result, _ := dp.GetData(field, []byte("1.1"), instance, group)
 			Expect(result).To(Equal("number one point one"))
 
-			result, _ = dp.GetData("mappedField", []byte("1.0"))
+			result, _ = dp.GetData(field, []byte("1.0"), instance, group)
 			Expect(result).To(Equal("number one point zero"))
 		})
 
 		It("returns mapped data for string keys", func() {
-			result, _ := dp.GetData("mappedField", []byte("\"2\""))
+			result, _ := dp.GetData(field, []byte("\"2\""), instance, group)
 			Expect(result).To(Equal("string two"))
 
-			result, _ = dp.GetData("mappedField", []byte("A"))
+			result, _ = dp.GetData(field, []byte("A"), instance, group)
 			Expect(result).To(Equal("string A"))
 
-			result, _ = dp.GetData("mappedField", []byte("\"B\""))
+			result, _ = dp.GetData(field, []byte("\"B\""), instance, group)
 			Expect(result).To(Equal("string B"))
 		})
 
 		It("returns error for non-existing key", func() {
-			_, err := dp.GetData("mappedField", []byte("999"))
+			_, err := dp.GetData(field, []byte("999"), instance, group)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("the value 999 is not in '" + mappedFilePath + "'"))
 		})
+
+		It("returns an error if no mapping file exists", func() {
+			unknownField := config.Field{Object: &config.FieldInformation{FieldName: "unknownField", ColumnName: "unknownColumn"}}
+			_, err := dp.GetData(unknownField, []byte("1"), instance, group)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(MatchError("the mapping file could not be found, searched '" + strings.Join([]string{
+				filepath.Join(tempDataDir, "foo.church.tools", "foo_group", "unknownField.yml"),
+				filepath.Join(tempDataDir, "foo.church.tools", "foo_group", "unknownField.csv"),
+				filepath.Join(tempDataDir, "foo.church.tools", "unknownField.yml"),
+				filepath.Join(tempDataDir, "foo.church.tools", "unknownField.csv"),
+				filepath.Join(tempDataDir, "unknownField.yml"),
+				filepath.Join(tempDataDir, "unknownField.csv"),
+			}, "', '") + "'"))
+		})
+
+		It("does not search a missing mapping file again", func() {
+			unknownField := config.Field{Object: &config.FieldInformation{FieldName: "unknownField", ColumnName: "unknownColumn"}}
+			_, err := dp.GetData(unknownField, []byte("1"), instance, group)
+			Expect(err).To(MatchError(data_provider.ErrMappingFileNotFound))
+
+			err = os.WriteFile(filepath.Join(tempDataDir, "unknownField.yml"), []byte(`1: "number one"`), 0644)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = dp.GetData(unknownField, []byte("1"), instance, group)
+			Expect(err).To(MatchError(data_provider.ErrMappingFileNotFound))
+		})
+
+		It("prefers inline mappings over mapping files", func() {
+			inlineField := config.Field{Object: &config.FieldInformation{
+				FieldName:  "mappedField",
+				ColumnName: "mappedColumn",
+				Mapping: config.Mapping{
+					{Tag: "!!int", Value: "1"}: "inline one",
+				},
+			}}
+
+			result, err := dp.GetData(inlineField, []byte("1"), instance, group)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal("inline one"))
+
+			_, err = dp.GetData(inlineField, []byte("2"), instance, group)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("the value 2 is not in the inline mapping of field 'mappedField'"))
+		})
+
+		It("prefers instance mapping files over global mapping files", func() {
+			instanceDir := filepath.Join(tempDataDir, "foo.church.tools")
+			Expect(os.MkdirAll(instanceDir, 0755)).To(Succeed())
+			err = os.WriteFile(filepath.Join(instanceDir, "mappedField.yml"), []byte(`1: "instance one"`), 0644)
+			Expect(err).ToNot(HaveOccurred())
+
+			result, err := dp.GetData(field, []byte("1"), instance, group)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal("instance one"))
+
+			result, err = dp.GetData(field, []byte("1"), config.Instance{Hostname: "bar.church.tools"}, group)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal("number one"))
+		})
+
+		It("prefers group mapping files over instance mapping files", func() {
+			instanceDir := filepath.Join(tempDataDir, "foo.church.tools")
+			groupDir := filepath.Join(instanceDir, "foo_group")
+			Expect(os.MkdirAll(groupDir, 0755)).To(Succeed())
+			err = os.WriteFile(filepath.Join(instanceDir, "mappedField.yml"), []byte(`1: "instance one"`), 0644)
+			Expect(err).ToNot(HaveOccurred())
+			err = os.WriteFile(filepath.Join(groupDir, "mappedField.yml"), []byte(`1: "group one"`), 0644)
+			Expect(err).ToNot(HaveOccurred())
+
+			result, err := dp.GetData(field, []byte("1"), instance, group)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal("group one"))
+
+			result, err = dp.GetData(field, []byte("1"), instance, config.Group{Name: "bar group"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal("instance one"))
+		})
+
+		It("uses the group mapping directory of earlier versions", func() {
+			groupDir := filepath.Join(tempDataDir, "foo.church.tools", "Caf_Treff")
+			Expect(os.MkdirAll(groupDir, 0755)).To(Succeed())
+			err = os.WriteFile(filepath.Join(groupDir, "mappedField.yml"), []byte(`1: "group one"`), 0644)
+			Expect(err).ToNot(HaveOccurred())
+
+			result, err := dp.GetData(field, []byte("1"), instance, config.Group{Name: "Café Treff"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal("group one"))
+		})
+
+		var _ = Describe("csv mapping files", func() {
+			var csvField config.Field
+
+			BeforeEach(func() {
+				csvField = config.Field{Object: &config.FieldInformation{FieldName: "csvField", ColumnName: "csvColumn"}}
+			})
+
+			It("returns mapped data and ignores the header line", func() {
+				csvContent := "Wert;Bezeichnung\n1;Mitglied\nA; Gast \n"
+				err = os.WriteFile(filepath.Join(tempDataDir, "csvField.csv"), []byte(csvContent), 0644)
+				Expect(err).ToNot(HaveOccurred())
+
+				result, err := dp.GetData(csvField, []byte("1"), instance, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal("Mitglied"))
+
+				result, err = dp.GetData(csvField, []byte(`"A"`), instance, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal("Gast"))
+
+				_, err = dp.GetData(csvField, []byte(`"Wert"`), instance, group)
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("reads comma separated files with UTF-8 BOM", func() {
+				csvContent := "\xEF\xBB\xBFvalue,label\n1,Gemeindeglied\n"
+				err = os.WriteFile(filepath.Join(tempDataDir, "csvField.csv"), []byte(csvContent), 0644)
+				Expect(err).ToNot(HaveOccurred())
+
+				result, err := dp.GetData(csvField, []byte("1"), instance, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal("Gemeindeglied"))
+			})
+
+			It("reads Windows-1252 encoded files", func() {
+				csvContent := []byte("Wert;Bezeichnung\n1;Gr\xFC\xDFe\n")
+				err = os.WriteFile(filepath.Join(tempDataDir, "csvField.csv"), csvContent, 0644)
+				Expect(err).ToNot(HaveOccurred())
+
+				result, err := dp.GetData(csvField, []byte("1"), instance, group)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal("Grüße"))
+			})
+
+			It("returns an error for duplicate values", func() {
+				csvContent := "Wert;Bezeichnung\n1;Mitglied\n1;Gast\n"
+				err = os.WriteFile(filepath.Join(tempDataDir, "csvField.csv"), []byte(csvContent), 0644)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err := dp.GetData(csvField, []byte("1"), instance, group)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("line 3 of"))
+				Expect(err.Error()).To(ContainSubstring("contains the duplicate value '1'"))
+			})
+
+			It("returns an error for lines without mapped value", func() {
+				csvContent := "Wert;Bezeichnung\n1\n"
+				err = os.WriteFile(filepath.Join(tempDataDir, "csvField.csv"), []byte(csvContent), 0644)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err := dp.GetData(csvField, []byte("1"), instance, group)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("must contain a value and a mapped value"))
+			})
+		})
 	})
 })
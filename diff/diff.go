@@ -0,0 +1,116 @@
+package diff
+
+import (
+	"ctRestClient/csv"
+	"fmt"
+	"slices"
+)
+
+// ChangedHeader is the header of the file of changed persons, it has a row
+// per changed column of a person.
+var ChangedHeader = []string{"id", "column", "previous", "current"}
+
+// Result are the differences between two exports of a group.
+type Result struct {
+	// Added are the persons that are only in the current export.
+	Added csv.CsvData
+	// Removed are the persons that are only in the previous export, with the
+	// columns of the previous export.
+	Removed csv.CsvData
+	// Changed are the changed columns of the persons in both exports.
+	Changed csv.CsvData
+	// ChangedPersons is the number of persons with at least one changed
+	// column.
+	ChangedPersons int
+}
+
+type table struct {
+	header  []string
+	records [][]string
+}
+
+func (t table) Header() []string {
+	return t.header
+}
+
+func (t table) Records() [][]string {
+	return t.records
+}
+
+// Compare compares the persons of two exports of a group by the id column.
+// Only the columns of both exports are compared, so that a column added to
+// or removed from the group does not change every person.
+func Compare(previous csv.CsvData, current csv.CsvData, idColumn string) (Result, error) {
+	previousPersons, err := index(previous, idColumn)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to read the previous export, %w", err)
+	}
+	currentPersons, err := index(current, idColumn)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to read the current export, %w", err)
+	}
+
+	// The column indexes of the current export in the previous export
+	previousIndexes := make([]int, len(current.Header()))
+	for i, column := range current.Header() {
+		previousIndexes[i] = slices.Index(previous.Header(), column)
+	}
+	currentId := slices.Index(current.Header(), idColumn)
+
+	added, removed, changed := [][]string{}, [][]string{}, [][]string{}
+	changedPersons := 0
+	for _, record := range current.Records() {
+		id := record[currentId]
+		previousRecord, exists := previousPersons[id]
+		if !exists {
+			added = append(added, record)
+			continue
+		}
+
+		personChanged := false
+		for i, column := range current.Header() {
+			if previousIndexes[i] < 0 || record[i] == previousRecord[previousIndexes[i]] {
+				continue
+			}
+			changed = append(changed, []string{id, column, previousRecord[previousIndexes[i]], record[i]})
+			personChanged = true
+		}
+		if personChanged {
+			changedPersons++
+		}
+	}
+	previousId := slices.Index(previous.Header(), idColumn)
+	for _, record := range previous.Records() {
+		if _, exists := currentPersons[record[previousId]]; !exists {
+			removed = append(removed, record)
+		}
+	}
+
+	return Result{
+		Added:          table{header: current.Header(), records: added},
+		Removed:        table{header: previous.Header(), records: removed},
+		Changed:        table{header: ChangedHeader, records: changed},
+		ChangedPersons: changedPersons,
+	}, nil
+}
+
+// index returns the records by their id.
+func index(data csv.CsvData, idColumn string) (map[string][]string, error) {
+	idIndex := slices.Index(data.Header(), idColumn)
+	if idIndex < 0 {
+		return nil, fmt.Errorf("the column '%s' does not exist", idColumn)
+	}
+
+	records := make(map[string][]string, len(data.Records()))
+	for _, record := range data.Records() {
+		if len(record) != len(data.Header()) {
+			return nil, fmt.Errorf("the record %v does not match the header", record)
+		}
+		id := record[idIndex]
+		if _, exists := records[id]; exists {
+			return nil, fmt.Errorf("the id '%s' is not unique", id)
+		}
+		records[id] = record
+	}
+	return records, nil
+}
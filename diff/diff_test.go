@@ -0,0 +1,106 @@
+package diff_test
+
+import (
+	"ctRestClient/diff"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type data struct {
+	header  []string
+	records [][]string
+}
+
+func (d data) Header() []string    { return d.header }
+func (d data) Records() [][]string { return d.records }
+
+var _ = Describe("Compare", func() {
+	var previous data
+
+	BeforeEach(func() {
+		previous = data{
+			header: []string{"ID", "firstName", "street"},
+			records: [][]string{
+				{"1", "John", "Main Street 1"},
+				{"2", "Jane", "Church Road 2"},
+				{"3", "Max", "Market 3"},
+			},
+		}
+	})
+
+	It("returns the added, removed and changed persons", func() {
+		current := data{
+			header: []string{"ID", "firstName", "street"},
+			records: [][]string{
+				{"4", "Anna", "Lake 4"},
+				{"2", "Jane", "Hill 5"},
+				{"1", "John", "Main Street 1"},
+			},
+		}
+
+		result, err := diff.Compare(previous, current, "ID")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Added.Header()).To(Equal([]string{"ID", "firstName", "street"}))
+		Expect(result.Added.Records()).To(Equal([][]string{{"4", "Anna", "Lake 4"}}))
+		Expect(result.Removed.Records()).To(Equal([][]string{{"3", "Max", "Market 3"}}))
+		Expect(result.Changed.Header()).To(Equal(diff.ChangedHeader))
+		Expect(result.Changed.Records()).To(Equal([][]string{{"2", "street", "Church Road 2", "Hill 5"}}))
+		Expect(result.ChangedPersons).To(Equal(1))
+	})
+
+	It("lists every changed column of a person", func() {
+		current := data{
+			header:  previous.header,
+			records: [][]string{{"1", "Johnny", "Main Street 9"}, {"2", "Jane", "Church Road 2"}, {"3", "Max", "Market 3"}},
+		}
+
+		result, err := diff.Compare(previous, current, "ID")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Changed.Records()).To(Equal([][]string{
+			{"1", "firstName", "John", "Johnny"},
+			{"1", "street", "Main Street 1", "Main Street 9"},
+		}))
+		Expect(result.ChangedPersons).To(Equal(1))
+	})
+
+	It("only compares the columns of both exports", func() {
+		current := data{
+			header:  []string{"email", "ID", "firstName"},
+			records: [][]string{{"john@example.com", "1", "John"}, {"", "2", "Jane"}, {"", "3", "Max"}},
+		}
+
+		result, err := diff.Compare(previous, current, "ID")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Added.Records()).To(BeEmpty())
+		Expect(result.Removed.Records()).To(BeEmpty())
+		Expect(result.Changed.Records()).To(BeEmpty())
+	})
+
+	It("keeps the columns of the previous export for removed persons", func() {
+		current := data{header: []string{"ID"}, records: [][]string{{"1"}, {"2"}}}
+
+		result, err := diff.Compare(previous, current, "ID")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Removed.Header()).To(Equal(previous.header))
+		Expect(result.Removed.Records()).To(Equal([][]string{{"3", "Max", "Market 3"}}))
+	})
+
+	It("returns an error if an export has no id column", func() {
+		current := data{header: []string{"id"}, records: [][]string{}}
+
+		_, err := diff.Compare(previous, current, "id")
+		Expect(err).To(MatchError("failed to read the previous export, the column 'id' does not exist"))
+	})
+
+	It("returns an error if an id is not unique", func() {
+		current := data{header: []string{"ID"}, records: [][]string{{"1"}, {"1"}}}
+
+		_, err := diff.Compare(previous, current, "ID")
+		Expect(err).To(MatchError("failed to read the current export, the id '1' is not unique"))
+	})
+})
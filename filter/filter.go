@@ -0,0 +1,601 @@
+package filter
+
+import (
+	"ctRestClient/jsonpath"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Mapper returns the mapped value of a field, see mapped().
+type Mapper func(fieldName string, value json.RawMessage) (string, error)
+
+// An Expression is a parsed filter expression like
+//
+//	age(birthday) >= 18 and not empty(street) and campusId in [1, 2]
+//
+// Identifiers are field paths of the person data. Supported are the
+// comparisons ==, !=, <, <=, >, >=, the boolean operators and, or, not
+// (or &&, ||, !), lists with in and not in, and the functions empty(),
+// age() and mapped().
+type Expression struct {
+	source string
+	root   node
+	fields []string
+}
+
+// Parse parses a filter expression.
+func Parse(expression string) (*Expression, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s at position %d", p.peek(), p.peek().pos+1)
+	}
+
+	return &Expression{source: expression, root: root, fields: p.fields}, nil
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Fields returns the field paths used in the expression.
+func (e *Expression) Fields() []string {
+	return e.fields
+}
+
+// Matches evaluates the expression for a person.
+func (e *Expression) Matches(person map[string]json.RawMessage, mapper Mapper) (bool, error) {
+	value, err := e.root.eval(&context{person: person, mapper: mapper, now: time.Now()})
+	if err != nil {
+		return false, err
+	}
+	return truthy(value), nil
+}
+
+type context struct {
+	person map[string]json.RawMessage
+	mapper Mapper
+	now    time.Time
+}
+
+type node interface {
+	eval(ctx *context) (interface{}, error)
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	fields []string
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t.kind == tokenIdentifier && strings.EqualFold(t.value, keyword)
+}
+
+func (p *parser) expect(kind tokenKind, description string) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, fmt.Errorf("expected %s but found %s at position %d", description, t, t.pos+1)
+	}
+	return t, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or") || (p.peek().kind == tokenOperator && p.peek().value == "||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("and") || (p.peek().kind == tokenOperator && p.peek().value == "&&") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.isKeyword("not") || (p.peek().kind == tokenOperator && p.peek().value == "!") {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind == tokenOperator {
+		switch t.value {
+		case "==", "!=", "<", "<=", ">", ">=":
+			p.next()
+			right, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			return comparisonNode{operator: t.value, left: left, right: right}, nil
+		}
+	}
+
+	negate := false
+	if p.isKeyword("not") && p.pos+1 < len(p.tokens) {
+		following := p.tokens[p.pos+1]
+		if following.kind == tokenIdentifier && strings.EqualFold(following.value, "in") {
+			p.next()
+			negate = true
+		}
+	}
+	if p.isKeyword("in") {
+		p.next()
+		list, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		var result node = inNode{value: left, list: list}
+		if negate {
+			result = notNode{operand: result}
+		}
+		return result, nil
+	}
+
+	return left, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenString:
+		return literalNode{value: t.value}, nil
+
+	case tokenNumber:
+		number, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s' at position %d", t.value, t.pos+1)
+		}
+		return literalNode{value: number}, nil
+
+	case tokenLeftParen:
+		expression, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRightParen, "')'"); err != nil {
+			return nil, err
+		}
+		return expression, nil
+
+	case tokenLeftBracket:
+		var elements []node
+		for p.peek().kind != tokenRightBracket {
+			element, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.next()
+		}
+		if _, err := p.expect(tokenRightBracket, "']'"); err != nil {
+			return nil, err
+		}
+		return listNode{elements: elements}, nil
+
+	case tokenIdentifier:
+		switch strings.ToLower(t.value) {
+		case "true":
+			return literalNode{value: true}, nil
+		case "false":
+			return literalNode{value: false}, nil
+		case "null":
+			return literalNode{value: nil}, nil
+		}
+		if isKeyword(t.value) {
+			return nil, fmt.Errorf("unexpected %s at position %d", t, t.pos+1)
+		}
+
+		if p.peek().kind == tokenLeftParen {
+			return p.parseFunction(t)
+		}
+		p.fields = append(p.fields, t.value)
+		return fieldNode{path: t.value}, nil
+	}
+
+	return nil, fmt.Errorf("unexpected %s at position %d", t, t.pos+1)
+}
+
+func (p *parser) parseFunction(name token) (node, error) {
+	p.next()
+
+	switch strings.ToLower(name.value) {
+	case "empty", "age":
+		argument, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRightParen, "')'"); err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(name.value, "empty") {
+			return emptyNode{operand: argument}, nil
+		}
+		return ageNode{operand: argument}, nil
+
+	case "mapped":
+		field, err := p.expect(tokenIdentifier, "a field name")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRightParen, "')'"); err != nil {
+			return nil, err
+		}
+		p.fields = append(p.fields, field.value)
+		return mappedNode{path: field.value}, nil
+	}
+
+	return nil, fmt.Errorf("unknown function '%s' at position %d", name.value, name.pos+1)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n literalNode) eval(ctx *context) (interface{}, error) {
+	return n.value, nil
+}
+
+type listNode struct {
+	elements []node
+}
+
+func (n listNode) eval(ctx *context) (interface{}, error) {
+	values := make([]interface{}, 0, len(n.elements))
+	for _, element := range n.elements {
+		value, err := element.eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+type fieldNode struct {
+	path string
+}
+
+func (n fieldNode) eval(ctx *context) (interface{}, error) {
+	rawValue, exists := jsonpath.Lookup(ctx.person, n.path)
+	if !exists {
+		return nil, nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(rawValue, &value); err != nil {
+		return nil, fmt.Errorf("failed to read field '%s': %w", n.path, err)
+	}
+	return value, nil
+}
+
+type mappedNode struct {
+	path string
+}
+
+func (n mappedNode) eval(ctx *context) (interface{}, error) {
+	rawValue, exists := jsonpath.Lookup(ctx.person, n.path)
+	if !exists || string(rawValue) == "null" {
+		return nil, nil
+	}
+	if ctx.mapper == nil {
+		return nil, fmt.Errorf("mapped values are not available for field '%s'", n.path)
+	}
+	mappedValue, err := ctx.mapper(n.path, rawValue)
+	if err != nil {
+		return nil, err
+	}
+	return mappedValue, nil
+}
+
+type emptyNode struct {
+	operand node
+}
+
+func (n emptyNode) eval(ctx *context) (interface{}, error) {
+	value, err := n.operand.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case nil:
+		return true, nil
+	case string:
+		return strings.TrimSpace(v) == "", nil
+	case []interface{}:
+		return len(v) == 0, nil
+	case map[string]interface{}:
+		return len(v) == 0, nil
+	}
+	return false, nil
+}
+
+type ageNode struct {
+	operand node
+}
+
+// eval returns the age in years for a date like '2000-12-31' or null if
+// the value is not a date.
+func (n ageNode) eval(ctx *context) (interface{}, error) {
+	value, err := n.operand.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dateString, ok := value.(string)
+	if !ok || len(dateString) < 10 {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", dateString[:10])
+	if err != nil {
+		return nil, nil
+	}
+
+	age := ctx.now.Year() - date.Year()
+	if ctx.now.Month() < date.Month() || (ctx.now.Month() == date.Month() && ctx.now.Day() < date.Day()) {
+		age--
+	}
+	return float64(age), nil
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n notNode) eval(ctx *context) (interface{}, error) {
+	value, err := n.operand.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return !truthy(value), nil
+}
+
+type andNode struct {
+	left, right node
+}
+
+func (n andNode) eval(ctx *context) (interface{}, error) {
+	left, err := n.left.eval(ctx)
+	if err != nil || !truthy(left) {
+		return false, err
+	}
+	right, err := n.right.eval(ctx)
+	if err != nil {
+		return false, err
+	}
+	return truthy(right), nil
+}
+
+type orNode struct {
+	left, right node
+}
+
+func (n orNode) eval(ctx *context) (interface{}, error) {
+	left, err := n.left.eval(ctx)
+	if err != nil {
+		return false, err
+	}
+	if truthy(left) {
+		return true, nil
+	}
+	right, err := n.right.eval(ctx)
+	if err != nil {
+		return false, err
+	}
+	return truthy(right), nil
+}
+
+type inNode struct {
+	value, list node
+}
+
+func (n inNode) eval(ctx *context) (interface{}, error) {
+	value, err := n.value.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list, err := n.list.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	elements, ok := list.([]interface{})
+	if !ok {
+		return false, nil
+	}
+	for _, element := range elements {
+		if equal(value, element) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type comparisonNode struct {
+	operator    string
+	left, right node
+}
+
+func (n comparisonNode) eval(ctx *context) (interface{}, error) {
+	left, err := n.left.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.operator {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	}
+
+	// Ordering comparisons with null are always false
+	if left == nil || right == nil {
+		return false, nil
+	}
+
+	var result int
+	if leftNumber, rightNumber, ok := asNumbers(left, right); ok {
+		result = compareNumbers(leftNumber, rightNumber)
+	} else {
+		result = strings.Compare(toString(left), toString(right))
+	}
+
+	switch n.operator {
+	case "<":
+		return result < 0, nil
+	case "<=":
+		return result <= 0, nil
+	case ">":
+		return result > 0, nil
+	default:
+		return result >= 0, nil
+	}
+}
+
+func equal(left, right interface{}) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+	if leftNumber, rightNumber, ok := asNumbers(left, right); ok {
+		return leftNumber == rightNumber
+	}
+	if leftBool, ok := left.(bool); ok {
+		rightBool, ok := right.(bool)
+		return ok && leftBool == rightBool
+	}
+	return toString(left) == toString(right)
+}
+
+// asNumbers returns both values as numbers if at least one of them is a
+// number and the other one is a number or a numeric string, e.g. a zip code.
+func asNumbers(left, right interface{}) (float64, float64, bool) {
+	_, leftIsNumber := left.(float64)
+	_, rightIsNumber := right.(float64)
+	if !leftIsNumber && !rightIsNumber {
+		return 0, 0, false
+	}
+	leftNumber, ok := toNumber(left)
+	if !ok {
+		return 0, 0, false
+	}
+	rightNumber, ok := toNumber(right)
+	if !ok {
+		return 0, 0, false
+	}
+	return leftNumber, rightNumber, true
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+func compareNumbers(left, right float64) int {
+	switch {
+	case left < right:
+		return -1
+	case left > right:
+		return 1
+	}
+	return 0
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		if v == math.Trunc(v) {
+			return strconv.FormatFloat(v, 'f', 0, 64)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	}
+	bytes, _ := json.Marshal(value)
+	return string(bytes)
+}
+
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case float64:
+		return v != 0
+	case []interface{}:
+		return len(v) > 0
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package filter_test
+
+import (
+	"ctRestClient/filter"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Filter", func() {
+
+	var (
+		person map[string]json.RawMessage
+		mapper filter.Mapper
+	)
+
+	matches := func(expression string) bool {
+		parsed, err := filter.Parse(expression)
+		Expect(err).ToNot(HaveOccurred())
+		result, err := parsed.Matches(person, mapper)
+		Expect(err).ToNot(HaveOccurred())
+		return result
+	}
+
+	BeforeEach(func() {
+		adultBirthday := time.Now().AddDate(-30, 0, 0).Format("2006-01-02")
+		err := json.Unmarshal([]byte(fmt.Sprintf(`{
+			"id": 1,
+			"firstName": "foo",
+			"email": "",
+			"street": "Mainstreet 1",
+			"zip": "12345",
+			"campusId": 2,
+			"statusId": 3,
+			"birthday": "%s",
+			"isArchived": false,
+			"weddingDate": null,
+			"member": {"groupMemberStatus": "active"}
+		}`, adultBirthday)), &person)
+		Expect(err).ToNot(HaveOccurred())
+
+		mapper = func(fieldName string, value json.RawMessage) (string, error) {
+			if fieldName == "statusId" && string(value) == "3" {
+				return "Mitglied", nil
+			}
+			return "", errors.New("not mapped")
+		}
+	})
+
+	var _ = Describe("Matches", func() {
+		It("compares values", func() {
+			Expect(matches(`firstName == "foo"`)).To(BeTrue())
+			Expect(matches(`firstName != 'foo'`)).To(BeFalse())
+			Expect(matches(`campusId == 2`)).To(BeTrue())
+			Expect(matches(`campusId > 1 && campusId <= 2`)).To(BeTrue())
+			Expect(matches(`zip == 12345`)).To(BeTrue())
+			Expect(matches(`zip >= "10000"`)).To(BeTrue())
+			Expect(matches(`isArchived == false`)).To(BeTrue())
+			Expect(matches(`weddingDate == null`)).To(BeTrue())
+			Expect(matches(`weddingDate < 5`)).To(BeFalse())
+			Expect(matches(`unknown == null`)).To(BeTrue())
+			Expect(matches(`member.groupMemberStatus == "active"`)).To(BeTrue())
+		})
+
+		It("combines conditions", func() {
+			Expect(matches(`firstName == "foo" and campusId == 1`)).To(BeFalse())
+			Expect(matches(`firstName == "foo" or campusId == 1`)).To(BeTrue())
+			Expect(matches(`not (campusId == 1)`)).To(BeTrue())
+			Expect(matches(`!isArchived`)).To(BeTrue())
+			Expect(matches(`campusId == 1 or campusId == 2 and firstName == "bar"`)).To(BeFalse())
+		})
+
+		It("checks for empty values", func() {
+			Expect(matches(`empty(email)`)).To(BeTrue())
+			Expect(matches(`empty(weddingDate)`)).To(BeTrue())
+			Expect(matches(`empty(unknown)`)).To(BeTrue())
+			Expect(matches(`not empty(street)`)).To(BeTrue())
+		})
+
+		It("calculates the age", func() {
+			Expect(matches(`age(birthday) >= 18`)).To(BeTrue())
+			Expect(matches(`age(birthday) == 30`)).To(BeTrue())
+			Expect(matches(`age(weddingDate) >= 18`)).To(BeFalse())
+		})
+
+		It("checks lists", func() {
+			Expect(matches(`campusId in [1, 2]`)).To(BeTrue())
+			Expect(matches(`campusId not in [1, 2]`)).To(BeFalse())
+			Expect(matches(`firstName in ["bar", "baz"]`)).To(BeFalse())
+		})
+
+		It("uses mapped values", func() {
+			Expect(matches(`mapped(statusId) == "Mitglied"`)).To(BeTrue())
+			Expect(matches(`mapped(weddingDate) == null`)).To(BeTrue())
+		})
+
+		It("returns an error if a value cannot be mapped", func() {
+			parsed, err := filter.Parse(`mapped(campusId) == "foo"`)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = parsed.Matches(person, mapper)
+			Expect(err).To(MatchError("not mapped"))
+		})
+	})
+
+	var _ = Describe("Fields", func() {
+		It("returns the used field paths", func() {
+			parsed, err := filter.Parse(`age(birthday) >= 18 and mapped(statusId) == "Mitglied" or member.comment == "foo"`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsed.Fields()).To(Equal([]string{"birthday", "statusId", "member.comment"}))
+		})
+	})
+
+	var _ = Describe("Parse", func() {
+		It("returns errors for invalid expressions", func() {
+			_, err := filter.Parse(`firstName ==`)
+			Expect(err).To(MatchError("unexpected end of expression at position 13"))
+
+			_, err = filter.Parse(`firstName = "foo"`)
+			Expect(err).To(MatchError("unknown operator '=' at position 11"))
+
+			_, err = filter.Parse(`(campusId == 1`)
+			Expect(err).To(MatchError("expected ')' but found end of expression at position 15"))
+
+			_, err = filter.Parse(`firstName == "foo`)
+			Expect(err).To(MatchError("unterminated string at position 14"))
+
+			_, err = filter.Parse(`unknown(firstName)`)
+			Expect(err).To(MatchError("unknown function 'unknown' at position 1"))
+
+			_, err = filter.Parse(`campusId == 1 campusId`)
+			Expect(err).To(MatchError("unexpected 'campusId' at position 15"))
+		})
+	})
+})
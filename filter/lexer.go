@@ -0,0 +1,163 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdentifier
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+	tokenLeftBracket
+	tokenRightBracket
+	tokenComma
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("'%s'", t.value)
+}
+
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expression)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLeftParen, value: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRightParen, value: ")", pos: i})
+			i++
+		case r == '[':
+			tokens = append(tokens, token{kind: tokenLeftBracket, value: "[", pos: i})
+			i++
+		case r == ']':
+			tokens = append(tokens, token{kind: tokenRightBracket, value: "]", pos: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, value: ",", pos: i})
+			i++
+
+		case r == '"' || r == '\'':
+			start := i
+			var value strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				value.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start+1)
+			}
+			tokens = append(tokens, token{kind: tokenString, value: value.String(), pos: start})
+			i++
+
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]) && expectsOperand(tokens)):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, value: string(runes[start:i]), pos: start})
+
+		case strings.ContainsRune("=!<>&|", r):
+			start := i
+			operator := string(r)
+			if i+1 < len(runes) && strings.ContainsRune("=&|", runes[i+1]) {
+				operator += string(runes[i+1])
+			}
+			switch operator {
+			case "==", "!=", "<", "<=", ">", ">=", "!", "&&", "||":
+			default:
+				return nil, fmt.Errorf("unknown operator '%s' at position %d", operator, start+1)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, value: operator, pos: start})
+			i += len(operator)
+
+		case isIdentifierRune(r):
+			start := i
+			i = scanPath(runes, i)
+			tokens = append(tokens, token{kind: tokenIdentifier, value: string(runes[start:i]), pos: start})
+
+		default:
+			return nil, fmt.Errorf("unexpected character '%c' at position %d", r, i+1)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+func isIdentifierRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// scanPath returns the end of a field path like
+// 'relationship.parent[0].firstName' starting at position i.
+func scanPath(runes []rune, i int) int {
+	for i < len(runes) {
+		switch {
+		case isIdentifierRune(runes[i]):
+			i++
+		case runes[i] == '.' && i+1 < len(runes) && isIdentifierRune(runes[i+1]):
+			i++
+		case runes[i] == '[':
+			end := i + 1
+			for end < len(runes) && unicode.IsDigit(runes[end]) {
+				end++
+			}
+			if end == i+1 || end >= len(runes) || runes[end] != ']' {
+				return i
+			}
+			i = end + 1
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func expectsOperand(tokens []token) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+	switch tokens[len(tokens)-1].kind {
+	case tokenOperator, tokenLeftParen, tokenLeftBracket, tokenComma:
+		return true
+	case tokenIdentifier:
+		return isKeyword(tokens[len(tokens)-1].value)
+	}
+	return false
+}
+
+func isKeyword(value string) bool {
+	switch strings.ToLower(value) {
+	case "and", "or", "not", "in":
+		return true
+	}
+	return false
+}
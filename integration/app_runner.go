@@ -6,24 +6,31 @@ import (
 	"ctRestClient/csv"
 	"ctRestClient/data_provider"
 	"ctRestClient/logger"
+	"ctRestClient/secret"
 	"path/filepath"
 )
 
 // RunApplicationWrapper wraps the main application logic for integration testing
-func RunApplicationWrapper(config *config.Config, rootDir string, dataDir string, keepassDbFilePath string, keepassDbPassword string, appLogger logger.Logger) error {
-	keepassCli, err := app.NewKeepassCli(keepassDbFilePath, keepassDbPassword, appLogger)
-	if err != nil {
-		return err
-	}
+func RunApplicationWrapper(config *config.Config, rootDir string, dataDir string, keepassDbFilePath string, keepassDbPassword string, appLogger logger.Logger) (app.RunReport, error) {
+	stores := secret.NewStores(secret.StoresOptions{
+		Keepass: func() (secret.Store, error) {
+			keepassCli, err := app.NewKeepassReader(keepassDbFilePath, keepassDbPassword, "", appLogger)
+			if err != nil {
+				return nil, err
+			}
+			return secret.StoreFunc(keepassCli.GetPassword), nil
+		},
+	})
 	return app.NewInstancesProcessor(
 		*config,
 		appLogger,
+		app.ProcessorOptions{},
 	).Process(
 		app.NewGroupExporter(),
 		csv.NewCSVFileWriter(),
 		rootDir,
 		data_provider.NewFileDataProvider(filepath.Join(dataDir, "mappings/persons")),
 		data_provider.NewBlockListDataProvider(filepath.Join(dataDir, "blocklists"), appLogger),
-		keepassCli,
+		stores,
 	)
 }
@@ -1,6 +1,7 @@
 package integration
 
 import (
+	"ctRestClient/app"
 	"ctRestClient/config"
 	"ctRestClient/logger"
 	"ctRestClient/testutil"
@@ -103,7 +104,12 @@ var _ = Describe("ChurchTools Integration Test with HTTP Server", func() {
 			cfg, err := config.LoadConfig(configPath)
 			Expect(err).ToNot(HaveOccurred())
 
-			err = RunApplicationWrapper(cfg, outputDir, dataDir, keepassDbPath, "abcd1234", appLogger)
+			report, err := RunApplicationWrapper(cfg, outputDir, dataDir, keepassDbPath, "abcd1234", appLogger)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.Status).To(Equal(app.StatusSucceeded))
+
+			// Check if the run report was created
+			_, err = os.Stat(filepath.Join(outputDir, app.RunReportFileName))
 			Expect(err).ToNot(HaveOccurred())
 
 			// Check if log file was created
@@ -153,7 +159,7 @@ var _ = Describe("ChurchTools Integration Test with HTTP Server", func() {
 			cfg, err := config.LoadConfig(configPath)
 			Expect(err).ToNot(HaveOccurred())
 
-			err = RunApplicationWrapper(cfg, outputDir, dataDir, keepassDbPath, "abcd1234", appLogger)
+			_, err = RunApplicationWrapper(cfg, outputDir, dataDir, keepassDbPath, "abcd1234", appLogger)
 			Expect(err).ToNot(HaveOccurred())
 
 			// Check if log file was created
@@ -204,7 +210,7 @@ var _ = Describe("ChurchTools Integration Test with HTTP Server", func() {
 			cfg, err := config.LoadConfig(configPath)
 			Expect(err).ToNot(HaveOccurred())
 
-			err = RunApplicationWrapper(cfg, outputDir, dataDir, keepassDbPath, "abcd1234", appLogger)
+			_, err = RunApplicationWrapper(cfg, outputDir, dataDir, keepassDbPath, "abcd1234", appLogger)
 			Expect(err).ToNot(HaveOccurred())
 
 			// Check if log file was created
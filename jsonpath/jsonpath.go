@@ -0,0 +1,127 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Lookup returns the value of a field of a json object. Nested values are
+// addressed by a path like 'member.memberStartDate' or
+// 'relationship.parent[0].firstName'. A key that exists literally in the
+// object is preferred over a nested lookup. If a list is addressed by a key
+// like in 'relationship.spouse.email', the first element of the list is used.
+func Lookup(object map[string]json.RawMessage, path string) (json.RawMessage, bool) {
+	if value, exists := object[path]; exists {
+		return value, true
+	}
+
+	segments, ok := parse(path)
+	if !ok || (len(segments) == 1 && len(segments[0].indices) == 0) {
+		return nil, false
+	}
+
+	value, exists := object[segments[0].key]
+	if !exists {
+		return nil, false
+	}
+	value, ok = resolveIndices(value, segments[0].indices)
+	if !ok {
+		return nil, false
+	}
+
+	for _, segment := range segments[1:] {
+		nested, ok := asObject(value)
+		if !ok {
+			return nil, false
+		}
+		value, exists = nested[segment.key]
+		if !exists {
+			return nil, false
+		}
+		value, ok = resolveIndices(value, segment.indices)
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// Namespace returns the first segment of a path, e.g. 'member' for
+// 'member.memberStartDate'.
+func Namespace(path string) string {
+	namespace, _, found := strings.Cut(path, ".")
+	if !found {
+		return ""
+	}
+	namespace, _, _ = strings.Cut(namespace, "[")
+	return namespace
+}
+
+type segment struct {
+	key     string
+	indices []int
+}
+
+func parse(path string) ([]segment, bool) {
+	var segments []segment
+
+	for _, part := range strings.Split(path, ".") {
+		key, rest, _ := strings.Cut(part, "[")
+		if key == "" {
+			return nil, false
+		}
+
+		seg := segment{key: key}
+		for rest != "" {
+			index, remainder, found := strings.Cut(rest, "]")
+			if !found {
+				return nil, false
+			}
+			i, err := strconv.Atoi(index)
+			if err != nil || i < 0 {
+				return nil, false
+			}
+			seg.indices = append(seg.indices, i)
+
+			if remainder == "" {
+				break
+			}
+			if !strings.HasPrefix(remainder, "[") {
+				return nil, false
+			}
+			rest = remainder[1:]
+		}
+		segments = append(segments, seg)
+	}
+
+	return segments, true
+}
+
+func asObject(value json.RawMessage) (map[string]json.RawMessage, bool) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(value, &object); err == nil {
+		return object, object != nil
+	}
+
+	var list []json.RawMessage
+	if err := json.Unmarshal(value, &list); err != nil || len(list) == 0 {
+		return nil, false
+	}
+	return asObject(list[0])
+}
+
+func resolveIndices(value json.RawMessage, indices []int) (json.RawMessage, bool) {
+	for _, index := range indices {
+		var list []json.RawMessage
+		if err := json.Unmarshal(value, &list); err != nil {
+			return nil, false
+		}
+		if index >= len(list) {
+			return nil, false
+		}
+		value = list[index]
+	}
+	return value, true
+}
@@ -0,0 +1,101 @@
+package jsonpath_test
+
+import (
+	"ctRestClient/jsonpath"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JSONPath", func() {
+
+	var (
+		person map[string]json.RawMessage
+	)
+
+	BeforeEach(func() {
+		err := json.Unmarshal([]byte(`{
+			"id": 1,
+			"firstName": "foo",
+			"member.legacy": "literal",
+			"member": {
+				"groupTypeRoleId": 16,
+				"fields": {"allergies": "nuts"}
+			},
+			"relationship": {
+				"parent": [
+					{"firstName": "mother"},
+					{"firstName": "father"}
+				],
+				"matrix": [[1, 2], [3, 4]]
+			}
+		}`), &person)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	var _ = Describe("Lookup", func() {
+		It("returns top level values", func() {
+			value, exists := jsonpath.Lookup(person, "firstName")
+			Expect(exists).To(BeTrue())
+			Expect(value).To(MatchJSON(`"foo"`))
+		})
+
+		It("prefers literal keys", func() {
+			value, exists := jsonpath.Lookup(person, "member.legacy")
+			Expect(exists).To(BeTrue())
+			Expect(value).To(MatchJSON(`"literal"`))
+		})
+
+		It("returns nested values", func() {
+			value, exists := jsonpath.Lookup(person, "member.groupTypeRoleId")
+			Expect(exists).To(BeTrue())
+			Expect(value).To(MatchJSON(`16`))
+
+			value, exists = jsonpath.Lookup(person, "member.fields.allergies")
+			Expect(exists).To(BeTrue())
+			Expect(value).To(MatchJSON(`"nuts"`))
+		})
+
+		It("returns values of lists", func() {
+			value, exists := jsonpath.Lookup(person, "relationship.parent[1].firstName")
+			Expect(exists).To(BeTrue())
+			Expect(value).To(MatchJSON(`"father"`))
+
+			value, exists = jsonpath.Lookup(person, "relationship.matrix[1][0]")
+			Expect(exists).To(BeTrue())
+			Expect(value).To(MatchJSON(`3`))
+		})
+
+		It("uses the first element of lists addressed by a key", func() {
+			value, exists := jsonpath.Lookup(person, "relationship.parent.firstName")
+			Expect(exists).To(BeTrue())
+			Expect(value).To(MatchJSON(`"mother"`))
+		})
+
+		It("returns false for unknown paths", func() {
+			_, exists := jsonpath.Lookup(person, "unknown")
+			Expect(exists).To(BeFalse())
+
+			_, exists = jsonpath.Lookup(person, "member.unknown")
+			Expect(exists).To(BeFalse())
+
+			_, exists = jsonpath.Lookup(person, "firstName.unknown")
+			Expect(exists).To(BeFalse())
+
+			_, exists = jsonpath.Lookup(person, "relationship.parent[2].firstName")
+			Expect(exists).To(BeFalse())
+
+			_, exists = jsonpath.Lookup(person, "relationship.parent[x]")
+			Expect(exists).To(BeFalse())
+		})
+	})
+
+	var _ = Describe("Namespace", func() {
+		It("returns the first path segment", func() {
+			Expect(jsonpath.Namespace("member.groupTypeRoleId")).To(Equal("member"))
+			Expect(jsonpath.Namespace("relationship.parent[0].firstName")).To(Equal("relationship"))
+			Expect(jsonpath.Namespace("firstName")).To(Equal(""))
+		})
+	})
+})
@@ -0,0 +1,281 @@
+package kdbx
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"sync"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// The Argon2 types, golang.org/x/crypto/argon2 only provides Argon2i and
+// Argon2id but KeePass uses Argon2d by default.
+const (
+	argon2d  = 0
+	argon2i  = 1
+	argon2id = 2
+)
+
+// The Argon2 versions 1.0 and 1.3.
+const (
+	argon2Version10 = 0x10
+	argon2Version13 = 0x13
+)
+
+const (
+	argon2BlockWords = 128
+	argon2SyncPoints = 4
+)
+
+type argon2Block [argon2BlockWords]uint64
+
+// argon2Params are the parameters of Argon2 as defined by RFC 9106.
+type argon2Params struct {
+	mode        int
+	version     uint32
+	password    []byte
+	salt        []byte
+	secret      []byte
+	data        []byte
+	iterations  uint32
+	memoryKiB   uint32
+	parallelism uint32
+	keyLength   uint32
+}
+
+// argon2Key derives a key with Argon2 as defined by RFC 9106.
+func argon2Key(p argon2Params) []byte {
+	h0 := argon2InitialHash(p)
+
+	memory := p.memoryKiB / (argon2SyncPoints * p.parallelism) * (argon2SyncPoints * p.parallelism)
+	if memory < 2*argon2SyncPoints*p.parallelism {
+		memory = 2 * argon2SyncPoints * p.parallelism
+	}
+	laneLength := memory / p.parallelism
+	segmentLength := laneLength / argon2SyncPoints
+
+	blocks := make([]argon2Block, memory)
+	for lane := uint32(0); lane < p.parallelism; lane++ {
+		for i := uint32(0); i < 2; i++ {
+			input := make([]byte, 0, len(h0)+8)
+			input = append(input, h0[:]...)
+			input = binary.LittleEndian.AppendUint32(input, i)
+			input = binary.LittleEndian.AppendUint32(input, lane)
+			var buffer [1024]byte
+			argon2Hash(buffer[:], input)
+			for j := range blocks[lane*laneLength+i] {
+				blocks[lane*laneLength+i][j] = binary.LittleEndian.Uint64(buffer[j*8:])
+			}
+		}
+	}
+
+	for pass := uint32(0); pass < p.iterations; pass++ {
+		for slice := uint32(0); slice < argon2SyncPoints; slice++ {
+			var wg sync.WaitGroup
+			for lane := uint32(0); lane < p.parallelism; lane++ {
+				wg.Add(1)
+				go func(lane uint32) {
+					defer wg.Done()
+					argon2FillSegment(blocks, p, memory, laneLength, segmentLength, pass, slice, lane)
+				}(lane)
+			}
+			wg.Wait()
+		}
+	}
+
+	final := blocks[memory-1]
+	for lane := uint32(0); lane < p.parallelism-1; lane++ {
+		for i, word := range blocks[lane*laneLength+laneLength-1] {
+			final[i] ^= word
+		}
+	}
+	var buffer [1024]byte
+	for i, word := range final {
+		binary.LittleEndian.PutUint64(buffer[i*8:], word)
+	}
+	key := make([]byte, p.keyLength)
+	argon2Hash(key, buffer[:])
+	return key
+}
+
+func argon2InitialHash(p argon2Params) [blake2b.Size]byte {
+	input := make([]byte, 0, 40+len(p.password)+len(p.salt)+len(p.secret)+len(p.data))
+	for _, value := range []uint32{p.parallelism, p.keyLength, p.memoryKiB, p.iterations, p.version, uint32(p.mode)} {
+		input = binary.LittleEndian.AppendUint32(input, value)
+	}
+	for _, value := range [][]byte{p.password, p.salt, p.secret, p.data} {
+		input = binary.LittleEndian.AppendUint32(input, uint32(len(value)))
+		input = append(input, value...)
+	}
+	return blake2b.Sum512(input)
+}
+
+func argon2FillSegment(blocks []argon2Block, p argon2Params, memory, laneLength, segmentLength, pass, slice, lane uint32) {
+	dataIndependent := p.mode == argon2i || (p.mode == argon2id && pass == 0 && slice < argon2SyncPoints/2)
+
+	var addresses, input, zero argon2Block
+	if dataIndependent {
+		input[0] = uint64(pass)
+		input[1] = uint64(lane)
+		input[2] = uint64(slice)
+		input[3] = uint64(memory)
+		input[4] = uint64(p.iterations)
+		input[5] = uint64(p.mode)
+	}
+
+	index := uint32(0)
+	if pass == 0 && slice == 0 {
+		// the first two blocks of each lane are already initialized
+		index = 2
+		if dataIndependent {
+			input[6]++
+			argon2Compress(&addresses, &zero, &input, false)
+			argon2Compress(&addresses, &zero, &addresses, false)
+		}
+	}
+
+	offset := lane*laneLength + slice*segmentLength + index
+	for ; index < segmentLength; index, offset = index+1, offset+1 {
+		previous := offset - 1
+		if index == 0 && slice == 0 {
+			previous += laneLength
+		}
+
+		var random uint64
+		if dataIndependent {
+			if index%argon2BlockWords == 0 {
+				input[6]++
+				argon2Compress(&addresses, &zero, &input, false)
+				argon2Compress(&addresses, &zero, &addresses, false)
+			}
+			random = addresses[index%argon2BlockWords]
+		} else {
+			random = blocks[previous][0]
+		}
+
+		reference := argon2ReferenceIndex(random, p.parallelism, laneLength, segmentLength, pass, slice, lane, index)
+		xor := pass > 0 && p.version == argon2Version13
+		argon2Compress(&blocks[offset], &blocks[previous], &blocks[reference], xor)
+	}
+}
+
+// argon2ReferenceIndex maps the pseudo random value to the index of the
+// reference block, see RFC 9106 section 3.4.
+func argon2ReferenceIndex(random uint64, lanes, laneLength, segmentLength, pass, slice, lane, index uint32) uint32 {
+	referenceLane := uint32(random>>32) % lanes
+	if pass == 0 && slice == 0 {
+		referenceLane = lane
+	}
+	sameLane := referenceLane == lane
+
+	var areaSize, start uint32
+	if pass == 0 {
+		areaSize = slice * segmentLength
+		if sameLane {
+			areaSize += index - 1
+		} else if index == 0 {
+			areaSize--
+		}
+	} else {
+		start = ((slice + 1) % argon2SyncPoints) * segmentLength
+		areaSize = laneLength - segmentLength
+		if sameLane {
+			areaSize += index - 1
+		} else if index == 0 {
+			areaSize--
+		}
+	}
+
+	x := (random & 0xFFFFFFFF) * (random & 0xFFFFFFFF) >> 32
+	y := uint64(areaSize) * x >> 32
+	relative := uint64(areaSize) - 1 - y
+	return referenceLane*laneLength + uint32((uint64(start)+relative)%uint64(laneLength))
+}
+
+// argon2Compress is the compression function G, with xor the result is
+// combined with the previous content of out.
+func argon2Compress(out, x, y *argon2Block, xor bool) {
+	var r, q argon2Block
+	for i := range r {
+		r[i] = x[i] ^ y[i]
+	}
+	q = r
+
+	// the block consists of 8x8 registers of 16 bytes, i.e. two words, the
+	// permutation is applied to each row and then to each column
+	for row := 0; row < 8; row++ {
+		var indexes [16]int
+		for i := range indexes {
+			indexes[i] = row*16 + i
+		}
+		argon2Permute(&q, indexes)
+	}
+	for column := 0; column < 8; column++ {
+		var indexes [16]int
+		for i := 0; i < 8; i++ {
+			indexes[2*i] = i*16 + 2*column
+			indexes[2*i+1] = i*16 + 2*column + 1
+		}
+		argon2Permute(&q, indexes)
+	}
+
+	for i := range out {
+		if xor {
+			out[i] ^= q[i] ^ r[i]
+		} else {
+			out[i] = q[i] ^ r[i]
+		}
+	}
+}
+
+// argon2Permute is the permutation P, a BLAKE2b round with multiplications.
+func argon2Permute(b *argon2Block, indexes [16]int) {
+	v := func(i int) *uint64 { return &b[indexes[i]] }
+	argon2Mix(v(0), v(4), v(8), v(12))
+	argon2Mix(v(1), v(5), v(9), v(13))
+	argon2Mix(v(2), v(6), v(10), v(14))
+	argon2Mix(v(3), v(7), v(11), v(15))
+	argon2Mix(v(0), v(5), v(10), v(15))
+	argon2Mix(v(1), v(6), v(11), v(12))
+	argon2Mix(v(2), v(7), v(8), v(13))
+	argon2Mix(v(3), v(4), v(9), v(14))
+}
+
+func argon2Mix(a, b, c, d *uint64) {
+	blaMka := func(x, y uint64) uint64 {
+		return x + y + 2*uint64(uint32(x))*uint64(uint32(y))
+	}
+	*a = blaMka(*a, *b)
+	*d = bits.RotateLeft64(*d^*a, -32)
+	*c = blaMka(*c, *d)
+	*b = bits.RotateLeft64(*b^*c, -24)
+	*a = blaMka(*a, *b)
+	*d = bits.RotateLeft64(*d^*a, -16)
+	*c = blaMka(*c, *d)
+	*b = bits.RotateLeft64(*b^*c, -63)
+}
+
+// argon2Hash is the variable length hash function H' filling out.
+func argon2Hash(out []byte, input []byte) {
+	prefixed := binary.LittleEndian.AppendUint32(nil, uint32(len(out)))
+	prefixed = append(prefixed, input...)
+
+	if len(out) <= blake2b.Size {
+		hash, _ := blake2b.New(len(out), nil)
+		hash.Write(prefixed)
+		hash.Sum(out[:0])
+		return
+	}
+
+	v := blake2b.Sum512(prefixed)
+	copy(out, v[:32])
+	position := 32
+	for len(out)-position > blake2b.Size {
+		v = blake2b.Sum512(v[:])
+		copy(out[position:], v[:32])
+		position += 32
+	}
+	hash, _ := blake2b.New(len(out)-position, nil)
+	hash.Write(v[:])
+	hash.Sum(out[position:position])
+}
@@ -0,0 +1,31 @@
+package kdbx_test
+
+import (
+	"bytes"
+	"ctRestClient/kdbx"
+	"encoding/hex"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Argon2", func() {
+	// the test vectors of RFC 9106 section 5
+	DescribeTable("derives the key of the test vector",
+		func(mode int, expected string) {
+			key := kdbx.Argon2Key(
+				mode,
+				bytes.Repeat([]byte{0x01}, 32),
+				bytes.Repeat([]byte{0x02}, 16),
+				bytes.Repeat([]byte{0x03}, 8),
+				bytes.Repeat([]byte{0x04}, 12),
+				3, 32, 4,
+			)
+
+			Expect(hex.EncodeToString(key)).To(Equal(expected))
+		},
+		Entry("Argon2d", kdbx.Argon2d, "512b391b6f1162975371d30919734294f868e3be3984f3c1a13a4db9fabe4acb"),
+		Entry("Argon2i", kdbx.Argon2i, "c814d9d1dc7f37aa13f0d77f2494bda1c8de6b016dd388d29952a4c4672b6ce8"),
+		Entry("Argon2id", kdbx.Argon2id, "0d640df58d78766c08c037a34a8b53c9d01ef0452d75b65eb52520e96b01e659"),
+	)
+})
@@ -0,0 +1,340 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/salsa20/salsa"
+)
+
+// The inner random streams encrypting the protected values.
+const (
+	innerStreamNone     = 0
+	innerStreamSalsa20  = 2
+	innerStreamChaCha20 = 3
+)
+
+// salsa20Nonce is the fixed nonce of the Salsa20 inner random stream.
+var salsa20Nonce = []byte{0xE8, 0x30, 0x09, 0x4B, 0x97, 0x20, 0x5D, 0x2A}
+
+// innerStream decrypts the protected values, which are encrypted with a
+// single key stream in the order of the document.
+type innerStream func(dst, src []byte)
+
+func newInnerStream(id uint32, key []byte) (innerStream, error) {
+	switch id {
+	case innerStreamNone:
+		return func(dst, src []byte) { copy(dst, src) }, nil
+	case innerStreamSalsa20:
+		return newSalsa20Stream(key).XORKeyStream, nil
+	case innerStreamChaCha20:
+		hash := sha512.Sum512(key)
+		stream, err := chacha20.NewUnauthenticatedCipher(hash[:32], hash[32:44])
+		if err != nil {
+			return nil, err
+		}
+		return stream.XORKeyStream, nil
+	default:
+		return nil, fmt.Errorf("the inner random stream %d is not supported", id)
+	}
+}
+
+// salsa20Stream is the Salsa20 key stream, it continues where the previous
+// call of XORKeyStream stopped like the ChaCha20 cipher.
+type salsa20Stream struct {
+	key [32]byte
+	// counter is the nonce followed by the little endian block counter
+	counter [16]byte
+	block   [64]byte
+	// used is the number of bytes of the block that are already used
+	used int
+}
+
+func newSalsa20Stream(key []byte) *salsa20Stream {
+	s := &salsa20Stream{key: sha256.Sum256(key)}
+	copy(s.counter[:], salsa20Nonce)
+	s.used = len(s.block)
+	return s
+}
+
+func (s *salsa20Stream) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if s.used == len(s.block) {
+			s.block = [64]byte{}
+			salsa.XORKeyStream(s.block[:], s.block[:], &s.counter, &s.key)
+			binary.LittleEndian.PutUint64(s.counter[8:], binary.LittleEndian.Uint64(s.counter[8:])+1)
+			s.used = 0
+		}
+		dst[i] = src[i] ^ s.block[s.used]
+		s.used++
+	}
+}
+
+// Database is a decrypted KeePass database.
+type Database struct {
+	entries []*Entry
+}
+
+// Entry is an entry of the database. Entries in the recycle bin and the
+// history of entries are not included.
+type Entry struct {
+	// Path is the path of the entry relative to the root group, e.g.
+	// 'Tokens/ChurchTools/example.church.tools'.
+	Path   string
+	Fields map[string]string
+}
+
+// Title returns the title of the entry.
+func (e *Entry) Title() string {
+	return e.Fields["Title"]
+}
+
+// Password returns the password of the entry.
+func (e *Entry) Password() string {
+	return e.Fields["Password"]
+}
+
+// Entries returns all entries of the database in the order of the document.
+func (d *Database) Entries() []*Entry {
+	return d.entries
+}
+
+// Entry returns the entry with the path, e.g. 'Tokens/example.church.tools'.
+// A name without a group may also address the entry with this title in any
+// group if the title is unique.
+func (d *Database) Entry(path string) (*Entry, error) {
+	path = strings.Trim(path, "/")
+
+	var matches []*Entry
+	for _, entry := range d.entries {
+		if entry.Path == path {
+			matches = append(matches, entry)
+		}
+	}
+	if len(matches) == 0 && !strings.Contains(path, "/") {
+		for _, entry := range d.entries {
+			if entry.Title() == path {
+				matches = append(matches, entry)
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("the entry '%s' could not be found", path)
+	case 1:
+		return matches[0], nil
+	default:
+		paths := make([]string, len(matches))
+		for i, match := range matches {
+			paths[i] = match.Path
+		}
+		return nil, fmt.Errorf("the entry '%s' is ambiguous, it matches %s", path, strings.Join(paths, ", "))
+	}
+}
+
+type group struct {
+	uuid   string
+	name   string
+	parent *group
+}
+
+// path returns the path of the group relative to the root group.
+func (g *group) path() []string {
+	if g == nil || g.parent == nil {
+		return nil
+	}
+	return append(g.parent.path(), g.name)
+}
+
+func (g *group) isInside(uuid string) bool {
+	for current := g; current != nil; current = current.parent {
+		if current.uuid == uuid {
+			return true
+		}
+	}
+	return false
+}
+
+// protectedValue is an encrypted value that is decrypted after the whole
+// document is read.
+type protectedValue struct {
+	entry      *Entry
+	key        string
+	ciphertext []byte
+}
+
+// emptyUUID is the UUID of a disabled recycle bin.
+const emptyUUID = "AAAAAAAAAAAAAAAAAAAAAA=="
+
+type entryBuilder struct {
+	entry     *Entry
+	group     *group
+	inHistory bool
+}
+
+type element struct {
+	name      string
+	protected bool
+}
+
+func parseXML(content []byte, stream innerStream) (*Database, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+
+	var (
+		elements        []element
+		groups          []*group
+		entries         []*entryBuilder
+		entryStack      []*entryBuilder
+		historyDepth    int
+		recycleBinUUID  string
+		fieldKey        string
+		fieldValue      string
+		fieldProtected  bool
+		text            strings.Builder
+		protectedValues []protectedValue
+	)
+
+	parent := func() string {
+		if len(elements) < 2 {
+			return ""
+		}
+		return elements[len(elements)-2].name
+	}
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the database content, %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			current := element{name: t.Name.Local}
+			for _, attribute := range t.Attr {
+				if attribute.Name.Local == "Protected" && strings.EqualFold(attribute.Value, "True") {
+					current.protected = true
+				}
+			}
+			elements = append(elements, current)
+			text.Reset()
+
+			switch t.Name.Local {
+			case "Group":
+				var parentGroup *group
+				if len(groups) > 0 {
+					parentGroup = groups[len(groups)-1]
+				}
+				groups = append(groups, &group{parent: parentGroup})
+			case "History":
+				historyDepth++
+			case "Entry":
+				if len(groups) == 0 {
+					return nil, errors.New("the database content contains an entry outside of a group")
+				}
+				builder := &entryBuilder{
+					entry:     &Entry{Fields: make(map[string]string)},
+					group:     groups[len(groups)-1],
+					inHistory: historyDepth > 0,
+				}
+				entries = append(entries, builder)
+				entryStack = append(entryStack, builder)
+			case "String":
+				fieldKey, fieldValue, fieldProtected = "", "", false
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			current := elements[len(elements)-1]
+
+			// every protected value is part of the key stream, including the
+			// values of history entries and protected binaries of KDBX 3.1
+			if current.protected {
+				ciphertext, err := base64.StdEncoding.DecodeString(text.String())
+				if err != nil {
+					return nil, fmt.Errorf("invalid protected value of '%s', %w", fieldKey, err)
+				}
+				value := protectedValue{ciphertext: ciphertext}
+				if current.name == "Value" && parent() == "String" && len(entryStack) > 0 && !entryStack[len(entryStack)-1].inHistory {
+					value.entry = entryStack[len(entryStack)-1].entry
+					value.key = fieldKey
+				}
+				protectedValues = append(protectedValues, value)
+			}
+
+			switch current.name {
+			case "RecycleBinUUID":
+				if parent() == "Meta" {
+					recycleBinUUID = text.String()
+				}
+			case "UUID":
+				if parent() == "Group" {
+					groups[len(groups)-1].uuid = text.String()
+				}
+			case "Name":
+				if parent() == "Group" {
+					groups[len(groups)-1].name = text.String()
+				}
+			case "Key":
+				if parent() == "String" {
+					fieldKey = text.String()
+				}
+			case "Value":
+				if parent() == "String" {
+					fieldValue = text.String()
+					fieldProtected = current.protected
+				}
+			case "String":
+				if parent() == "Entry" && len(entryStack) > 0 && !fieldProtected {
+					entryStack[len(entryStack)-1].entry.Fields[fieldKey] = fieldValue
+				}
+			case "Entry":
+				entryStack = entryStack[:len(entryStack)-1]
+			case "History":
+				historyDepth--
+			case "Group":
+				groups = groups[:len(groups)-1]
+			}
+			elements = elements[:len(elements)-1]
+			text.Reset()
+		}
+	}
+
+	var ciphertext []byte
+	for _, value := range protectedValues {
+		ciphertext = append(ciphertext, value.ciphertext...)
+	}
+	plaintext := make([]byte, len(ciphertext))
+	stream(plaintext, ciphertext)
+	for _, value := range protectedValues {
+		if value.entry != nil {
+			value.entry.Fields[value.key] = string(plaintext[:len(value.ciphertext)])
+		}
+		plaintext = plaintext[len(value.ciphertext):]
+	}
+
+	database := &Database{}
+	for _, builder := range entries {
+		if builder.inHistory {
+			continue
+		}
+		if recycleBinUUID != "" && recycleBinUUID != emptyUUID && builder.group.isInside(recycleBinUUID) {
+			continue
+		}
+		builder.entry.Path = strings.Join(append(builder.group.path(), builder.entry.Title()), "/")
+		database.entries = append(database.entries, builder.entry)
+	}
+	return database, nil
+}
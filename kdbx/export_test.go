@@ -0,0 +1,361 @@
+package kdbx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"regexp"
+
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/salsa20"
+	"golang.org/x/crypto/twofish"
+
+	. "github.com/onsi/gomega"
+)
+
+var KeyFileKey = keyFileKey
+
+// The inner random streams for NewInnerStream.
+const (
+	InnerStreamSalsa20  = innerStreamSalsa20
+	InnerStreamChaCha20 = innerStreamChaCha20
+)
+
+// NewInnerStream returns the XORKeyStream function of the inner random
+// stream.
+func NewInnerStream(id uint32, key []byte) (func(dst, src []byte), error) {
+	return newInnerStream(id, key)
+}
+
+// The Argon2 types for Argon2Key.
+const (
+	Argon2d  = argon2d
+	Argon2i  = argon2i
+	Argon2id = argon2id
+)
+
+func Argon2Key(mode int, password, salt, secret, data []byte, iterations, memoryKiB, parallelism uint32) []byte {
+	return argon2Key(argon2Params{
+		mode:        mode,
+		version:     argon2Version13,
+		password:    password,
+		salt:        salt,
+		secret:      secret,
+		data:        data,
+		iterations:  iterations,
+		memoryKiB:   memoryKiB,
+		parallelism: parallelism,
+		keyLength:   32,
+	})
+}
+
+// TestDatabase describes a database written by EncodeTestDatabase, the
+// writer only exists to test the reader with all supported formats.
+type TestDatabase struct {
+	// MajorVersion is 3 for KDBX 3.1 or 4 for KDBX 4.
+	MajorVersion uint16
+	// Cipher is AES, ChaCha20 or Twofish.
+	Cipher string
+	// KDF is AES, Argon2d or Argon2id, KDBX 3.1 always uses AES.
+	KDF string
+	// InnerStream is Salsa20 or ChaCha20.
+	InnerStream string
+	Compressed  bool
+	Password    string
+	KeyFile     []byte
+	// Content is the XML document, values with the attribute
+	// Protected="True" are given in plain text and encrypted by the writer.
+	Content string
+}
+
+var testCiphers = map[string][]byte{"AES": cipherAES256, "ChaCha20": cipherChaCha20, "Twofish": cipherTwofish}
+
+var testInnerStreams = map[string]uint32{"Salsa20": innerStreamSalsa20, "ChaCha20": innerStreamChaCha20}
+
+func testKdfParameters(kdf string) variantDictionary {
+	if kdf == "AES" {
+		return variantDictionary{
+			"$UUID": kdfAES,
+			"S":     randomBytes(32),
+			"R":     binary.LittleEndian.AppendUint64(nil, 100),
+		}
+	}
+
+	uuid := kdfArgon2d
+	if kdf == "Argon2id" {
+		uuid = kdfArgon2id
+	}
+	return variantDictionary{
+		"$UUID": uuid,
+		"S":     randomBytes(32),
+		"I":     binary.LittleEndian.AppendUint64(nil, 2),
+		"M":     binary.LittleEndian.AppendUint64(nil, 64*1024),
+		"P":     binary.LittleEndian.AppendUint32(nil, 2),
+		"V":     binary.LittleEndian.AppendUint32(nil, argon2Version13),
+	}
+}
+
+var protectedValuePattern = regexp.MustCompile(`Protected="True">([^<]*)<`)
+
+func EncodeTestDatabase(db TestDatabase) []byte {
+	innerStream := testInnerStreams[db.InnerStream]
+	streamKey := randomBytes(64)
+	content := encryptProtectedValues(db.Content, innerStream, streamKey)
+
+	h := header{
+		majorVersion: db.MajorVersion,
+		cipherID:     testCiphers[db.Cipher],
+		compressed:   db.Compressed,
+		masterSeed:   randomBytes(32),
+	}
+	if db.MajorVersion == 4 {
+		h.kdfParameters = testKdfParameters(db.KDF)
+	}
+	if db.Cipher == "ChaCha20" {
+		h.encryptionIV = randomBytes(12)
+	} else {
+		h.encryptionIV = randomBytes(16)
+	}
+	if db.MajorVersion == 3 {
+		h.transformSeed = randomBytes(32)
+		h.transformRounds = 100
+		h.protectedStreamKey = streamKey
+		h.streamStartBytes = randomBytes(32)
+		h.innerRandomStreamID = innerStream
+	}
+	h.raw = writeHeader(h)
+
+	key, err := compositeKey(db.Password, db.KeyFile)
+	Expect(err).NotTo(HaveOccurred())
+	transformedKey, err := transformKey(h, key)
+	Expect(err).NotTo(HaveOccurred())
+	masterKey := sha256.Sum256(concat(h.masterSeed, transformedKey))
+
+	if db.MajorVersion == 3 {
+		payload := compressIf(db.Compressed, []byte(content))
+		blocks := binary.LittleEndian.AppendUint32(nil, 0)
+		hash := sha256.Sum256(payload)
+		blocks = append(blocks, hash[:]...)
+		blocks = binary.LittleEndian.AppendUint32(blocks, uint32(len(payload)))
+		blocks = append(blocks, payload...)
+		blocks = binary.LittleEndian.AppendUint32(blocks, 1)
+		blocks = append(blocks, make([]byte, 32)...)
+		blocks = binary.LittleEndian.AppendUint32(blocks, 0)
+
+		return concat(h.raw, encrypt(h.cipherID, masterKey[:], h.encryptionIV, concat(h.streamStartBytes, blocks)))
+	}
+
+	inner := []byte{innerHeaderStreamID, 4, 0, 0, 0}
+	inner = binary.LittleEndian.AppendUint32(inner, innerStream)
+	inner = append(inner, innerHeaderStreamKey)
+	inner = binary.LittleEndian.AppendUint32(inner, uint32(len(streamKey)))
+	inner = append(inner, streamKey...)
+	inner = append(inner, innerHeaderEnd, 0, 0, 0, 0)
+	payload := encrypt(h.cipherID, masterKey[:], h.encryptionIV, compressIf(db.Compressed, concat(inner, []byte(content))))
+
+	hmacKey := sha512.Sum512(concat(h.masterSeed, transformedKey, []byte{1}))
+	headerHash := sha256.Sum256(h.raw)
+	result := concat(h.raw, headerHash[:], blockHMAC(hmacKey[:], ^uint64(0), h.raw))
+	for index, block := range [][]byte{payload, {}} {
+		size := binary.LittleEndian.AppendUint32(nil, uint32(len(block)))
+		mac := blockHMAC(hmacKey[:], uint64(index), concat(binary.LittleEndian.AppendUint64(nil, uint64(index)), size, block))
+		result = concat(result, mac, size, block)
+	}
+	return result
+}
+
+func writeHeader(h header) []byte {
+	data := binary.LittleEndian.AppendUint32(nil, signature1)
+	data = binary.LittleEndian.AppendUint32(data, signature2)
+	data = binary.LittleEndian.AppendUint16(data, 1)
+	data = binary.LittleEndian.AppendUint16(data, h.majorVersion)
+
+	field := func(id uint8, value []byte) {
+		data = append(data, id)
+		if h.majorVersion == 3 {
+			data = binary.LittleEndian.AppendUint16(data, uint16(len(value)))
+		} else {
+			data = binary.LittleEndian.AppendUint32(data, uint32(len(value)))
+		}
+		data = append(data, value...)
+	}
+
+	compression := uint32(0)
+	if h.compressed {
+		compression = 1
+	}
+	field(headerCipherID, h.cipherID)
+	field(headerCompression, binary.LittleEndian.AppendUint32(nil, compression))
+	field(headerMasterSeed, h.masterSeed)
+	field(headerEncryptionIV, h.encryptionIV)
+	if h.majorVersion == 3 {
+		field(headerTransformSeed, h.transformSeed)
+		field(headerTransformRounds, binary.LittleEndian.AppendUint64(nil, h.transformRounds))
+		field(headerProtectedStreamKey, h.protectedStreamKey)
+		field(headerStreamStartBytes, h.streamStartBytes)
+		field(headerInnerRandomStreamID, binary.LittleEndian.AppendUint32(nil, h.innerRandomStreamID))
+	} else {
+		field(headerKdfParameters, writeVariantDictionary(h.kdfParameters))
+	}
+	field(headerEnd, []byte("\r\n\r\n"))
+	return data
+}
+
+func writeVariantDictionary(dictionary variantDictionary) []byte {
+	data := binary.LittleEndian.AppendUint16(nil, 0x0100)
+	for name, value := range dictionary {
+		valueType := uint8(variantByteArray)
+		switch {
+		case name == "$UUID" || name == "S":
+		case len(value) == 4:
+			valueType = variantUInt32
+		case len(value) == 8:
+			valueType = variantUInt64
+		}
+		data = append(data, valueType)
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(name)))
+		data = append(data, name...)
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(value)))
+		data = append(data, value...)
+	}
+	return append(data, 0)
+}
+
+func encryptProtectedValues(content string, streamID uint32, key []byte) string {
+	matches := protectedValuePattern.FindAllStringSubmatch(content, -1)
+	var plaintext []byte
+	for _, match := range matches {
+		plaintext = append(plaintext, match[1]...)
+	}
+	// The key stream is created independently of newInnerStream in a single
+	// call, so that the reader is tested against the format
+	ciphertext := make([]byte, len(plaintext))
+	if streamID == innerStreamSalsa20 {
+		salsaKey := sha256.Sum256(key)
+		salsa20.XORKeyStream(ciphertext, plaintext, salsa20Nonce, &salsaKey)
+	} else {
+		hash := sha512.Sum512(key)
+		stream, err := chacha20.NewUnauthenticatedCipher(hash[:32], hash[32:44])
+		Expect(err).NotTo(HaveOccurred())
+		stream.XORKeyStream(ciphertext, plaintext)
+	}
+
+	return protectedValuePattern.ReplaceAllStringFunc(content, func(string) string {
+		value := ciphertext[:len(matches[0][1])]
+		ciphertext = ciphertext[len(matches[0][1]):]
+		matches = matches[1:]
+		return `Protected="True">` + base64.StdEncoding.EncodeToString(value) + "<"
+	})
+}
+
+func encrypt(cipherID []byte, key []byte, iv []byte, plaintext []byte) []byte {
+	if bytes.Equal(cipherID, cipherChaCha20) {
+		stream, err := chacha20.NewUnauthenticatedCipher(key, iv)
+		Expect(err).NotTo(HaveOccurred())
+		ciphertext := make([]byte, len(plaintext))
+		stream.XORKeyStream(ciphertext, plaintext)
+		return ciphertext
+	}
+
+	var block cipher.Block
+	var err error
+	if bytes.Equal(cipherID, cipherTwofish) {
+		block, err = twofish.NewCipher(key)
+	} else {
+		block, err = aes.NewCipher(key)
+	}
+	Expect(err).NotTo(HaveOccurred())
+
+	padding := block.BlockSize() - len(plaintext)%block.BlockSize()
+	padded := append(append([]byte{}, plaintext...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	ciphertext := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+	return ciphertext
+}
+
+func compressIf(compressed bool, data []byte) []byte {
+	if !compressed {
+		return data
+	}
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	_, err := writer.Write(data)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(writer.Close()).To(Succeed())
+	return buffer.Bytes()
+}
+
+func randomBytes(size int) []byte {
+	data := make([]byte, size)
+	_, err := rand.Read(data)
+	Expect(err).NotTo(HaveOccurred())
+	return data
+}
+
+// TestContent contains entries in nested groups, an entry with a history,
+// entries with the same title and an entry in the recycle bin.
+const TestContent = `<?xml version="1.0" encoding="utf-8" standalone="yes"?>
+<KeePassFile>
+	<Meta>
+		<Generator>ctRestClient</Generator>
+		<RecycleBinEnabled>True</RecycleBinEnabled>
+		<RecycleBinUUID>cmVjeWNsZWJpbnV1aWQxMg==</RecycleBinUUID>
+	</Meta>
+	<Root>
+		<Group>
+			<UUID>cm9vdGdyb3VwdXVpZDEyMw==</UUID>
+			<Name>Root</Name>
+			<Entry>
+				<UUID>ZW50cnl1dWlkMDAwMDAwMQ==</UUID>
+				<String><Key>Title</Key><Value>top level</Value></String>
+				<String><Key>Password</Key><Value Protected="True">top secret</Value></String>
+			</Entry>
+			<Group>
+				<UUID>dG9rZW5zZ3JvdXB1dWlkMQ==</UUID>
+				<Name>Tokens</Name>
+				<Entry>
+					<UUID>ZW50cnl1dWlkMDAwMDAwMg==</UUID>
+					<String><Key>Notes</Key><Value>the token of the test instance</Value></String>
+					<String><Key>Password</Key><Value Protected="True">new token</Value></String>
+					<String><Key>Title</Key><Value>example.church.tools</Value></String>
+					<History>
+						<Entry>
+							<UUID>ZW50cnl1dWlkMDAwMDAwMg==</UUID>
+							<String><Key>Password</Key><Value Protected="True">old token</Value></String>
+							<String><Key>Title</Key><Value>example.church.tools</Value></String>
+						</Entry>
+					</History>
+				</Entry>
+				<Group>
+					<UUID>c3ViZ3JvdXB1dWlkMDAwMQ==</UUID>
+					<Name>Sub</Name>
+					<Entry>
+						<UUID>ZW50cnl1dWlkMDAwMDAwMw==</UUID>
+						<String><Key>Title</Key><Value>duplicate</Value></String>
+						<String><Key>Password</Key><Value Protected="True">first</Value></String>
+					</Entry>
+				</Group>
+				<Entry>
+					<UUID>ZW50cnl1dWlkMDAwMDAwNA==</UUID>
+					<String><Key>Title</Key><Value>duplicate</Value></String>
+					<String><Key>Password</Key><Value Protected="True">second</Value></String>
+				</Entry>
+			</Group>
+			<Group>
+				<UUID>cmVjeWNsZWJpbnV1aWQxMg==</UUID>
+				<Name>Recycle Bin</Name>
+				<Entry>
+					<UUID>ZW50cnl1dWlkMDAwMDAwNQ==</UUID>
+					<String><Key>Title</Key><Value>deleted</Value></String>
+					<String><Key>Password</Key><Value Protected="True">deleted token</Value></String>
+				</Entry>
+			</Group>
+		</Group>
+	</Root>
+</KeePassFile>`
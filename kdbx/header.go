@@ -0,0 +1,255 @@
+package kdbx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The signatures at the start of a KDBX file.
+const (
+	signature1 = 0x9AA2D903
+	signature2 = 0xB54BFB67
+)
+
+// The fields of the outer header.
+const (
+	headerEnd          = 0
+	headerCipherID     = 2
+	headerCompression  = 3
+	headerMasterSeed   = 4
+	headerEncryptionIV = 7
+
+	// only KDBX 3.1, KDBX 4 has the key derivation parameters and the
+	// inner header instead
+	headerTransformSeed       = 5
+	headerTransformRounds     = 6
+	headerProtectedStreamKey  = 8
+	headerStreamStartBytes    = 9
+	headerInnerRandomStreamID = 10
+
+	// only KDBX 4
+	headerKdfParameters = 11
+)
+
+// header is the unencrypted outer header of a KDBX file.
+type header struct {
+	majorVersion uint16
+	minorVersion uint16
+
+	cipherID     []byte
+	compressed   bool
+	masterSeed   []byte
+	encryptionIV []byte
+
+	transformSeed       []byte
+	transformRounds     uint64
+	protectedStreamKey  []byte
+	streamStartBytes    []byte
+	innerRandomStreamID uint32
+
+	kdfParameters variantDictionary
+
+	// raw are the bytes of the header including the signatures, they are
+	// authenticated by the hash and the HMAC in KDBX 4.
+	raw []byte
+}
+
+func readHeader(data []byte) (header, error) {
+	reader := bytes.NewReader(data)
+
+	var start struct {
+		Signature1   uint32
+		Signature2   uint32
+		MinorVersion uint16
+		MajorVersion uint16
+	}
+	if err := binary.Read(reader, binary.LittleEndian, &start); err != nil {
+		return header{}, errors.New("the file is not a KeePass database")
+	}
+	if start.Signature1 != signature1 || start.Signature2 != signature2 {
+		return header{}, errors.New("the file is not a KeePass database")
+	}
+	if start.MajorVersion != 3 && start.MajorVersion != 4 {
+		return header{}, fmt.Errorf("the KDBX version %d.%d is not supported, only 3.1 and 4 are supported", start.MajorVersion, start.MinorVersion)
+	}
+
+	h := header{majorVersion: start.MajorVersion, minorVersion: start.MinorVersion}
+	for {
+		var id uint8
+		if err := binary.Read(reader, binary.LittleEndian, &id); err != nil {
+			return header{}, fmt.Errorf("the header is truncated, %w", err)
+		}
+
+		var size uint32
+		if h.majorVersion == 3 {
+			var size16 uint16
+			if err := binary.Read(reader, binary.LittleEndian, &size16); err != nil {
+				return header{}, fmt.Errorf("the header is truncated, %w", err)
+			}
+			size = uint32(size16)
+		} else if err := binary.Read(reader, binary.LittleEndian, &size); err != nil {
+			return header{}, fmt.Errorf("the header is truncated, %w", err)
+		}
+		if int64(size) > int64(reader.Len()) {
+			return header{}, errors.New("the header is truncated")
+		}
+		value := make([]byte, size)
+		if _, err := io.ReadFull(reader, value); err != nil {
+			return header{}, fmt.Errorf("the header is truncated, %w", err)
+		}
+
+		if id == headerEnd {
+			break
+		}
+		if err := h.setField(id, value); err != nil {
+			return header{}, err
+		}
+	}
+	h.raw = data[:len(data)-reader.Len()]
+
+	if len(h.cipherID) == 0 || len(h.masterSeed) != 32 || len(h.encryptionIV) == 0 {
+		return header{}, errors.New("the header is incomplete")
+	}
+	if h.majorVersion == 3 && (len(h.transformSeed) == 0 || len(h.streamStartBytes) == 0) {
+		return header{}, errors.New("the header is incomplete")
+	}
+	if h.majorVersion == 4 && h.kdfParameters == nil {
+		return header{}, errors.New("the header has no key derivation parameters")
+	}
+	return h, nil
+}
+
+func (h *header) setField(id uint8, value []byte) error {
+	switch id {
+	case headerCipherID:
+		h.cipherID = value
+	case headerCompression:
+		if len(value) != 4 {
+			return errors.New("invalid compression flag in the header")
+		}
+		switch binary.LittleEndian.Uint32(value) {
+		case 0:
+			h.compressed = false
+		case 1:
+			h.compressed = true
+		default:
+			return fmt.Errorf("the compression %d is not supported", binary.LittleEndian.Uint32(value))
+		}
+	case headerMasterSeed:
+		h.masterSeed = value
+	case headerTransformSeed:
+		h.transformSeed = value
+	case headerTransformRounds:
+		if len(value) != 8 {
+			return errors.New("invalid transform rounds in the header")
+		}
+		h.transformRounds = binary.LittleEndian.Uint64(value)
+	case headerEncryptionIV:
+		h.encryptionIV = value
+	case headerProtectedStreamKey:
+		h.protectedStreamKey = value
+	case headerStreamStartBytes:
+		h.streamStartBytes = value
+	case headerInnerRandomStreamID:
+		if len(value) != 4 {
+			return errors.New("invalid inner random stream ID in the header")
+		}
+		h.innerRandomStreamID = binary.LittleEndian.Uint32(value)
+	case headerKdfParameters:
+		parameters, err := readVariantDictionary(value)
+		if err != nil {
+			return fmt.Errorf("invalid key derivation parameters, %w", err)
+		}
+		h.kdfParameters = parameters
+	}
+	// unknown fields like the public custom data are ignored
+	return nil
+}
+
+// The value types of a variant dictionary.
+const (
+	variantUInt32    = 0x04
+	variantUInt64    = 0x05
+	variantBool      = 0x08
+	variantInt32     = 0x0C
+	variantInt64     = 0x0D
+	variantString    = 0x18
+	variantByteArray = 0x42
+)
+
+// variantDictionary is the key value list of KDBX 4 used for the key
+// derivation parameters. The values are kept in their binary form.
+type variantDictionary map[string][]byte
+
+func readVariantDictionary(data []byte) (variantDictionary, error) {
+	reader := bytes.NewReader(data)
+
+	var version uint16
+	if err := binary.Read(reader, binary.LittleEndian, &version); err != nil {
+		return nil, err
+	}
+	if version>>8 != 1 {
+		return nil, fmt.Errorf("the version %#04x is not supported", version)
+	}
+
+	dictionary := make(variantDictionary)
+	for {
+		var valueType uint8
+		if err := binary.Read(reader, binary.LittleEndian, &valueType); err != nil {
+			return nil, err
+		}
+		if valueType == 0 {
+			return dictionary, nil
+		}
+
+		name, err := readSizedBytes(reader)
+		if err != nil {
+			return nil, err
+		}
+		value, err := readSizedBytes(reader)
+		if err != nil {
+			return nil, err
+		}
+		dictionary[string(name)] = value
+	}
+}
+
+func readSizedBytes(reader *bytes.Reader) ([]byte, error) {
+	var size int32
+	if err := binary.Read(reader, binary.LittleEndian, &size); err != nil {
+		return nil, err
+	}
+	if size < 0 || int64(size) > int64(reader.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	value := make([]byte, size)
+	_, err := io.ReadFull(reader, value)
+	return value, err
+}
+
+func (d variantDictionary) bytes(name string) ([]byte, error) {
+	value, exists := d[name]
+	if !exists {
+		return nil, fmt.Errorf("the parameter '%s' is missing", name)
+	}
+	return value, nil
+}
+
+// uint returns an unsigned integer parameter stored with 4 or 8 bytes.
+func (d variantDictionary) uint(name string) (uint64, error) {
+	value, err := d.bytes(name)
+	if err != nil {
+		return 0, err
+	}
+	switch len(value) {
+	case 4:
+		return uint64(binary.LittleEndian.Uint32(value)), nil
+	case 8:
+		return binary.LittleEndian.Uint64(value), nil
+	default:
+		return 0, fmt.Errorf("the parameter '%s' is not an integer", name)
+	}
+}
@@ -0,0 +1,323 @@
+// Package kdbx reads KeePass databases in the formats KDBX 3.1 and KDBX 4,
+// as written by KeePass 2 and KeePassXC.
+package kdbx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/twofish"
+)
+
+// ErrInvalidCredentials is returned if the password or the key file do not
+// match the database.
+var ErrInvalidCredentials = errors.New("the password or the key file is invalid")
+
+// The UUIDs of the ciphers of the payload.
+var (
+	cipherAES256   = mustDecodeHex("31c1f2e6bf714350be5805216afc5aff")
+	cipherChaCha20 = mustDecodeHex("d6038a2b8b6f4cb5a524339a31dbb59a")
+	cipherTwofish  = mustDecodeHex("ad68f29f576f4bb9a36ad47af965346c")
+)
+
+// Open reads and decrypts the database file. The key file path is optional.
+func Open(path string, password string, keyFilePath string) (*Database, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keyFile []byte
+	if keyFilePath != "" {
+		keyFile, err = os.ReadFile(keyFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the key file, %w", err)
+		}
+	}
+
+	return Decode(data, password, keyFile)
+}
+
+// Decode decrypts the database. The key file is nil if the database is not
+// protected by a key file.
+func Decode(data []byte, password string, keyFile []byte) (*Database, error) {
+	h, err := readHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := compositeKey(password, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	transformedKey, err := transformKey(h, key)
+	if err != nil {
+		return nil, err
+	}
+	masterKey := sha256.Sum256(concat(h.masterSeed, transformedKey))
+
+	if h.majorVersion == 3 {
+		return decodeV3(h, data[len(h.raw):], masterKey[:])
+	}
+	return decodeV4(h, data[len(h.raw):], masterKey[:], transformedKey)
+}
+
+func decodeV3(h header, payload []byte, masterKey []byte) (*Database, error) {
+	plaintext, err := decrypt(h.cipherID, masterKey, h.encryptionIV, payload)
+	if errors.Is(err, errInvalidPadding) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(plaintext) < len(h.streamStartBytes) || !bytes.Equal(plaintext[:len(h.streamStartBytes)], h.streamStartBytes) {
+		return nil, ErrInvalidCredentials
+	}
+
+	content, err := readHashedBlocks(plaintext[len(h.streamStartBytes):])
+	if err != nil {
+		return nil, err
+	}
+	if h.compressed {
+		if content, err = decompress(content); err != nil {
+			return nil, err
+		}
+	}
+
+	stream, err := newInnerStream(h.innerRandomStreamID, h.protectedStreamKey)
+	if err != nil {
+		return nil, err
+	}
+	return parseXML(content, stream)
+}
+
+func decodeV4(h header, data []byte, masterKey []byte, transformedKey []byte) (*Database, error) {
+	if len(data) < 64 {
+		return nil, errors.New("the file is truncated")
+	}
+	headerHash := sha256.Sum256(h.raw)
+	if !bytes.Equal(data[:32], headerHash[:]) {
+		return nil, errors.New("the header is corrupted")
+	}
+
+	hmacKey := sha512.Sum512(concat(h.masterSeed, transformedKey, []byte{1}))
+	if !hmac.Equal(data[32:64], blockHMAC(hmacKey[:], ^uint64(0), h.raw)) {
+		return nil, ErrInvalidCredentials
+	}
+
+	payload, err := readHMACBlocks(data[64:], hmacKey[:])
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := decrypt(h.cipherID, masterKey, h.encryptionIV, payload)
+	if err != nil {
+		return nil, err
+	}
+	if h.compressed {
+		if plaintext, err = decompress(plaintext); err != nil {
+			return nil, err
+		}
+	}
+
+	streamID, streamKey, content, err := readInnerHeader(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := newInnerStream(streamID, streamKey)
+	if err != nil {
+		return nil, err
+	}
+	return parseXML(content, stream)
+}
+
+// readHashedBlocks reads the block stream of KDBX 3.1, each block consists
+// of its index, the SHA-256 hash, the size and the data.
+func readHashedBlocks(data []byte) ([]byte, error) {
+	var content []byte
+	for index := uint32(0); ; index++ {
+		if len(data) < 40 {
+			return nil, errors.New("the payload is truncated")
+		}
+		if binary.LittleEndian.Uint32(data[:4]) != index {
+			return nil, fmt.Errorf("the block %d is out of order", index)
+		}
+		hash := data[4:36]
+		size := binary.LittleEndian.Uint32(data[36:40])
+		data = data[40:]
+
+		if size == 0 {
+			return content, nil
+		}
+		if uint64(size) > uint64(len(data)) {
+			return nil, errors.New("the payload is truncated")
+		}
+		block := data[:size]
+		if blockHash := sha256.Sum256(block); !bytes.Equal(hash, blockHash[:]) {
+			return nil, fmt.Errorf("the block %d is corrupted", index)
+		}
+		content = append(content, block...)
+		data = data[size:]
+	}
+}
+
+// readHMACBlocks reads the block stream of KDBX 4, each block consists of
+// the HMAC, the size and the data.
+func readHMACBlocks(data []byte, hmacKey []byte) ([]byte, error) {
+	var content []byte
+	for index := uint64(0); ; index++ {
+		if len(data) < 36 {
+			return nil, errors.New("the payload is truncated")
+		}
+		mac := data[:32]
+		size := binary.LittleEndian.Uint32(data[32:36])
+		if uint64(size) > uint64(len(data)-36) {
+			return nil, errors.New("the payload is truncated")
+		}
+		block := data[36 : 36+size]
+		if !hmac.Equal(mac, blockHMAC(hmacKey, index, concat(binary.LittleEndian.AppendUint64(nil, index), data[32:36], block))) {
+			return nil, fmt.Errorf("the block %d is corrupted", index)
+		}
+
+		if size == 0 {
+			return content, nil
+		}
+		content = append(content, block...)
+		data = data[36+size:]
+	}
+}
+
+func blockHMAC(hmacKey []byte, index uint64, data []byte) []byte {
+	blockKey := sha512.Sum512(concat(binary.LittleEndian.AppendUint64(nil, index), hmacKey))
+	mac := hmac.New(sha256.New, blockKey[:])
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+var errInvalidPadding = errors.New("invalid padding")
+
+func decrypt(cipherID []byte, key []byte, iv []byte, payload []byte) ([]byte, error) {
+	switch {
+	case bytes.Equal(cipherID, cipherAES256):
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return decryptCBC(block, iv, payload)
+	case bytes.Equal(cipherID, cipherTwofish):
+		block, err := twofish.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return decryptCBC(block, iv, payload)
+	case bytes.Equal(cipherID, cipherChaCha20):
+		stream, err := chacha20.NewUnauthenticatedCipher(key, iv)
+		if err != nil {
+			return nil, err
+		}
+		plaintext := make([]byte, len(payload))
+		stream.XORKeyStream(plaintext, payload)
+		return plaintext, nil
+	default:
+		return nil, fmt.Errorf("the cipher %x is not supported", cipherID)
+	}
+}
+
+// decryptCBC decrypts the payload in CBC mode and removes the PKCS #7
+// padding.
+func decryptCBC(block cipher.Block, iv []byte, payload []byte) ([]byte, error) {
+	if len(iv) != block.BlockSize() {
+		return nil, errors.New("invalid encryption IV in the header")
+	}
+	if len(payload) == 0 || len(payload)%block.BlockSize() != 0 {
+		return nil, errors.New("the payload is truncated")
+	}
+
+	plaintext := make([]byte, len(payload))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, payload)
+
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > block.BlockSize() {
+		return nil, errInvalidPadding
+	}
+	for _, b := range plaintext[len(plaintext)-padding:] {
+		if int(b) != padding {
+			return nil, errInvalidPadding
+		}
+	}
+	return plaintext[:len(plaintext)-padding], nil
+}
+
+func decompress(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress the payload, %w", err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress the payload, %w", err)
+	}
+	return content, nil
+}
+
+// The fields of the inner header of KDBX 4.
+const (
+	innerHeaderEnd       = 0
+	innerHeaderStreamID  = 1
+	innerHeaderStreamKey = 2
+	innerHeaderBinary    = 3
+)
+
+// readInnerHeader returns the inner random stream and the XML document
+// following the inner header. Binary attachments are skipped.
+func readInnerHeader(data []byte) (uint32, []byte, []byte, error) {
+	var streamID uint32
+	var streamKey []byte
+	for {
+		if len(data) < 5 {
+			return 0, nil, nil, errors.New("the inner header is truncated")
+		}
+		id := data[0]
+		size := binary.LittleEndian.Uint32(data[1:5])
+		if uint64(size) > uint64(len(data)-5) {
+			return 0, nil, nil, errors.New("the inner header is truncated")
+		}
+		value := data[5 : 5+size]
+		data = data[5+size:]
+
+		switch id {
+		case innerHeaderEnd:
+			if streamKey == nil {
+				return 0, nil, nil, errors.New("the inner header has no stream key")
+			}
+			return streamID, streamKey, data, nil
+		case innerHeaderStreamID:
+			if len(value) != 4 {
+				return 0, nil, nil, errors.New("invalid inner random stream ID in the inner header")
+			}
+			streamID = binary.LittleEndian.Uint32(value)
+		case innerHeaderStreamKey:
+			streamKey = value
+		}
+	}
+}
+
+func concat(values ...[]byte) []byte {
+	var result []byte
+	for _, value := range values {
+		result = append(result, value...)
+	}
+	return result
+}
@@ -0,0 +1,219 @@
+package kdbx_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"ctRestClient/kdbx"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/salsa20"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Open", func() {
+	It("reads a database written by KeePassXC", func() {
+		database, err := kdbx.Open("testdata/churchtools-tokens.kdbx", "abcd1234", "")
+
+		Expect(err).NotTo(HaveOccurred())
+		entry, err := database.Entry("TEST_TOKEN")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry.Password()).To(Equal("1234567890"))
+	})
+
+	It("returns ErrInvalidCredentials for a wrong password", func() {
+		_, err := kdbx.Open("testdata/churchtools-tokens.kdbx", "wrong", "")
+
+		Expect(err).To(MatchError(kdbx.ErrInvalidCredentials))
+	})
+
+	It("returns an error if the file does not exist", func() {
+		_, err := kdbx.Open("testdata/missing.kdbx", "abcd1234", "")
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("reads the key file", func() {
+		keyFile := []byte("any content of a key file")
+		dir := GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(dir, "key"), keyFile, 0600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "db.kdbx"), kdbx.EncodeTestDatabase(kdbx.TestDatabase{
+			MajorVersion: 4, Cipher: "AES", KDF: "AES", InnerStream: "ChaCha20",
+			Password: "secret", KeyFile: keyFile, Content: kdbx.TestContent,
+		}), 0600)).To(Succeed())
+
+		database, err := kdbx.Open(filepath.Join(dir, "db.kdbx"), "secret", filepath.Join(dir, "key"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(database.Entries()).To(HaveLen(4))
+
+		_, err = kdbx.Open(filepath.Join(dir, "db.kdbx"), "secret", "")
+		Expect(err).To(MatchError(kdbx.ErrInvalidCredentials))
+	})
+
+	It("reads a database protected only by a key file", func() {
+		keyFile := []byte("any content of a key file")
+		dir := GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(dir, "key"), keyFile, 0600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "db.kdbx"), kdbx.EncodeTestDatabase(kdbx.TestDatabase{
+			MajorVersion: 4, Cipher: "AES", KDF: "AES", InnerStream: "ChaCha20",
+			KeyFile: keyFile, Content: kdbx.TestContent,
+		}), 0600)).To(Succeed())
+
+		_, err := kdbx.Open(filepath.Join(dir, "db.kdbx"), "", filepath.Join(dir, "key"))
+
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
+
+var _ = Describe("Decode", func() {
+	DescribeTable("reads the format",
+		func(db kdbx.TestDatabase) {
+			db.Password = "secret"
+			db.Content = kdbx.TestContent
+			data := kdbx.EncodeTestDatabase(db)
+
+			database, err := kdbx.Decode(data, "secret", nil)
+
+			Expect(err).NotTo(HaveOccurred())
+			passwords := make(map[string]string)
+			for _, entry := range database.Entries() {
+				passwords[entry.Path] = entry.Password()
+			}
+			Expect(passwords).To(Equal(map[string]string{
+				"top level":                   "top secret",
+				"Tokens/example.church.tools": "new token",
+				"Tokens/Sub/duplicate":        "first",
+				"Tokens/duplicate":            "second",
+			}))
+
+			_, err = kdbx.Decode(data, "wrong", nil)
+			Expect(err).To(MatchError(kdbx.ErrInvalidCredentials))
+		},
+		Entry("KDBX 4 with AES-KDF and AES", kdbx.TestDatabase{
+			MajorVersion: 4, Cipher: "AES", KDF: "AES", InnerStream: "ChaCha20", Compressed: true,
+		}),
+		Entry("KDBX 4 with Argon2d and ChaCha20", kdbx.TestDatabase{
+			MajorVersion: 4, Cipher: "ChaCha20", KDF: "Argon2d", InnerStream: "ChaCha20", Compressed: true,
+		}),
+		Entry("KDBX 4 with Argon2id and Twofish", kdbx.TestDatabase{
+			MajorVersion: 4, Cipher: "Twofish", KDF: "Argon2id", InnerStream: "Salsa20",
+		}),
+		Entry("KDBX 3.1 with AES", kdbx.TestDatabase{
+			MajorVersion: 3, Cipher: "AES", InnerStream: "Salsa20", Compressed: true,
+		}),
+		Entry("KDBX 3.1 with ChaCha20", kdbx.TestDatabase{
+			MajorVersion: 3, Cipher: "ChaCha20", InnerStream: "Salsa20",
+		}),
+	)
+
+	It("returns an error if the data is no KeePass database", func() {
+		_, err := kdbx.Decode([]byte("not a database"), "secret", nil)
+
+		Expect(err).To(MatchError("the file is not a KeePass database"))
+	})
+
+	It("returns an error for an unsupported version", func() {
+		data := kdbx.EncodeTestDatabase(kdbx.TestDatabase{
+			MajorVersion: 4, Cipher: "AES", KDF: "AES", Content: kdbx.TestContent,
+		})
+		data[10] = 2
+
+		_, err := kdbx.Decode(data, "", nil)
+
+		Expect(err).To(MatchError(ContainSubstring("the KDBX version 2.1 is not supported")))
+	})
+
+	It("returns an error if a block is corrupted", func() {
+		data := kdbx.EncodeTestDatabase(kdbx.TestDatabase{
+			MajorVersion: 4, Cipher: "AES", KDF: "AES", Password: "secret", Content: kdbx.TestContent,
+		})
+		data[len(data)-50] ^= 1
+
+		_, err := kdbx.Decode(data, "secret", nil)
+
+		Expect(err).To(MatchError("the block 0 is corrupted"))
+	})
+})
+
+var _ = Describe("Database", func() {
+	var database *kdbx.Database
+
+	BeforeEach(func() {
+		var err error
+		database, err = kdbx.Decode(kdbx.EncodeTestDatabase(kdbx.TestDatabase{
+			MajorVersion: 4, Cipher: "AES", KDF: "AES", InnerStream: "ChaCha20", Content: kdbx.TestContent,
+		}), "", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("finds an entry by its path", func() {
+		entry, err := database.Entry("Tokens/Sub/duplicate")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry.Password()).To(Equal("first"))
+	})
+
+	It("finds an entry in the root group", func() {
+		entry, err := database.Entry("/top level")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry.Password()).To(Equal("top secret"))
+	})
+
+	It("finds an entry by its unique title", func() {
+		entry, err := database.Entry("example.church.tools")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry.Path).To(Equal("Tokens/example.church.tools"))
+		Expect(entry.Fields["Notes"]).To(Equal("the token of the test instance"))
+	})
+
+	It("returns an error if the title is ambiguous", func() {
+		_, err := database.Entry("duplicate")
+
+		Expect(err).To(MatchError("the entry 'duplicate' is ambiguous, it matches Tokens/Sub/duplicate, Tokens/duplicate"))
+	})
+
+	It("does not find entries in the recycle bin", func() {
+		_, err := database.Entry("deleted")
+
+		Expect(err).To(MatchError("the entry 'deleted' could not be found"))
+	})
+})
+
+var _ = Describe("inner random stream", func() {
+	key := []byte("the protected stream key")
+	plaintext := bytes.Repeat([]byte("protected value "), 20)
+
+	// decryptInParts decrypts the values one by one like a reader of the
+	// document, the parts span several blocks of the key stream.
+	decryptInParts := func(id uint32) []byte {
+		stream, err := kdbx.NewInnerStream(id, key)
+		Expect(err).NotTo(HaveOccurred())
+		result := make([]byte, len(plaintext))
+		for start, size := 0, 1; start < len(plaintext); start, size = start+size, size*3 {
+			end := min(start+size, len(plaintext))
+			stream(result[start:end], plaintext[start:end])
+		}
+		return result
+	}
+
+	It("continues the Salsa20 key stream across values", func() {
+		salsaKey := sha256.Sum256(key)
+		expected := make([]byte, len(plaintext))
+		salsa20.XORKeyStream(expected, plaintext, []byte{0xE8, 0x30, 0x09, 0x4B, 0x97, 0x20, 0x5D, 0x2A}, &salsaKey)
+
+		Expect(decryptInParts(kdbx.InnerStreamSalsa20)).To(Equal(expected))
+	})
+
+	It("continues the ChaCha20 key stream across values", func() {
+		stream, err := kdbx.NewInnerStream(kdbx.InnerStreamChaCha20, key)
+		Expect(err).NotTo(HaveOccurred())
+		expected := make([]byte, len(plaintext))
+		stream(expected, plaintext)
+
+		Expect(decryptInParts(kdbx.InnerStreamChaCha20)).To(Equal(expected))
+	})
+})
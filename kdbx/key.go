@@ -0,0 +1,216 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The UUIDs of the key derivation functions.
+var (
+	kdfAES      = mustDecodeHex("c9d9f39a628a4460bf740d08c18a4fea")
+	kdfArgon2d  = mustDecodeHex("ef636ddf8c29444b91f7a9a403e30a0c")
+	kdfArgon2id = mustDecodeHex("9e298b1956db4773b23dfc3ec6f0a1e6")
+)
+
+// compositeKey combines the password and the key file. A database may be
+// protected by a password, a key file or both.
+func compositeKey(password string, keyFile []byte) ([]byte, error) {
+	var components []byte
+	if password != "" || keyFile == nil {
+		hash := sha256.Sum256([]byte(password))
+		components = append(components, hash[:]...)
+	}
+	if keyFile != nil {
+		key, err := keyFileKey(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, key...)
+	}
+	hash := sha256.Sum256(components)
+	return hash[:], nil
+}
+
+// keyFileXML is the XML key file format of KeePass in the versions 1.0 and
+// 2.0.
+type keyFileXML struct {
+	Meta struct {
+		Version string `xml:"Version"`
+	} `xml:"Meta"`
+	Key struct {
+		Data struct {
+			Hash  string `xml:"Hash,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"Data"`
+	} `xml:"Key"`
+}
+
+// keyFileKey returns the key of a key file. XML key files and files with
+// 32 bytes or 64 hex characters contain the key, the key of any other file
+// is its hash.
+func keyFileKey(data []byte) ([]byte, error) {
+	if bytes.Contains(data, []byte("<KeyFile")) {
+		var keyFile keyFileXML
+		if err := xml.Unmarshal(data, &keyFile); err != nil {
+			return nil, fmt.Errorf("invalid XML key file, %w", err)
+		}
+		value := strings.Join(strings.Fields(keyFile.Key.Data.Value), "")
+
+		switch {
+		case strings.HasPrefix(keyFile.Meta.Version, "1."):
+			key, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid XML key file, %w", err)
+			}
+			return key, nil
+		case strings.HasPrefix(keyFile.Meta.Version, "2."):
+			key, err := hex.DecodeString(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid XML key file, %w", err)
+			}
+			if keyFile.Key.Data.Hash != "" {
+				hash := sha256.Sum256(key)
+				if !strings.EqualFold(hex.EncodeToString(hash[:4]), keyFile.Key.Data.Hash) {
+					return nil, errors.New("the XML key file is corrupted, its hash does not match")
+				}
+			}
+			return key, nil
+		default:
+			return nil, fmt.Errorf("the XML key file version '%s' is not supported", keyFile.Meta.Version)
+		}
+	}
+
+	if len(data) == 32 {
+		return data, nil
+	}
+	if len(data) == 64 {
+		if key, err := hex.DecodeString(string(data)); err == nil {
+			return key, nil
+		}
+	}
+	hash := sha256.Sum256(data)
+	return hash[:], nil
+}
+
+// transformKey derives the key from the composite key with the key
+// derivation function of the header.
+func transformKey(h header, key []byte) ([]byte, error) {
+	if h.majorVersion == 3 {
+		return aesKdf(key, h.transformSeed, h.transformRounds)
+	}
+
+	uuid, err := h.kdfParameters.bytes("$UUID")
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case bytes.Equal(uuid, kdfAES):
+		seed, err := h.kdfParameters.bytes("S")
+		if err != nil {
+			return nil, err
+		}
+		rounds, err := h.kdfParameters.uint("R")
+		if err != nil {
+			return nil, err
+		}
+		return aesKdf(key, seed, rounds)
+	case bytes.Equal(uuid, kdfArgon2d):
+		return argon2Kdf(argon2d, key, h.kdfParameters)
+	case bytes.Equal(uuid, kdfArgon2id):
+		return argon2Kdf(argon2id, key, h.kdfParameters)
+	default:
+		return nil, fmt.Errorf("the key derivation function %x is not supported", uuid)
+	}
+}
+
+// aesKdf encrypts the key with AES-256 in ECB mode for the number of rounds.
+func aesKdf(key []byte, seed []byte, rounds uint64) ([]byte, error) {
+	if len(seed) != 32 {
+		return nil, errors.New("the AES-KDF seed must have 32 bytes")
+	}
+	block, err := aes.NewCipher(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	transformed := make([]byte, len(key))
+	copy(transformed, key)
+	for i := uint64(0); i < rounds; i++ {
+		block.Encrypt(transformed[:16], transformed[:16])
+		block.Encrypt(transformed[16:], transformed[16:])
+	}
+	hash := sha256.Sum256(transformed)
+	return hash[:], nil
+}
+
+// The limits of the Argon2 parameters, larger values can not be processed
+// and are rejected instead of exhausting the memory.
+const (
+	maxArgon2MemoryKiB = 4 * 1024 * 1024
+	maxArgon2Lanes     = 1 << 8
+)
+
+func argon2Kdf(mode int, key []byte, parameters variantDictionary) ([]byte, error) {
+	salt, err := parameters.bytes("S")
+	if err != nil {
+		return nil, err
+	}
+	iterations, err := parameters.uint("I")
+	if err != nil {
+		return nil, err
+	}
+	memory, err := parameters.uint("M")
+	if err != nil {
+		return nil, err
+	}
+	parallelism, err := parameters.uint("P")
+	if err != nil {
+		return nil, err
+	}
+	version, err := parameters.uint("V")
+	if err != nil {
+		return nil, err
+	}
+
+	if iterations < 1 || iterations > 1<<32-1 {
+		return nil, fmt.Errorf("invalid Argon2 iterations %d", iterations)
+	}
+	if memory/1024 > maxArgon2MemoryKiB {
+		return nil, fmt.Errorf("the Argon2 memory of %d bytes is too large", memory)
+	}
+	if parallelism < 1 || parallelism > maxArgon2Lanes {
+		return nil, fmt.Errorf("invalid Argon2 parallelism %d", parallelism)
+	}
+	if version != argon2Version10 && version != argon2Version13 {
+		return nil, fmt.Errorf("the Argon2 version %#x is not supported", version)
+	}
+
+	// the secret key and the associated data are optional
+	return argon2Key(argon2Params{
+		mode:        mode,
+		version:     uint32(version),
+		password:    key,
+		salt:        salt,
+		secret:      parameters["K"],
+		data:        parameters["A"],
+		iterations:  uint32(iterations),
+		memoryKiB:   uint32(memory / 1024),
+		parallelism: uint32(parallelism),
+		keyLength:   32,
+	}), nil
+}
+
+func mustDecodeHex(value string) []byte {
+	decoded, err := hex.DecodeString(value)
+	if err != nil {
+		panic(err)
+	}
+	return decoded
+}
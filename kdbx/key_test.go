@@ -0,0 +1,67 @@
+package kdbx_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"ctRestClient/kdbx"
+	"encoding/hex"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("KeyFileKey", func() {
+	key := bytes.Repeat([]byte{0xAB}, 32)
+
+	It("reads an XML key file of version 1.0", func() {
+		keyFile := []byte(`<?xml version="1.0" encoding="utf-8"?>
+<KeyFile>
+	<Meta><Version>1.00</Version></Meta>
+	<Key><Data>q6urq6urq6urq6urq6urq6urq6urq6urq6urq6urq6s=</Data></Key>
+</KeyFile>`)
+
+		Expect(kdbx.KeyFileKey(keyFile)).To(Equal(key))
+	})
+
+	It("reads an XML key file of version 2.0", func() {
+		keyFile := []byte(`<?xml version="1.0" encoding="utf-8"?>
+<KeyFile>
+	<Meta><Version>2.0</Version></Meta>
+	<Key>
+		<Data Hash="` + hashPrefix(key) + `">
+			ABABABAB ABABABAB ABABABAB ABABABAB
+			ABABABAB ABABABAB ABABABAB ABABABAB
+		</Data>
+	</Key>
+</KeyFile>`)
+
+		Expect(kdbx.KeyFileKey(keyFile)).To(Equal(key))
+	})
+
+	It("returns an error if the hash of an XML key file does not match", func() {
+		keyFile := []byte(`<KeyFile><Meta><Version>2.0</Version></Meta><Key><Data Hash="00000000">ABAB</Data></Key></KeyFile>`)
+
+		_, err := kdbx.KeyFileKey(keyFile)
+
+		Expect(err).To(MatchError("the XML key file is corrupted, its hash does not match"))
+	})
+
+	It("uses a file of 32 bytes as key", func() {
+		Expect(kdbx.KeyFileKey(key)).To(Equal(key))
+	})
+
+	It("decodes a file of 64 hex characters", func() {
+		Expect(kdbx.KeyFileKey([]byte(hex.EncodeToString(key)))).To(Equal(key))
+	})
+
+	It("hashes any other file", func() {
+		hash := sha256.Sum256([]byte("any file"))
+
+		Expect(kdbx.KeyFileKey([]byte("any file"))).To(Equal(hash[:]))
+	})
+})
+
+func hashPrefix(key []byte) string {
+	hash := sha256.Sum256(key)
+	return hex.EncodeToString(hash[:4])
+}
@@ -20,9 +20,12 @@ type logger struct {
 	logger  *log.Logger
 }
 
-func NewLogger(logFilePath string) Logger {
+// NewLogger returns a logger that writes to the console, the log file if the
+// path is set and the additional writers.
+func NewLogger(logFilePath string, additionalWriters ...io.Writer) Logger {
 	var writers []io.Writer
 	writers = append(writers, os.Stdout) // Always write to console
+	writers = append(writers, additionalWriters...)
 
 	var logFile *os.File
 	if logFilePath != "" {
@@ -1,12 +1,8 @@
 package main
 
 import (
-	"ctRestClient/app"
-	"ctRestClient/config"
-	"ctRestClient/csv"
-	"ctRestClient/data_provider"
+	"ctRestClient/cli"
 	"ctRestClient/logger"
-	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -14,73 +10,37 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
-	"syscall"
 	"time"
-
-	"golang.org/x/term"
 )
 
-func main() {
-	var configFilePath string
-	var dataDir string
-	var outputDir string
-	var keepassDbFilePath string
-
-	flag.StringVar(&configFilePath, "c", "config.yml", "the config file path")
-	flag.StringVar(&dataDir, "d", getDefaultDataDir(), "the data directory")
-	flag.StringVar(&outputDir, "o", getDefaultOutputDir(), "the output directory")
-	flag.StringVar(&keepassDbFilePath, "k", "passwords.kdbx", "the Keepass DB file path")
-	flag.Parse()
-
-	rootDir := filepath.Join(outputDir, time.Now().Format("2006.01.02_15-04-05"))
-	err := os.MkdirAll(rootDir, 0755)
-	if err != nil {
-		log.Fatalf("    failed to create directory: %v", err)
-	}
-
-	logFile := filepath.Join(rootDir, "ctRestClient.log")
-
-	appLogger := logger.NewLogger(logFile)
-	logGeneralInfo(appLogger, getCurrentUserName(), getCurrentOSName(), getDate())
-
-	config, err := config.LoadConfig(configFilePath)
-	if err != nil {
-		appLogger.Fatal(fmt.Sprintf("Failed to load config from path %s: %v", configFilePath, err))
-	}
+// version is set at build time with -ldflags "-X main.version=<version>".
+var version = "dev"
 
-	keepassDbPassword, err := getPasswordFromUser()
-	if err != nil {
-		appLogger.Fatal(fmt.Sprintf("Failed to get password: %v", err))
-	}
-
-	keepassCli, err := app.NewKeepassCli(keepassDbFilePath, keepassDbPassword, appLogger)
-	if err != nil {
-		appLogger.Fatal(fmt.Sprintf("Failed to initialize Keepass CLI: %v", err))
-	}
-
-	validPassword, err := keepassCli.IsPasswordValid(keepassDbPassword)
-	if err != nil {
-		appLogger.Fatal(fmt.Sprintf("Failed check keepass password: %v", err))
-	}
-
-	if !validPassword {
-		appLogger.Fatal("The keepass password is invalid")
+func main() {
+	commandLine := cli.CLI{
+		Name: "ctRestClient",
+		Commands: []cli.Command{
+			exportCommand(),
+			serveCommand(),
+			validateCommand(),
+			groupsCommand(),
+			fieldsCommand(),
+			personCommand(),
+			initCommand(),
+			versionCommand(),
+		},
+		DefaultCommand: "export",
+		Defaults: cli.GlobalOptions{
+			ConfigFilePath:    "config.yml",
+			DataDir:           getDefaultDataDir(),
+			OutputDir:         getDefaultOutputDir(),
+			KeepassDbFilePath: "passwords.kdbx",
+			KeepassBackend:    "native",
+		},
+		Output: os.Stdout,
 	}
 
-	err = app.NewInstancesProcessor(
-		*config,
-		appLogger,
-	).Process(
-		app.NewGroupExporter(),
-		csv.NewCSVFileWriter(),
-		rootDir,
-		data_provider.NewFileDataProvider(filepath.Join(dataDir, "mappings/persons")),
-		data_provider.NewBlockListDataProvider(filepath.Join(dataDir, "blocklists"), appLogger),
-		keepassCli,
-	)
-	if err != nil {
-		appLogger.Fatal(fmt.Sprintf("Failed to process instances: %v", err))
-	}
+	os.Exit(commandLine.Run(os.Args[1:]))
 }
 
 func getDefaultOutputDir() string {
@@ -103,20 +63,6 @@ func getExecutableDir() string {
 	return executabelDir
 }
 
-func getPasswordFromUser() (string, error) {
-	fmt.Print("Enter Keepass database password: ")
-
-	// Use the appropriate file descriptor based on the platform
-	fd := int(syscall.Stdin)
-	password, err := term.ReadPassword(fd)
-	if err != nil {
-		return "", fmt.Errorf("failed to read password: %v", err)
-	}
-	fmt.Println()
-
-	return string(password), nil
-}
-
 func getCurrentUserName() string {
 	currentUser, err := user.Current()
 	if err != nil {
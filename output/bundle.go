@@ -0,0 +1,179 @@
+package output
+
+import (
+	"archive/zip"
+	"bytes"
+	"ctRestClient/config"
+	"ctRestClient/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/agessh"
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+// Bundle is an encrypted ZIP file with the files of a run. The csv files are
+// encoded in memory and encrypted into the bundle, so that they never touch
+// the disk in plain text.
+type Bundle interface {
+	csv.CSVFileWriter
+	// WriteFile adds a file with the content to the bundle.
+	WriteFile(path string, content []byte) error
+	// Close completes the bundle, it is incomplete and cannot be opened
+	// before.
+	Close() error
+}
+
+type bundle struct {
+	path    string
+	rootDir string
+	file    *os.File
+	// encrypter encrypts the whole ZIP file, it is nil if the entries are
+	// encrypted by the ZIP file itself
+	encrypter io.WriteCloser
+	password  string
+	zip       *zip.Writer
+}
+
+// NewBundle creates the bundle at the path. The files are stored by their
+// path relative to the root directory. The secret is the passphrase of a ZIP
+// file. For age it is a recipient like 'age1...' or 'ssh-ed25519 ...' and a
+// passphrase otherwise, for OpenPGP an armored public key and a passphrase
+// otherwise.
+func NewBundle(path string, rootDir string, encryption config.Encryption, secret string) (Bundle, error) {
+	if secret == "" {
+		return nil, errors.New("failed to create the bundle, the secret is empty")
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the bundle, %w", err)
+	}
+
+	b := &bundle{path: path, rootDir: rootDir, file: file}
+	switch encryption.Format {
+	case config.EncryptionZIP:
+		b.password = secret
+	case config.EncryptionAge:
+		b.encrypter, err = encryptAge(file, secret)
+	case config.EncryptionOpenPGP:
+		b.encrypter, err = encryptOpenPGP(file, secret)
+	default:
+		err = fmt.Errorf("the format '%s' is unknown", encryption.Format)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to create the bundle, %w", err)
+	}
+
+	if b.encrypter != nil {
+		b.zip = zip.NewWriter(b.encrypter)
+	} else {
+		b.zip = zip.NewWriter(file)
+	}
+	return b, nil
+}
+
+func encryptAge(writer io.Writer, secret string) (io.WriteCloser, error) {
+	var recipient age.Recipient
+	var err error
+	switch key := strings.TrimSpace(secret); {
+	case strings.HasPrefix(key, "age1"):
+		recipient, err = age.ParseX25519Recipient(key)
+	case strings.HasPrefix(key, "ssh-"):
+		recipient, err = agessh.ParseRecipient(key)
+	default:
+		recipient, err = age.NewScryptRecipient(secret)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid age recipient, %w", err)
+	}
+	return age.Encrypt(writer, recipient)
+}
+
+func encryptOpenPGP(writer io.Writer, secret string) (io.WriteCloser, error) {
+	hints := &openpgp.FileHints{IsBinary: true, FileName: "export.zip"}
+	if !strings.Contains(secret, "-----BEGIN PGP PUBLIC KEY BLOCK-----") {
+		return openpgp.SymmetricallyEncrypt(writer, []byte(secret), hints, nil)
+	}
+
+	keys, err := openpgp.ReadArmoredKeyRing(strings.NewReader(secret))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenPGP public key, %w", err)
+	}
+	return openpgp.Encrypt(writer, keys, nil, hints, nil)
+}
+
+func (b *bundle) Write(csvFilePath string, csvHeader []string, csvRecords [][]string) error {
+	var content bytes.Buffer
+	if err := csv.Encode(&content, csvHeader, csvRecords); err != nil {
+		return err
+	}
+	if err := b.add(csvFilePath, content.Bytes()); err != nil {
+		return fmt.Errorf("failed to create csv file: %v", err)
+	}
+	return nil
+}
+
+func (b *bundle) WriteFile(path string, content []byte) error {
+	if err := b.add(path, content); err != nil {
+		return fmt.Errorf("failed to add '%s' to the bundle, %w", path, err)
+	}
+	return nil
+}
+
+// add adds an entry with the content of the file. Its name is the path
+// relative to the root directory with slashes.
+func (b *bundle) add(path string, content []byte) error {
+	name, err := filepath.Rel(b.rootDir, path)
+	if err != nil || !filepath.IsLocal(name) {
+		return fmt.Errorf("the file '%s' is not in the directory '%s'", path, b.rootDir)
+	}
+
+	header := &zip.FileHeader{
+		Name:   filepath.ToSlash(name),
+		Method: zip.Deflate,
+		// The names are UTF-8
+		Flags: 0x800,
+	}
+	if b.password == "" {
+		header.Modified = time.Now()
+		entry, err := b.zip.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		_, err = entry.Write(content)
+		return err
+	}
+
+	encrypted, err := encryptAESEntry(content, b.password)
+	if err != nil {
+		return err
+	}
+	// Raw entries only have the MS-DOS time
+	header.SetModTime(time.Now())
+	entry, err := b.zip.CreateRaw(aesEntryHeader(header, encrypted, len(content)))
+	if err != nil {
+		return err
+	}
+	_, err = entry.Write(encrypted)
+	return err
+}
+
+func (b *bundle) Close() error {
+	err := b.zip.Close()
+	if b.encrypter != nil {
+		err = errors.Join(err, b.encrypter.Close())
+	}
+	err = errors.Join(err, b.file.Close())
+	if err != nil {
+		return fmt.Errorf("failed to complete the bundle '%s', %w", b.path, err)
+	}
+	return nil
+}
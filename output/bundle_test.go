@@ -0,0 +1,161 @@
+package output_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"ctRestClient/config"
+	"ctRestClient/csv"
+	"ctRestClient/output"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bundle", func() {
+	var (
+		runDir     string
+		bundlePath string
+		header     []string
+		records    [][]string
+		csvContent []byte
+	)
+
+	BeforeEach(func() {
+		runDir = filepath.Join(GinkgoT().TempDir(), "2025.03.10_06-00-00")
+		Expect(os.MkdirAll(runDir, 0755)).To(Succeed())
+		bundlePath = filepath.Join(runDir, "export.zip")
+
+		header = []string{"id", "name"}
+		records = [][]string{{"1", "Anna Müller"}, {"2", "Ben Schäfer"}}
+		var buffer bytes.Buffer
+		Expect(csv.Encode(&buffer, header, records)).To(Succeed())
+		csvContent = buffer.Bytes()
+	})
+
+	writeBundle := func(encryption config.Encryption, secret string) {
+		bundle, err := output.NewBundle(bundlePath, runDir, encryption, secret)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundle.Write(filepath.Join(runDir, "foo", "Choir.csv"), header, records)).To(Succeed())
+		Expect(bundle.WriteFile(filepath.Join(runDir, "ctRestClient.log"), []byte("log"))).To(Succeed())
+		Expect(bundle.Close()).To(Succeed())
+
+		// Only the bundle is written to the run directory
+		entries, err := os.ReadDir(runDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+	}
+
+	// readZip returns the content of the entries by their name.
+	readZip := func(content []byte) map[string][]byte {
+		reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+		Expect(err).NotTo(HaveOccurred())
+
+		files := make(map[string][]byte)
+		for _, file := range reader.File {
+			entry, err := file.Open()
+			Expect(err).NotTo(HaveOccurred())
+			files[file.Name], err = io.ReadAll(entry)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entry.Close()).To(Succeed())
+		}
+		return files
+	}
+
+	It("writes an AES encrypted ZIP file", func() {
+		writeBundle(config.Encryption{Format: config.EncryptionZIP}, "correct horse")
+
+		content, err := os.ReadFile(bundlePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readAESZip(content, "correct horse")).To(Equal(map[string][]byte{
+			"foo/Choir.csv":    csvContent,
+			"ctRestClient.log": []byte("log"),
+		}))
+		_, err = readAESZip(content, "wrong")
+		Expect(err).To(MatchError("wrong password"))
+	})
+
+	It("encrypts the ZIP file for an age recipient", func() {
+		identity, err := age.GenerateX25519Identity()
+		Expect(err).NotTo(HaveOccurred())
+
+		writeBundle(config.Encryption{Format: config.EncryptionAge}, identity.Recipient().String()+"\n")
+
+		file, err := os.Open(bundlePath)
+		Expect(err).NotTo(HaveOccurred())
+		defer file.Close()
+		reader, err := age.Decrypt(file, identity)
+		Expect(err).NotTo(HaveOccurred())
+		content, err := io.ReadAll(reader)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readZip(content)).To(Equal(map[string][]byte{
+			"foo/Choir.csv":    csvContent,
+			"ctRestClient.log": []byte("log"),
+		}))
+	})
+
+	It("encrypts the ZIP file for an OpenPGP public key", func() {
+		entity, err := openpgp.NewEntity("Export", "", "export@example.org", nil)
+		Expect(err).NotTo(HaveOccurred())
+		var publicKey strings.Builder
+		armored, err := armor.Encode(&publicKey, openpgp.PublicKeyType, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entity.Serialize(armored)).To(Succeed())
+		Expect(armored.Close()).To(Succeed())
+
+		writeBundle(config.Encryption{Format: config.EncryptionOpenPGP}, publicKey.String())
+
+		file, err := os.Open(bundlePath)
+		Expect(err).NotTo(HaveOccurred())
+		defer file.Close()
+		message, err := openpgp.ReadMessage(file, openpgp.EntityList{entity}, nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		content, err := io.ReadAll(message.UnverifiedBody)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readZip(content)).To(HaveKeyWithValue("foo/Choir.csv", csvContent))
+	})
+
+	It("encrypts the ZIP file with an OpenPGP passphrase", func() {
+		writeBundle(config.Encryption{Format: config.EncryptionOpenPGP}, "correct horse")
+
+		file, err := os.Open(bundlePath)
+		Expect(err).NotTo(HaveOccurred())
+		defer file.Close()
+		prompt := func(keys []openpgp.Key, symmetric bool) ([]byte, error) {
+			return []byte("correct horse"), nil
+		}
+		message, err := openpgp.ReadMessage(file, nil, prompt, nil)
+		Expect(err).NotTo(HaveOccurred())
+		content, err := io.ReadAll(message.UnverifiedBody)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readZip(content)).To(HaveKeyWithValue("foo/Choir.csv", csvContent))
+	})
+
+	It("returns an error if the secret is empty", func() {
+		_, err := output.NewBundle(bundlePath, runDir, config.Encryption{Format: config.EncryptionZIP}, "")
+		Expect(err).To(MatchError("failed to create the bundle, the secret is empty"))
+		Expect(bundlePath).NotTo(BeAnExistingFile())
+	})
+
+	It("returns an error if the age recipient is invalid", func() {
+		_, err := output.NewBundle(bundlePath, runDir, config.Encryption{Format: config.EncryptionAge}, "age1invalid")
+		Expect(err).To(MatchError(ContainSubstring("failed to create the bundle, invalid age recipient")))
+		Expect(bundlePath).NotTo(BeAnExistingFile())
+	})
+
+	It("returns an error if a file is not in the run directory", func() {
+		bundle, err := output.NewBundle(bundlePath, runDir, config.Encryption{Format: config.EncryptionZIP}, "correct horse")
+		Expect(err).NotTo(HaveOccurred())
+		defer bundle.Close()
+
+		err = bundle.WriteFile(filepath.Join(runDir, "..", "other.log"), []byte("log"))
+		Expect(err).To(MatchError(ContainSubstring("is not in the directory")))
+	})
+})
@@ -0,0 +1,77 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// LatestFile is a file of the latest directory.
+type LatestFile struct {
+	// Path is the file in the latest directory.
+	Path string
+	// Source is the file of the run that replaces it. The file is deleted
+	// if the source is empty or does not exist.
+	Source string
+}
+
+// UpdateLatest replaces the files of the latest directory by the files of a
+// run. A file without source is deleted, so that the latest directory never
+// mixes the files of different runs of a group. With secure the replaced
+// files are overwritten first.
+func UpdateLatest(files []LatestFile, secure bool) error {
+	for _, file := range files {
+		sourceExists := file.Source != ""
+		if _, err := os.Stat(file.Source); sourceExists && errors.Is(err, fs.ErrNotExist) {
+			sourceExists = false
+		}
+		if !sourceExists {
+			if err := Remove(file.Path, secure); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := replaceFile(file.Source, file.Path, secure); err != nil {
+			return fmt.Errorf("failed to update '%s', %w", file.Path, err)
+		}
+	}
+	return nil
+}
+
+// replaceFile copies the source next to the target and renames it, so that
+// readers of the target never see a partial file.
+func replaceFile(source string, target string, secure bool) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.CreateTemp(filepath.Dir(target), ".latest-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	if secure {
+		if err := overwrite(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(out.Name(), target)
+}
@@ -0,0 +1,85 @@
+package output_test
+
+import (
+	"ctRestClient/output"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UpdateLatest", func() {
+	var (
+		runDir    string
+		latestDir string
+	)
+
+	BeforeEach(func() {
+		outputDir := GinkgoT().TempDir()
+		runDir = filepath.Join(outputDir, "2025.03.10_06-00-00")
+		latestDir = filepath.Join(outputDir, output.LatestDirName)
+		Expect(os.MkdirAll(filepath.Join(runDir, "foo"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(latestDir, "foo"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(runDir, "foo", "group.csv"), []byte("new"), 0644)).To(Succeed())
+	})
+
+	DescribeTable("replaces the files of the run",
+		func(secure bool) {
+			Expect(os.WriteFile(filepath.Join(latestDir, "foo", "group.csv"), []byte("old content"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(latestDir, "foo", "group.added.csv"), []byte("old"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(latestDir, "foo", "other.csv"), []byte("other"), 0644)).To(Succeed())
+
+			err := output.UpdateLatest([]output.LatestFile{
+				{Path: filepath.Join(latestDir, "foo", "group.csv"), Source: filepath.Join(runDir, "foo", "group.csv")},
+				{Path: filepath.Join(latestDir, "foo", "group.added.csv"), Source: filepath.Join(runDir, "foo", "group.added.csv")},
+			}, secure)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.ReadFile(filepath.Join(latestDir, "foo", "group.csv"))).To(Equal([]byte("new")))
+			Expect(filepath.Join(latestDir, "foo", "group.added.csv")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(latestDir, "foo", "other.csv")).To(BeARegularFile())
+			entries, err := os.ReadDir(filepath.Join(latestDir, "foo"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(2))
+		},
+		Entry("deleted", false),
+		Entry("securely deleted", true),
+	)
+
+	It("creates the latest directory", func() {
+		Expect(os.RemoveAll(latestDir)).To(Succeed())
+
+		err := output.UpdateLatest([]output.LatestFile{
+			{Path: filepath.Join(latestDir, "foo", "group.csv"), Source: filepath.Join(runDir, "foo", "group.csv")},
+			{Path: filepath.Join(latestDir, "foo", "group.blocked.csv")},
+		}, true)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.ReadFile(filepath.Join(latestDir, "foo", "group.csv"))).To(Equal([]byte("new")))
+	})
+})
+
+var _ = Describe("Remove", func() {
+	It("overwrites the files before they are deleted", func() {
+		dir := filepath.Join(GinkgoT().TempDir(), "run")
+		Expect(os.MkdirAll(filepath.Join(dir, "foo"), 0755)).To(Succeed())
+		file := filepath.Join(dir, "foo", "group.csv")
+		Expect(os.WriteFile(file, []byte("personal data"), 0644)).To(Succeed())
+		// A second link keeps the overwritten content readable
+		link := filepath.Join(GinkgoT().TempDir(), "link.csv")
+		Expect(os.Link(file, link)).To(Succeed())
+
+		Expect(output.Remove(dir, true)).To(Succeed())
+
+		Expect(dir).NotTo(BeAnExistingFile())
+		content, err := os.ReadFile(link)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(HaveLen(len("personal data")))
+		Expect(string(content)).NotTo(Equal("personal data"))
+	})
+
+	It("ignores a missing path", func() {
+		Expect(output.Remove(filepath.Join(GinkgoT().TempDir(), "missing"), true)).To(Succeed())
+	})
+})
@@ -0,0 +1,54 @@
+package output
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Remove deletes a file or a directory with all its files. With secure the
+// content of each file is overwritten with random data before it is deleted.
+// On SSDs and copy-on-write file systems copies of the data may remain
+// nonetheless.
+func Remove(path string, secure bool) error {
+	if secure {
+		err := filepath.WalkDir(path, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.Type().IsRegular() {
+				return overwrite(path)
+			}
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to overwrite '%s', %w", path, err)
+		}
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("failed to delete '%s', %w", path, err)
+	}
+	return nil
+}
+
+// overwrite replaces the content of the file by random data of the same
+// size.
+func overwrite(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(file, rand.Reader, info.Size()); err != nil {
+		return err
+	}
+	return file.Sync()
+}
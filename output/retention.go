@@ -0,0 +1,92 @@
+package output
+
+import (
+	"ctRestClient/config"
+	"ctRestClient/logger"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+)
+
+// ApplyRetention deletes the run directories in the output directory that
+// exceed a limit of the retention. The current run is never deleted and
+// counts as one of the kept runs. Files of the latest directory that are
+// older than the kept days are deleted as well. In a dry run the directories
+// are only logged.
+func ApplyRetention(outputDir string, currentRun string, retention config.Retention, now time.Time, dryRun bool, logger logger.Logger) error {
+	if !retention.IsSet() {
+		return nil
+	}
+
+	runs, err := Runs(outputDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read the output directory, %w", err)
+	}
+
+	var previousRuns []Run
+	for _, run := range runs {
+		if filepath.Clean(run.Path) != filepath.Clean(currentRun) {
+			previousRuns = append(previousRuns, run)
+		}
+	}
+
+	cutoff := now.AddDate(0, 0, -retention.KeepDays)
+	var expired []string
+	for i, run := range previousRuns {
+		newerRuns := len(previousRuns) - i
+		tooMany := retention.KeepRuns > 0 && newerRuns >= retention.KeepRuns
+		tooOld := retention.KeepDays > 0 && run.Time.Before(cutoff)
+		if tooMany || tooOld {
+			expired = append(expired, run.Path)
+		}
+	}
+	if retention.KeepDays > 0 {
+		latestFiles, err := filesBefore(filepath.Join(outputDir, LatestDirName), cutoff)
+		if err != nil {
+			return fmt.Errorf("failed to read the latest directory, %w", err)
+		}
+		expired = append(expired, latestFiles...)
+	}
+
+	var errs []error
+	for _, path := range expired {
+		if dryRun {
+			logger.Info(fmt.Sprintf("dry run, '%s' would be deleted by the retention", path))
+			continue
+		}
+		if err := Remove(path, retention.SecureDelete); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		logger.Info(fmt.Sprintf("deleted '%s' by the retention", path))
+	}
+	return errors.Join(errs...)
+}
+
+// filesBefore returns the files in the directory that were last modified
+// before the time.
+func filesBefore(dir string, before time.Time) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == dir {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().Before(before) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,115 @@
+package output_test
+
+import (
+	"ctRestClient/config"
+	"ctRestClient/logger/loggerfakes"
+	"ctRestClient/output"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ApplyRetention", func() {
+	var (
+		outputDir  string
+		currentRun string
+		now        time.Time
+		logger     *loggerfakes.FakeLogger
+	)
+
+	createRun := func(name string) string {
+		path := filepath.Join(outputDir, name)
+		Expect(os.MkdirAll(filepath.Join(path, "foo"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "foo", "group.csv"), []byte("id;name"), 0644)).To(Succeed())
+		return path
+	}
+
+	remainingRuns := func() []string {
+		runs, err := output.Runs(outputDir)
+		Expect(err).NotTo(HaveOccurred())
+		var names []string
+		for _, run := range runs {
+			names = append(names, filepath.Base(run.Path))
+		}
+		return names
+	}
+
+	BeforeEach(func() {
+		outputDir = GinkgoT().TempDir()
+		now = time.Date(2025, 3, 10, 6, 0, 0, 0, time.Local)
+		logger = &loggerfakes.FakeLogger{}
+		createRun("2025.03.01_06-00-00")
+		createRun("2025.03.05_06-00-00")
+		createRun("2025.03.08_06-00-00")
+		currentRun = createRun("2025.03.10_06-00-00")
+		Expect(os.MkdirAll(filepath.Join(outputDir, "notes"), 0755)).To(Succeed())
+	})
+
+	It("keeps the newest runs including the current one", func() {
+		err := output.ApplyRetention(outputDir, currentRun, config.Retention{KeepRuns: 2}, now, false, logger)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(remainingRuns()).To(Equal([]string{"2025.03.08_06-00-00", "2025.03.10_06-00-00"}))
+		Expect(filepath.Join(outputDir, "notes")).To(BeADirectory())
+		Expect(logger.InfoArgsForCall(0)).To(Equal("deleted '" + filepath.Join(outputDir, "2025.03.01_06-00-00") + "' by the retention"))
+	})
+
+	It("keeps the runs of the last days", func() {
+		err := output.ApplyRetention(outputDir, currentRun, config.Retention{KeepDays: 5}, now, false, logger)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(remainingRuns()).To(Equal([]string{"2025.03.05_06-00-00", "2025.03.08_06-00-00", "2025.03.10_06-00-00"}))
+	})
+
+	It("deletes the runs that exceed any limit", func() {
+		err := output.ApplyRetention(outputDir, currentRun, config.Retention{KeepRuns: 3, KeepDays: 3}, now, false, logger)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(remainingRuns()).To(Equal([]string{"2025.03.08_06-00-00", "2025.03.10_06-00-00"}))
+	})
+
+	It("never deletes the current run", func() {
+		err := output.ApplyRetention(outputDir, currentRun, config.Retention{KeepRuns: 1, SecureDelete: true}, now.AddDate(1, 0, 0), false, logger)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(remainingRuns()).To(Equal([]string{"2025.03.10_06-00-00"}))
+		Expect(filepath.Join(currentRun, "foo", "group.csv")).To(BeARegularFile())
+	})
+
+	It("deletes the expired files of the latest directory", func() {
+		latestDir := filepath.Join(outputDir, output.LatestDirName, "foo")
+		Expect(os.MkdirAll(latestDir, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(latestDir, "old.csv"), nil, 0644)).To(Succeed())
+		Expect(os.Chtimes(filepath.Join(latestDir, "old.csv"), now.AddDate(0, 0, -8), now.AddDate(0, 0, -8))).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(latestDir, "new.csv"), nil, 0644)).To(Succeed())
+
+		err := output.ApplyRetention(outputDir, currentRun, config.Retention{KeepDays: 7}, now, false, logger)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(filepath.Join(latestDir, "old.csv")).NotTo(BeAnExistingFile())
+		Expect(filepath.Join(latestDir, "new.csv")).To(BeARegularFile())
+	})
+
+	It("only logs the expired runs in a dry run", func() {
+		err := output.ApplyRetention(outputDir, filepath.Join(outputDir, "2025.03.10_07-00-00"), config.Retention{KeepRuns: 4}, now, true, logger)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(remainingRuns()).To(HaveLen(4))
+		Expect(logger.InfoArgsForCall(0)).To(Equal("dry run, '" + filepath.Join(outputDir, "2025.03.01_06-00-00") + "' would be deleted by the retention"))
+	})
+
+	It("keeps all runs without limits", func() {
+		err := output.ApplyRetention(outputDir, currentRun, config.Retention{SecureDelete: true}, now, false, logger)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(remainingRuns()).To(HaveLen(4))
+	})
+
+	It("ignores a missing output directory", func() {
+		err := output.ApplyRetention(filepath.Join(outputDir, "missing"), currentRun, config.Retention{KeepRuns: 1, KeepDays: 1}, now, true, logger)
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
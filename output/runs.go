@@ -0,0 +1,51 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// RunDirFormat is the time format of the names of the run directories in
+// the output directory.
+const RunDirFormat = "2006.01.02_15-04-05"
+
+// LatestDirName is the name of the directory with the newest files of each
+// group in the output directory.
+const LatestDirName = "latest"
+
+// Run is the directory of a run in the output directory.
+type Run struct {
+	Path string
+	Time time.Time
+}
+
+// Runs returns the run directories in the output directory, the oldest
+// first. Other directories like 'latest' are ignored.
+func Runs(outputDir string) ([]Run, error) {
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []Run
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(outputDir, entry.Name())
+		if runTime, exists := RunTime(path); exists {
+			runs = append(runs, Run{Path: path, Time: runTime})
+		}
+	}
+	slices.SortFunc(runs, func(a, b Run) int { return a.Time.Compare(b.Time) })
+	return runs, nil
+}
+
+// RunTime returns the time of a run directory from its name or false if it
+// is not named like a run directory.
+func RunTime(runDir string) (time.Time, bool) {
+	runTime, err := time.ParseInLocation(RunDirFormat, filepath.Base(runDir), time.Local)
+	return runTime, err == nil
+}
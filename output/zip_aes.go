@@ -0,0 +1,114 @@
+package output
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+)
+
+// The entries of an encrypted ZIP file are written in the WinZip AE-2 format
+// with AES-256, which 7-Zip, WinZip and WinRAR can open. archive/zip writes
+// the encrypted data as raw entries.
+const (
+	// zipMethodAES is the compression method of AES encrypted entries, the
+	// actual method is stored in the extra field.
+	zipMethodAES = 99
+	// zipExtraAES is the ID of the extra field of AES encrypted entries.
+	zipExtraAES = 0x9901
+	// zipVersionAE2 omits the CRC, the authentication code protects the data.
+	zipVersionAE2 = 2
+	// zipStrengthAES256 is the key size of AES-256.
+	zipStrengthAES256 = 3
+	// zipFlagEncrypted marks an encrypted entry.
+	zipFlagEncrypted = 0x1
+
+	aesKeySize        = 32
+	aesSaltSize       = 16
+	aesVerifierSize   = 2
+	aesAuthCodeSize   = 10
+	aesKeyIterations  = 1000
+	aesDerivedKeySize = 2*aesKeySize + aesVerifierSize
+)
+
+// aesEntryHeader returns the header of a raw AES encrypted entry with the
+// content of the size.
+func aesEntryHeader(header *zip.FileHeader, encrypted []byte, size int) *zip.FileHeader {
+	extra := binary.LittleEndian.AppendUint16(nil, zipExtraAES)
+	extra = binary.LittleEndian.AppendUint16(extra, 7)
+	extra = binary.LittleEndian.AppendUint16(extra, zipVersionAE2)
+	extra = append(extra, 'A', 'E', zipStrengthAES256)
+	extra = binary.LittleEndian.AppendUint16(extra, zip.Deflate)
+
+	header.Method = zipMethodAES
+	header.Flags |= zipFlagEncrypted
+	header.Extra = append(header.Extra, extra...)
+	header.CRC32 = 0
+	header.CompressedSize64 = uint64(len(encrypted))
+	header.UncompressedSize64 = uint64(size)
+	return header
+}
+
+// encryptAESEntry compresses the content and encrypts it with the password.
+// The result is the salt, the password verifier, the encrypted data and the
+// authentication code.
+func encryptAESEntry(content []byte, password string) ([]byte, error) {
+	var compressed bytes.Buffer
+	writer, err := flate.NewWriter(&compressed, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(content); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, aesSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	keys, err := pbkdf2.Key(sha1.New, password, salt, aesKeyIterations, aesDerivedKeySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(keys[:aesKeySize])
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted := make([]byte, compressed.Len())
+	xorAESCounter(block, encrypted, compressed.Bytes())
+	mac := hmac.New(sha1.New, keys[aesKeySize:2*aesKeySize])
+	mac.Write(encrypted)
+
+	result := append(salt, keys[2*aesKeySize:]...)
+	result = append(result, encrypted...)
+	return append(result, mac.Sum(nil)[:aesAuthCodeSize]...), nil
+}
+
+// xorAESCounter encrypts in the CTR mode of WinZip, its counter is little
+// endian and starts at 1, unlike the one of cipher.NewCTR.
+func xorAESCounter(block cipher.Block, dst, src []byte) {
+	var counter, keyStream [aes.BlockSize]byte
+	for start := 0; start < len(src); start += len(keyStream) {
+		for i := range counter {
+			counter[i]++
+			if counter[i] != 0 {
+				break
+			}
+		}
+		block.Encrypt(keyStream[:], counter[:])
+		end := min(start+len(keyStream), len(src))
+		for i := start; i < end; i++ {
+			dst[i] = src[i] ^ keyStream[i-start]
+		}
+	}
+}
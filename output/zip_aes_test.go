@@ -0,0 +1,111 @@
+package output_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// readAESZip returns the content of the entries of a ZIP file with WinZip
+// AES-256 encrypted entries by their name. It is written from the format
+// description and checked against a file written by libarchive.
+func readAESZip(content []byte, password string) (map[string][]byte, error) {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	for _, file := range reader.File {
+		if file.Method != 99 || file.Flags&0x1 == 0 {
+			return nil, errors.New("the entry is not AES encrypted")
+		}
+		method, err := aesExtraMethod(file.Extra)
+		if err != nil {
+			return nil, err
+		}
+
+		raw, err := file.OpenRaw()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(raw)
+		if err != nil {
+			return nil, err
+		}
+		salt, verifier, encrypted, authCode := data[:16], data[16:18], data[18:len(data)-10], data[len(data)-10:]
+
+		keys, err := pbkdf2.Key(sha1.New, password, salt, 1000, 66)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(keys[64:], verifier) {
+			return nil, errors.New("wrong password")
+		}
+		mac := hmac.New(sha1.New, keys[32:64])
+		mac.Write(encrypted)
+		if !bytes.Equal(mac.Sum(nil)[:10], authCode) {
+			return nil, errors.New("wrong authentication code")
+		}
+
+		block, err := aes.NewCipher(keys[:32])
+		if err != nil {
+			return nil, err
+		}
+		decrypted := make([]byte, len(encrypted))
+		for i := 0; i < len(encrypted); i += aes.BlockSize {
+			var counter, keyStream [aes.BlockSize]byte
+			binary.LittleEndian.PutUint64(counter[:], uint64(i/aes.BlockSize+1))
+			block.Encrypt(keyStream[:], counter[:])
+			for j := i; j < min(i+aes.BlockSize, len(encrypted)); j++ {
+				decrypted[j] = encrypted[j] ^ keyStream[j-i]
+			}
+		}
+
+		if method == zip.Deflate {
+			decrypted, err = io.ReadAll(flate.NewReader(bytes.NewReader(decrypted)))
+			if err != nil {
+				return nil, err
+			}
+		}
+		files[file.Name] = decrypted
+	}
+	return files, nil
+}
+
+// aesExtraMethod returns the actual compression method of the AES extra
+// field.
+func aesExtraMethod(extra []byte) (uint16, error) {
+	for len(extra) >= 4 {
+		id, size := binary.LittleEndian.Uint16(extra), int(binary.LittleEndian.Uint16(extra[2:]))
+		if id == 0x9901 && size == 7 && string(extra[6:8]) == "AE" && extra[8] == 3 {
+			return binary.LittleEndian.Uint16(extra[9:]), nil
+		}
+		extra = extra[4+size:]
+	}
+	return 0, errors.New("the entry has no AES-256 extra field")
+}
+
+var _ = Describe("readAESZip", func() {
+	It("reads a ZIP file written by libarchive", func() {
+		content, err := os.ReadFile("testdata/libarchive-aes256.zip")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(readAESZip(content, "correct horse")).To(Equal(map[string][]byte{
+			"hello.txt": []byte("written by libarchive\n"),
+		}))
+		_, err = readAESZip(content, "wrong")
+		Expect(err).To(MatchError("wrong password"))
+	})
+})
@@ -0,0 +1,122 @@
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A Rule defines how the value of a field is pseudonymized or masked before
+// it is exported.
+type Rule string
+
+const (
+	// None exports the value unchanged.
+	None Rule = ""
+	// Mask keeps the first character of a value, e.g. 'j***@example.com'
+	// for an email address or 'M***' for a name.
+	Mask Rule = "mask"
+	// Hash replaces a value by a keyed hash. Equal values result in equal
+	// hashes, so persons can still be matched across exports.
+	Hash Rule = "hash"
+	// DropYear removes the year of a date, e.g. '05-17' for '2001-05-17'.
+	DropYear Rule = "drop_year"
+	// Month generalizes a date to its month, e.g. '2001-05' for '2001-05-17'.
+	Month Rule = "month"
+)
+
+const dateLayout = "2006-01-02"
+
+// hashLength is the number of hex characters of a hashed value.
+const hashLength = 16
+
+func ParseRule(value string) (Rule, error) {
+	switch rule := Rule(value); rule {
+	case None, Mask, Hash, DropYear, Month:
+		return rule, nil
+	default:
+		return None, fmt.Errorf("unknown privacy rule '%s'", value)
+	}
+}
+
+func (r *Rule) UnmarshalYAML(node *yaml.Node) error {
+	rule, err := ParseRule(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	*r = rule
+	return nil
+}
+
+// Apply applies the rule to a value. The secret is the key of hashed values.
+// Empty values stay empty.
+func (r Rule) Apply(value string, secret string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	switch r {
+	case None:
+		return value, nil
+	case Mask:
+		return mask(value), nil
+	case Hash:
+		if secret == "" {
+			return "", fmt.Errorf("the secret of hashed values is not set")
+		}
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(value))
+		return hex.EncodeToString(mac.Sum(nil))[:hashLength], nil
+	case DropYear:
+		date, err := time.Parse(dateLayout, value)
+		if err != nil {
+			return "", fmt.Errorf("the value is not a date")
+		}
+		return date.Format("01-02"), nil
+	case Month:
+		date, err := time.Parse(dateLayout, value)
+		if err != nil {
+			return "", fmt.Errorf("the value is not a date")
+		}
+		return date.Format("2006-01"), nil
+	default:
+		return "", fmt.Errorf("unknown privacy rule '%s'", r)
+	}
+}
+
+func mask(value string) string {
+	local, domain, isEmail := strings.Cut(value, "@")
+	if !isEmail {
+		local = value
+	}
+
+	first, _ := utf8.DecodeRuneInString(local)
+	masked := string(first) + "***"
+	if isEmail {
+		masked += "@" + domain
+	}
+	return masked
+}
+
+// A Profile contains the privacy rules that apply to the fields of all
+// exported groups.
+type Profile struct {
+	Rules map[string]Rule
+	// Secret is the key of hashed values.
+	Secret string
+}
+
+// RuleFor returns the rule of a field. The rule of the field itself takes
+// precedence over the rule of the profile.
+func (p Profile) RuleFor(fieldName string, fieldRule Rule) Rule {
+	if fieldRule != None {
+		return fieldRule
+	}
+	return p.Rules[fieldName]
+}
@@ -0,0 +1,87 @@
+package privacy_test
+
+import (
+	"ctRestClient/privacy"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v3"
+)
+
+var _ = Describe("Privacy", func() {
+
+	var _ = Describe("Apply", func() {
+		It("masks email addresses", func() {
+			value, err := privacy.Mask.Apply("john.doe@example.com", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal("j***@example.com"))
+		})
+
+		It("masks other values", func() {
+			value, err := privacy.Mask.Apply("Müller", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal("M***"))
+		})
+
+		It("hashes values with the secret", func() {
+			value, err := privacy.Hash.Apply("john.doe@example.com", "secret")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(HaveLen(16))
+			Expect(value).NotTo(ContainSubstring("john"))
+
+			sameValue, _ := privacy.Hash.Apply("john.doe@example.com", "secret")
+			Expect(sameValue).To(Equal(value))
+
+			otherSecret, _ := privacy.Hash.Apply("john.doe@example.com", "other")
+			Expect(otherSecret).NotTo(Equal(value))
+		})
+
+		It("returns an error if the secret of hashed values is missing", func() {
+			value, err := privacy.Hash.Apply("john.doe@example.com", "")
+			Expect(err).To(MatchError("the secret of hashed values is not set"))
+			Expect(value).To(BeEmpty())
+		})
+
+		It("drops the year of dates", func() {
+			value, err := privacy.DropYear.Apply("2001-05-17", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal("05-17"))
+		})
+
+		It("generalizes dates to the month", func() {
+			value, err := privacy.Month.Apply("2001-05-17", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal("2001-05"))
+		})
+
+		It("returns an empty value for values that are not dates", func() {
+			value, err := privacy.Month.Apply("17.05.2001", "")
+			Expect(err).To(MatchError("the value is not a date"))
+			Expect(value).To(BeEmpty())
+		})
+
+		It("keeps empty values", func() {
+			value, err := privacy.Hash.Apply("", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(BeEmpty())
+		})
+	})
+
+	var _ = Describe("UnmarshalYAML", func() {
+		It("returns an error for unknown rules", func() {
+			var rules map[string]privacy.Rule
+			err := yaml.Unmarshal([]byte("email: hide\n"), &rules)
+			Expect(err).To(MatchError("line 1: unknown privacy rule 'hide'"))
+		})
+	})
+
+	var _ = Describe("RuleFor", func() {
+		It("prefers the rule of the field over the profile", func() {
+			profile := privacy.Profile{Rules: map[string]privacy.Rule{"email": privacy.Hash, "birthday": privacy.Month}}
+
+			Expect(profile.RuleFor("email", privacy.Mask)).To(Equal(privacy.Mask))
+			Expect(profile.RuleFor("birthday", privacy.None)).To(Equal(privacy.Month))
+			Expect(profile.RuleFor("lastName", privacy.None)).To(Equal(privacy.None))
+		})
+	})
+})
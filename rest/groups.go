@@ -15,6 +15,12 @@ type GroupsEndpoint interface {
 	GetGroupMembers(groupID int) ([]GroupsMembersResponse, error)
 
 	GetGroup(groupName string) (GroupsResponse, error)
+
+	GetAllGroups() ([]GroupsResponse, error)
+
+	GetGroupTypes() ([]GroupTypeResponse, error)
+
+	GetMemberCounts(groupIDs []int) (map[int]int, error)
 }
 
 type groupsEndpoint struct {
@@ -110,3 +116,92 @@ func (c groupsEndpoint) GetGroup(groupName string) (GroupsResponse, error) {
 
 	return groups[0], nil
 }
+
+const groupsPageSize = 100
+
+// GetAllGroups returns all groups that are visible for the token. The groups
+// are requested page by page.
+func (c groupsEndpoint) GetAllGroups() ([]GroupsResponse, error) {
+	var result []GroupsResponse
+
+	for page := 1; ; page++ {
+		params := url.Values{}
+		params.Add("page", fmt.Sprintf("%d", page))
+		params.Add("limit", fmt.Sprintf("%d", groupsPageSize))
+
+		var response GroupsResponseJson
+		if err := c.get("/api/groups", params, &response); err != nil {
+			return nil, err
+		}
+		result = append(result, response.Data...)
+
+		if len(response.Data) == 0 || page >= response.Meta.Pagination.LastPage {
+			return result, nil
+		}
+	}
+}
+
+func (c groupsEndpoint) GetGroupTypes() ([]GroupTypeResponse, error) {
+	var response GroupTypesResponseJson
+	if err := c.get("/api/group/grouptypes", url.Values{}, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetMemberCounts returns the number of members by group id. The members are
+// requested in batches of groups to keep the request urls short.
+func (c groupsEndpoint) GetMemberCounts(groupIDs []int) (map[int]int, error) {
+	result := make(map[int]int, len(groupIDs))
+
+	for start := 0; start < len(groupIDs); start += groupsPageSize {
+		end := min(start+groupsPageSize, len(groupIDs))
+
+		params := url.Values{}
+		for _, groupID := range groupIDs[start:end] {
+			params.Add("ids[]", fmt.Sprintf("%d", groupID))
+			result[groupID] = 0
+		}
+		params.Add("with_deleted", "false")
+
+		var response GroupsMembersResponseJson
+		if err := c.get("/api/groups/members", params, &response); err != nil {
+			return nil, err
+		}
+		for _, member := range response.Data {
+			result[member.GroupId]++
+		}
+	}
+
+	return result, nil
+}
+
+func (c groupsEndpoint) get(path string, params url.Values, response any) error {
+	req, err := http.NewRequest("GET", "", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request, %w", err)
+	}
+
+	req.URL.Path = path
+	req.URL.RawQuery = params.Encode()
+
+	resp, err := c.httpclient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request, %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("received non-200 response code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body, %w", err)
+	}
+
+	if err := json.Unmarshal(body, response); err != nil {
+		return fmt.Errorf("response body is not containing expected json, %w", err)
+	}
+	return nil
+}
@@ -1,12 +1,53 @@
 package rest
 
+import (
+    "encoding/json"
+    "fmt"
+)
+
 type GroupsResponseJson struct {
     Data []GroupsResponse `json:"data"`
+    Meta struct {
+        Pagination struct {
+            LastPage int `json:"lastPage"`
+        } `json:"pagination"`
+    } `json:"meta"`
 }
 
 type GroupsResponse struct {
+    ID          int              `json:"id"`
+    GUID        string           `json:"guid"`
+    Name        string           `json:"name"`
+    Information GroupInformation `json:"information"`
+}
+
+type GroupInformation struct {
+    GroupTypeId   int `json:"groupTypeId"`
+    GroupStatusId int `json:"groupStatusId"`
+}
+
+// Status returns the name of the ChurchTools group status.
+func (r GroupsResponse) Status() string {
+    switch r.Information.GroupStatusId {
+    case 1:
+        return "active"
+    case 2:
+        return "pending"
+    case 3:
+        return "archived"
+    case 4:
+        return "ended"
+    default:
+        return fmt.Sprintf("status %d", r.Information.GroupStatusId)
+    }
+}
+
+type GroupTypesResponseJson struct {
+    Data []GroupTypeResponse `json:"data"`
+}
+
+type GroupTypeResponse struct {
     ID   int    `json:"id"`
-    GUID string `json:"guid"`
     Name string `json:"name"`
 }
 
@@ -20,4 +61,21 @@ type GroupsMembersResponse struct {
     GroupTypeRoleId   int    `json:"groupTypeRoleId"`
     GroupMemberStatus string `json:"groupMemberStatus"`
     Deleted           bool   `json:"deleted"`
+
+    // Raw contains the complete member json including the membership
+    // attributes and the group specific fields.
+    Raw json.RawMessage `json:"-"`
+}
+
+func (r *GroupsMembersResponse) UnmarshalJSON(data []byte) error {
+    type groupsMembersResponse GroupsMembersResponse
+
+    var response groupsMembersResponse
+    if err := json.Unmarshal(data, &response); err != nil {
+        return err
+    }
+
+    *r = GroupsMembersResponse(response)
+    r.Raw = append(json.RawMessage(nil), data...)
+    return nil
 }
@@ -2,6 +2,7 @@ package rest_test
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 
@@ -183,6 +184,34 @@ var _ = Describe("GroupsEndpoint", func() {
 			Expect(resp[1].PersonId).To(Equal(2))
 		})
 
+		It("keeps the raw member json", func() {
+			httpResponse := &http.Response{
+				StatusCode: 200,
+				Body: io.NopCloser(testutil.JsonToBufferString(
+					`{
+						"data": [
+							{
+								"personId": 1,
+								"groupId": 71,
+								"memberStartDate": "2024-01-01",
+								"comment": "foo"
+							}
+						]
+					}`))}
+			httpClient.DoReturns(httpResponse, nil)
+
+			groupsEndpoint := rest.NewGroupsEndpoint(httpClient)
+			resp, err := groupsEndpoint.GetGroupMembers(10)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp[0].PersonId).To(Equal(1))
+			Expect(resp[0].Raw).To(MatchJSON(`{
+				"personId": 1,
+				"groupId": 71,
+				"memberStartDate": "2024-01-01",
+				"comment": "foo"
+			}`))
+		})
+
 		It("returns an error if the request cannot be send", func() {
 			httpClient.DoReturns(nil, errors.New("request failed"))
 
@@ -228,4 +257,101 @@ var _ = Describe("GroupsEndpoint", func() {
 		})
 	})
 
+	var _ = Describe("GetAllGroups", func() {
+		groupsPage := func(page int, lastPage int, name string) *http.Response {
+			return &http.Response{
+				StatusCode: 200,
+				Body: io.NopCloser(testutil.JsonToBufferString(fmt.Sprintf(
+					`{
+						"data": [
+							{
+								"id": %d,
+								"name": "%s",
+								"information": { "groupTypeId": 1, "groupStatusId": 3 }
+							}
+						],
+						"meta": { "pagination": { "current": %d, "lastPage": %d } }
+					}`, page, name, page, lastPage))),
+			}
+		}
+
+		It("returns the groups of all pages", func() {
+			httpClient.DoReturnsOnCall(0, groupsPage(1, 2, "group1"), nil)
+			httpClient.DoReturnsOnCall(1, groupsPage(2, 2, "group2"), nil)
+
+			groupsEndpoint := rest.NewGroupsEndpoint(httpClient)
+			groups, err := groupsEndpoint.GetAllGroups()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(groups).To(HaveLen(2))
+			Expect(groups[0].Name).To(Equal("group1"))
+			Expect(groups[1].Name).To(Equal("group2"))
+			Expect(groups[1].Information.GroupTypeId).To(Equal(1))
+			Expect(groups[1].Status()).To(Equal("archived"))
+
+			Expect(httpClient.DoCallCount()).To(Equal(2))
+			request := httpClient.DoArgsForCall(1)
+			Expect(request.URL.Path).To(Equal("/api/groups"))
+			Expect(request.URL.RawQuery).To(Equal("limit=100&page=2"))
+		})
+
+		It("returns an error if a page cannot be requested", func() {
+			httpClient.DoReturnsOnCall(0, groupsPage(1, 2, "group1"), nil)
+			httpClient.DoReturnsOnCall(1, nil, errors.New("request failed"))
+
+			groupsEndpoint := rest.NewGroupsEndpoint(httpClient)
+			_, err := groupsEndpoint.GetAllGroups()
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("failed to send request, request failed"))
+		})
+	})
+
+	var _ = Describe("GetGroupTypes", func() {
+		It("returns the group types", func() {
+			httpClient.DoReturns(&http.Response{
+				StatusCode: 200,
+				Body: io.NopCloser(testutil.JsonToBufferString(
+					`{
+						"data": [
+							{ "id": 1, "name": "Kleingruppe" },
+							{ "id": 2, "name": "Dienst" }
+						]
+					}`)),
+			}, nil)
+
+			groupsEndpoint := rest.NewGroupsEndpoint(httpClient)
+			groupTypes, err := groupsEndpoint.GetGroupTypes()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(groupTypes).To(Equal([]rest.GroupTypeResponse{{ID: 1, Name: "Kleingruppe"}, {ID: 2, Name: "Dienst"}}))
+			Expect(httpClient.DoArgsForCall(0).URL.Path).To(Equal("/api/group/grouptypes"))
+		})
+	})
+
+	var _ = Describe("GetMemberCounts", func() {
+		It("returns the number of members by group", func() {
+			httpClient.DoReturns(&http.Response{
+				StatusCode: 200,
+				Body: io.NopCloser(testutil.JsonToBufferString(
+					`{
+						"data": [
+							{ "personId": 1, "groupId": 10 },
+							{ "personId": 2, "groupId": 10 },
+							{ "personId": 2, "groupId": 11 }
+						]
+					}`)),
+			}, nil)
+
+			groupsEndpoint := rest.NewGroupsEndpoint(httpClient)
+			memberCounts, err := groupsEndpoint.GetMemberCounts([]int{10, 11, 12})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(memberCounts).To(Equal(map[int]int{10: 2, 11: 1, 12: 0}))
+
+			request := httpClient.DoArgsForCall(0)
+			Expect(request.URL.Path).To(Equal("/api/groups/members"))
+			Expect(request.URL.RawQuery).To(Equal("ids%5B%5D=10&ids%5B%5D=11&ids%5B%5D=12&with_deleted=false"))
+		})
+	})
 })
@@ -13,27 +13,119 @@ import (
 //counterfeiter:generate . PersonsEndpoint
 type PersonsEndpoint interface {
     GetPerson(personId int) ([]json.RawMessage, error)
+    GetPersons(personIds []int) ([]json.RawMessage, error)
+    GetFirstPersons(limit int) ([]json.RawMessage, error)
+    GetRelationships(personId int) ([]PersonRelationshipResponse, error)
 }
 
 type personsEndpoint struct {
-    httpclient httpclient.HTTPClient
+    httpclient    httpclient.HTTPClient
+    relationships map[int][]PersonRelationshipResponse
 }
 
 func NewPersonsEndpoint(httpclient httpclient.HTTPClient) PersonsEndpoint {
     return personsEndpoint{
-        httpclient: httpclient,
+        httpclient:    httpclient,
+        relationships: make(map[int][]PersonRelationshipResponse),
     }
 }
 
 func (c personsEndpoint) GetPerson(personId int) ([]json.RawMessage, error) {
+    params := url.Values{}
+    params.Add("ids[]", fmt.Sprintf("%d", personId))
+
+    return c.getPersons(params)
+}
+
+// GetPersons returns the persons with the given ids. The ids are requested
+// in batches to keep the request urls short.
+func (c personsEndpoint) GetPersons(personIds []int) ([]json.RawMessage, error) {
+    var result []json.RawMessage
+
+    for start := 0; start < len(personIds); start += personsBatchSize {
+        end := min(start+personsBatchSize, len(personIds))
+
+        params := url.Values{}
+        for _, personId := range personIds[start:end] {
+            params.Add("ids[]", fmt.Sprintf("%d", personId))
+        }
+        params.Add("limit", fmt.Sprintf("%d", end-start))
+
+        persons, err := c.getPersons(params)
+        if err != nil {
+            return nil, err
+        }
+        result = append(result, persons...)
+    }
+
+    return result, nil
+}
+
+// GetFirstPersons returns up to limit persons visible for the token, e.g. as
+// a sample of the available person fields.
+func (c personsEndpoint) GetFirstPersons(limit int) ([]json.RawMessage, error) {
+    params := url.Values{}
+    params.Add("page", "1")
+    params.Add("limit", fmt.Sprintf("%d", limit))
 
+    return c.getPersons(params)
+}
+
+// GetRelationships returns the relationships of a person. ChurchTools only
+// returns them per person, so they are cached and a person that is a member
+// of several groups is requested once.
+func (c personsEndpoint) GetRelationships(personId int) ([]PersonRelationshipResponse, error) {
+    if relationships, ok := c.relationships[personId]; ok {
+        return relationships, nil
+    }
+
+    relationships, err := c.getRelationships(personId)
+    if err != nil {
+        return nil, err
+    }
+    c.relationships[personId] = relationships
+    return relationships, nil
+}
+
+func (c personsEndpoint) getRelationships(personId int) ([]PersonRelationshipResponse, error) {
+    req, err := http.NewRequest("GET", "", nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create request, %w", err)
+    }
+
+    req.URL.Path = fmt.Sprintf("/api/persons/%d/relationships", personId)
+
+    resp, err := c.httpclient.Do(req)
+    if err != nil {
+        return nil, fmt.Errorf("failed to send request, %w", err)
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("received non-200 response code: %d", resp.StatusCode)
+    }
+
+    body, err := io.ReadAll(resp.Body)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read response body, %w", err)
+    }
+
+    var response PersonRelationshipsResponseJson
+    if err := json.Unmarshal(body, &response); err != nil {
+        return nil, fmt.Errorf("response body is not containing expected json, %w", err)
+    }
+
+    return response.Data, nil
+}
+
+const personsBatchSize = 100
+
+func (c personsEndpoint) getPersons(params url.Values) ([]json.RawMessage, error) {
     req, err := http.NewRequest("GET", "", nil)
     if err != nil {
         return nil, fmt.Errorf("failed to create request, %w", err)
     }
 
-    params := url.Values{}
-    params.Add("ids[]", fmt.Sprintf("%d", personId))
     encodedQueryParam := params.Encode()
 
     req.URL.Path = "/api/persons"
@@ -2,8 +2,46 @@ package rest
 
 import (
     "encoding/json"
+    "strconv"
+    "strings"
 )
 
 type PersonResponseJson struct {
     Data []json.RawMessage `json:"data"`
 }
+
+type PersonRelationshipsResponseJson struct {
+    Data []PersonRelationshipResponse `json:"data"`
+}
+
+type PersonRelationshipResponse struct {
+    RelationshipTypeId   int              `json:"relationshipTypeId"`
+    RelationshipName     string           `json:"relationshipName"`
+    DegreeOfRelationship string           `json:"degreeOfRelationship"`
+    Relative             RelativeResponse `json:"relative"`
+}
+
+type RelativeResponse struct {
+    Title            string `json:"title"`
+    DomainType       string `json:"domainType"`
+    DomainIdentifier string `json:"domainIdentifier"`
+}
+
+// RelationType returns the kind of the relative, e.g. 'parent', 'child' or
+// 'spouse' for a degree of relationship like 'relationship.part.parent'.
+func (r PersonRelationshipResponse) RelationType() string {
+    parts := strings.Split(r.DegreeOfRelationship, ".")
+    return parts[len(parts)-1]
+}
+
+// PersonId returns the id of the relative or 0 if the relative is not a person.
+func (r PersonRelationshipResponse) PersonId() int {
+    if r.Relative.DomainType != "person" {
+        return 0
+    }
+    id, err := strconv.Atoi(r.Relative.DomainIdentifier)
+    if err != nil {
+        return 0
+    }
+    return id
+}
@@ -97,4 +97,141 @@ var _ = Describe("PersonsEndpoint", func() {
             Expect(err.Error()).To(ContainSubstring("response body is not containing expected json"))
         })
     })
+
+    var _ = Describe("GetPersons", func() {
+        It("requests the persons in batches", func() {
+            httpClient.DoStub = func(req *http.Request) (*http.Response, error) {
+                return &http.Response{
+                    StatusCode: 200,
+                    Body: io.NopCloser(bytes.NewBufferString(
+                        `{"data": [{"id": 1}]}`))}, nil
+            }
+
+            personIds := make([]int, 150)
+            for i := range personIds {
+                personIds[i] = i + 1
+            }
+
+            personsEndpoint := rest.NewPersonsEndpoint(httpClient)
+            resp, err := personsEndpoint.GetPersons(personIds)
+            Expect(err).NotTo(HaveOccurred())
+            Expect(resp).To(HaveLen(2))
+            Expect(httpClient.DoCallCount()).To(Equal(2))
+
+            request := httpClient.DoArgsForCall(0)
+            Expect(request.URL.Path).To(Equal("/api/persons"))
+            Expect(request.URL.Query()["ids[]"]).To(HaveLen(100))
+            Expect(request.URL.Query().Get("limit")).To(Equal("100"))
+
+            request = httpClient.DoArgsForCall(1)
+            Expect(request.URL.Query()["ids[]"]).To(HaveLen(50))
+            Expect(request.URL.Query().Get("limit")).To(Equal("50"))
+        })
+
+        It("returns an error if the request cannot be send", func() {
+            httpClient.DoReturns(nil, errors.New("request failed"))
+
+            personsEndpoint := rest.NewPersonsEndpoint(httpClient)
+            _, err := personsEndpoint.GetPersons([]int{1, 2})
+            Expect(err).To(HaveOccurred())
+            Expect(err.Error()).To(Equal("failed to send request, request failed"))
+        })
+    })
+
+    var _ = Describe("GetFirstPersons", func() {
+        It("requests the first page of persons", func() {
+            httpClient.DoReturns(&http.Response{
+                StatusCode: 200,
+                Body: io.NopCloser(bytes.NewBufferString(
+                    `{"data": [{"id": 1}, {"id": 2}]}`))}, nil)
+
+            personsEndpoint := rest.NewPersonsEndpoint(httpClient)
+            resp, err := personsEndpoint.GetFirstPersons(2)
+            Expect(err).NotTo(HaveOccurred())
+            Expect(resp).To(HaveLen(2))
+
+            request := httpClient.DoArgsForCall(0)
+            Expect(request.URL.Path).To(Equal("/api/persons"))
+            Expect(request.URL.RawQuery).To(Equal("limit=2&page=1"))
+        })
+    })
+
+    var _ = Describe("GetRelationships", func() {
+        It("returns the relationships of a person", func() {
+            httpResponse := &http.Response{
+                StatusCode: 200,
+                Body: io.NopCloser(bytes.NewBufferString(
+                    `{
+                        "data": [
+                            {
+                                "relationshipTypeId": 1,
+                                "relationshipName": "Elternteil",
+                                "degreeOfRelationship": "relationship.part.parent",
+                                "relative": {
+                                    "title": "Jane Doe",
+                                    "domainType": "person",
+                                    "domainIdentifier": "42"
+                                }
+                            }
+                        ]
+                    }`))}
+            httpClient.DoReturns(httpResponse, nil)
+
+            personsEndpoint := rest.NewPersonsEndpoint(httpClient)
+            resp, err := personsEndpoint.GetRelationships(5)
+            Expect(err).NotTo(HaveOccurred())
+            Expect(resp).To(HaveLen(1))
+            Expect(resp[0].RelationType()).To(Equal("parent"))
+            Expect(resp[0].PersonId()).To(Equal(42))
+
+            request := httpClient.DoArgsForCall(0)
+            Expect(request.URL.Path).To(Equal("/api/persons/5/relationships"))
+        })
+
+        It("requests the relationships of a person once", func() {
+            httpClient.DoStub = func(*http.Request) (*http.Response, error) {
+                return &http.Response{
+                    StatusCode: 200,
+                    Body:       io.NopCloser(bytes.NewBufferString(`{"data": []}`))}, nil
+            }
+
+            personsEndpoint := rest.NewPersonsEndpoint(httpClient)
+            for _, personId := range []int{5, 6, 5} {
+                _, err := personsEndpoint.GetRelationships(personId)
+                Expect(err).NotTo(HaveOccurred())
+            }
+
+            Expect(httpClient.DoCallCount()).To(Equal(2))
+            Expect(httpClient.DoArgsForCall(1).URL.Path).To(Equal("/api/persons/6/relationships"))
+        })
+
+        It("requests the relationships again after an error", func() {
+            httpClient.DoReturnsOnCall(0, &http.Response{
+                StatusCode: 500,
+                Body:       io.NopCloser(bytes.NewBufferString(`{}`))}, nil)
+            httpClient.DoReturnsOnCall(1, &http.Response{
+                StatusCode: 200,
+                Body:       io.NopCloser(bytes.NewBufferString(`{"data": []}`))}, nil)
+
+            personsEndpoint := rest.NewPersonsEndpoint(httpClient)
+            _, err := personsEndpoint.GetRelationships(5)
+            Expect(err).To(HaveOccurred())
+            _, err = personsEndpoint.GetRelationships(5)
+            Expect(err).NotTo(HaveOccurred())
+
+            Expect(httpClient.DoCallCount()).To(Equal(2))
+        })
+
+        It("returns an error if the status code is wrong", func() {
+            httpResponse := &http.Response{
+                StatusCode: 404,
+                Body:       io.NopCloser(bytes.NewBufferString(`{}`))}
+            httpClient.DoReturns(httpResponse, nil)
+
+            personsEndpoint := rest.NewPersonsEndpoint(httpClient)
+            _, err := personsEndpoint.GetRelationships(5)
+            Expect(err).To(HaveOccurred())
+            Expect(err.Error()).To(Equal("received non-200 response code: 404"))
+        })
+    })
 })
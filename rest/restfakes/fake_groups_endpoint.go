@@ -7,6 +7,18 @@ import (
 )
 
 type FakeGroupsEndpoint struct {
+	GetAllGroupsStub        func() ([]rest.GroupsResponse, error)
+	getAllGroupsMutex       sync.RWMutex
+	getAllGroupsArgsForCall []struct {
+	}
+	getAllGroupsReturns struct {
+		result1 []rest.GroupsResponse
+		result2 error
+	}
+	getAllGroupsReturnsOnCall map[int]struct {
+		result1 []rest.GroupsResponse
+		result2 error
+	}
 	GetGroupStub        func(string) (rest.GroupsResponse, error)
 	getGroupMutex       sync.RWMutex
 	getGroupArgsForCall []struct {
@@ -33,10 +45,91 @@ type FakeGroupsEndpoint struct {
 		result1 []rest.GroupsMembersResponse
 		result2 error
 	}
+	GetGroupTypesStub        func() ([]rest.GroupTypeResponse, error)
+	getGroupTypesMutex       sync.RWMutex
+	getGroupTypesArgsForCall []struct {
+	}
+	getGroupTypesReturns struct {
+		result1 []rest.GroupTypeResponse
+		result2 error
+	}
+	getGroupTypesReturnsOnCall map[int]struct {
+		result1 []rest.GroupTypeResponse
+		result2 error
+	}
+	GetMemberCountsStub        func([]int) (map[int]int, error)
+	getMemberCountsMutex       sync.RWMutex
+	getMemberCountsArgsForCall []struct {
+		arg1 []int
+	}
+	getMemberCountsReturns struct {
+		result1 map[int]int
+		result2 error
+	}
+	getMemberCountsReturnsOnCall map[int]struct {
+		result1 map[int]int
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeGroupsEndpoint) GetAllGroups() ([]rest.GroupsResponse, error) {
+	fake.getAllGroupsMutex.Lock()
+	ret, specificReturn := fake.getAllGroupsReturnsOnCall[len(fake.getAllGroupsArgsForCall)]
+	fake.getAllGroupsArgsForCall = append(fake.getAllGroupsArgsForCall, struct {
+	}{})
+	stub := fake.GetAllGroupsStub
+	fakeReturns := fake.getAllGroupsReturns
+	fake.recordInvocation("GetAllGroups", []interface{}{})
+	fake.getAllGroupsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGroupsEndpoint) GetAllGroupsCallCount() int {
+	fake.getAllGroupsMutex.RLock()
+	defer fake.getAllGroupsMutex.RUnlock()
+	return len(fake.getAllGroupsArgsForCall)
+}
+
+func (fake *FakeGroupsEndpoint) GetAllGroupsCalls(stub func() ([]rest.GroupsResponse, error)) {
+	fake.getAllGroupsMutex.Lock()
+	defer fake.getAllGroupsMutex.Unlock()
+	fake.GetAllGroupsStub = stub
+}
+
+func (fake *FakeGroupsEndpoint) GetAllGroupsReturns(result1 []rest.GroupsResponse, result2 error) {
+	fake.getAllGroupsMutex.Lock()
+	defer fake.getAllGroupsMutex.Unlock()
+	fake.GetAllGroupsStub = nil
+	fake.getAllGroupsReturns = struct {
+		result1 []rest.GroupsResponse
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGroupsEndpoint) GetAllGroupsReturnsOnCall(i int, result1 []rest.GroupsResponse, result2 error) {
+	fake.getAllGroupsMutex.Lock()
+	defer fake.getAllGroupsMutex.Unlock()
+	fake.GetAllGroupsStub = nil
+	if fake.getAllGroupsReturnsOnCall == nil {
+		fake.getAllGroupsReturnsOnCall = make(map[int]struct {
+			result1 []rest.GroupsResponse
+			result2 error
+		})
+	}
+	fake.getAllGroupsReturnsOnCall[i] = struct {
+		result1 []rest.GroupsResponse
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeGroupsEndpoint) GetGroup(arg1 string) (rest.GroupsResponse, error) {
 	fake.getGroupMutex.Lock()
 	ret, specificReturn := fake.getGroupReturnsOnCall[len(fake.getGroupArgsForCall)]
@@ -165,13 +258,144 @@ func (fake *FakeGroupsEndpoint) GetGroupMembersReturnsOnCall(i int, result1 []re
 	}{result1, result2}
 }
 
+func (fake *FakeGroupsEndpoint) GetGroupTypes() ([]rest.GroupTypeResponse, error) {
+	fake.getGroupTypesMutex.Lock()
+	ret, specificReturn := fake.getGroupTypesReturnsOnCall[len(fake.getGroupTypesArgsForCall)]
+	fake.getGroupTypesArgsForCall = append(fake.getGroupTypesArgsForCall, struct {
+	}{})
+	stub := fake.GetGroupTypesStub
+	fakeReturns := fake.getGroupTypesReturns
+	fake.recordInvocation("GetGroupTypes", []interface{}{})
+	fake.getGroupTypesMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGroupsEndpoint) GetGroupTypesCallCount() int {
+	fake.getGroupTypesMutex.RLock()
+	defer fake.getGroupTypesMutex.RUnlock()
+	return len(fake.getGroupTypesArgsForCall)
+}
+
+func (fake *FakeGroupsEndpoint) GetGroupTypesCalls(stub func() ([]rest.GroupTypeResponse, error)) {
+	fake.getGroupTypesMutex.Lock()
+	defer fake.getGroupTypesMutex.Unlock()
+	fake.GetGroupTypesStub = stub
+}
+
+func (fake *FakeGroupsEndpoint) GetGroupTypesReturns(result1 []rest.GroupTypeResponse, result2 error) {
+	fake.getGroupTypesMutex.Lock()
+	defer fake.getGroupTypesMutex.Unlock()
+	fake.GetGroupTypesStub = nil
+	fake.getGroupTypesReturns = struct {
+		result1 []rest.GroupTypeResponse
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGroupsEndpoint) GetGroupTypesReturnsOnCall(i int, result1 []rest.GroupTypeResponse, result2 error) {
+	fake.getGroupTypesMutex.Lock()
+	defer fake.getGroupTypesMutex.Unlock()
+	fake.GetGroupTypesStub = nil
+	if fake.getGroupTypesReturnsOnCall == nil {
+		fake.getGroupTypesReturnsOnCall = make(map[int]struct {
+			result1 []rest.GroupTypeResponse
+			result2 error
+		})
+	}
+	fake.getGroupTypesReturnsOnCall[i] = struct {
+		result1 []rest.GroupTypeResponse
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGroupsEndpoint) GetMemberCounts(arg1 []int) (map[int]int, error) {
+	var arg1Copy []int
+	if arg1 != nil {
+		arg1Copy = make([]int, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.getMemberCountsMutex.Lock()
+	ret, specificReturn := fake.getMemberCountsReturnsOnCall[len(fake.getMemberCountsArgsForCall)]
+	fake.getMemberCountsArgsForCall = append(fake.getMemberCountsArgsForCall, struct {
+		arg1 []int
+	}{arg1Copy})
+	stub := fake.GetMemberCountsStub
+	fakeReturns := fake.getMemberCountsReturns
+	fake.recordInvocation("GetMemberCounts", []interface{}{arg1Copy})
+	fake.getMemberCountsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGroupsEndpoint) GetMemberCountsCallCount() int {
+	fake.getMemberCountsMutex.RLock()
+	defer fake.getMemberCountsMutex.RUnlock()
+	return len(fake.getMemberCountsArgsForCall)
+}
+
+func (fake *FakeGroupsEndpoint) GetMemberCountsCalls(stub func([]int) (map[int]int, error)) {
+	fake.getMemberCountsMutex.Lock()
+	defer fake.getMemberCountsMutex.Unlock()
+	fake.GetMemberCountsStub = stub
+}
+
+func (fake *FakeGroupsEndpoint) GetMemberCountsArgsForCall(i int) []int {
+	fake.getMemberCountsMutex.RLock()
+	defer fake.getMemberCountsMutex.RUnlock()
+	argsForCall := fake.getMemberCountsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeGroupsEndpoint) GetMemberCountsReturns(result1 map[int]int, result2 error) {
+	fake.getMemberCountsMutex.Lock()
+	defer fake.getMemberCountsMutex.Unlock()
+	fake.GetMemberCountsStub = nil
+	fake.getMemberCountsReturns = struct {
+		result1 map[int]int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGroupsEndpoint) GetMemberCountsReturnsOnCall(i int, result1 map[int]int, result2 error) {
+	fake.getMemberCountsMutex.Lock()
+	defer fake.getMemberCountsMutex.Unlock()
+	fake.GetMemberCountsStub = nil
+	if fake.getMemberCountsReturnsOnCall == nil {
+		fake.getMemberCountsReturnsOnCall = make(map[int]struct {
+			result1 map[int]int
+			result2 error
+		})
+	}
+	fake.getMemberCountsReturnsOnCall[i] = struct {
+		result1 map[int]int
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeGroupsEndpoint) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.getAllGroupsMutex.RLock()
+	defer fake.getAllGroupsMutex.RUnlock()
 	fake.getGroupMutex.RLock()
 	defer fake.getGroupMutex.RUnlock()
 	fake.getGroupMembersMutex.RLock()
 	defer fake.getGroupMembersMutex.RUnlock()
+	fake.getGroupTypesMutex.RLock()
+	defer fake.getGroupTypesMutex.RUnlock()
+	fake.getMemberCountsMutex.RLock()
+	defer fake.getMemberCountsMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -8,6 +8,19 @@ import (
 )
 
 type FakePersonsEndpoint struct {
+	GetFirstPersonsStub        func(int) ([]json.RawMessage, error)
+	getFirstPersonsMutex       sync.RWMutex
+	getFirstPersonsArgsForCall []struct {
+		arg1 int
+	}
+	getFirstPersonsReturns struct {
+		result1 []json.RawMessage
+		result2 error
+	}
+	getFirstPersonsReturnsOnCall map[int]struct {
+		result1 []json.RawMessage
+		result2 error
+	}
 	GetPersonStub        func(int) ([]json.RawMessage, error)
 	getPersonMutex       sync.RWMutex
 	getPersonArgsForCall []struct {
@@ -21,10 +34,100 @@ type FakePersonsEndpoint struct {
 		result1 []json.RawMessage
 		result2 error
 	}
+	GetPersonsStub        func([]int) ([]json.RawMessage, error)
+	getPersonsMutex       sync.RWMutex
+	getPersonsArgsForCall []struct {
+		arg1 []int
+	}
+	getPersonsReturns struct {
+		result1 []json.RawMessage
+		result2 error
+	}
+	getPersonsReturnsOnCall map[int]struct {
+		result1 []json.RawMessage
+		result2 error
+	}
+	GetRelationshipsStub        func(int) ([]rest.PersonRelationshipResponse, error)
+	getRelationshipsMutex       sync.RWMutex
+	getRelationshipsArgsForCall []struct {
+		arg1 int
+	}
+	getRelationshipsReturns struct {
+		result1 []rest.PersonRelationshipResponse
+		result2 error
+	}
+	getRelationshipsReturnsOnCall map[int]struct {
+		result1 []rest.PersonRelationshipResponse
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakePersonsEndpoint) GetFirstPersons(arg1 int) ([]json.RawMessage, error) {
+	fake.getFirstPersonsMutex.Lock()
+	ret, specificReturn := fake.getFirstPersonsReturnsOnCall[len(fake.getFirstPersonsArgsForCall)]
+	fake.getFirstPersonsArgsForCall = append(fake.getFirstPersonsArgsForCall, struct {
+		arg1 int
+	}{arg1})
+	stub := fake.GetFirstPersonsStub
+	fakeReturns := fake.getFirstPersonsReturns
+	fake.recordInvocation("GetFirstPersons", []interface{}{arg1})
+	fake.getFirstPersonsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakePersonsEndpoint) GetFirstPersonsCallCount() int {
+	fake.getFirstPersonsMutex.RLock()
+	defer fake.getFirstPersonsMutex.RUnlock()
+	return len(fake.getFirstPersonsArgsForCall)
+}
+
+func (fake *FakePersonsEndpoint) GetFirstPersonsCalls(stub func(int) ([]json.RawMessage, error)) {
+	fake.getFirstPersonsMutex.Lock()
+	defer fake.getFirstPersonsMutex.Unlock()
+	fake.GetFirstPersonsStub = stub
+}
+
+func (fake *FakePersonsEndpoint) GetFirstPersonsArgsForCall(i int) int {
+	fake.getFirstPersonsMutex.RLock()
+	defer fake.getFirstPersonsMutex.RUnlock()
+	argsForCall := fake.getFirstPersonsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakePersonsEndpoint) GetFirstPersonsReturns(result1 []json.RawMessage, result2 error) {
+	fake.getFirstPersonsMutex.Lock()
+	defer fake.getFirstPersonsMutex.Unlock()
+	fake.GetFirstPersonsStub = nil
+	fake.getFirstPersonsReturns = struct {
+		result1 []json.RawMessage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakePersonsEndpoint) GetFirstPersonsReturnsOnCall(i int, result1 []json.RawMessage, result2 error) {
+	fake.getFirstPersonsMutex.Lock()
+	defer fake.getFirstPersonsMutex.Unlock()
+	fake.GetFirstPersonsStub = nil
+	if fake.getFirstPersonsReturnsOnCall == nil {
+		fake.getFirstPersonsReturnsOnCall = make(map[int]struct {
+			result1 []json.RawMessage
+			result2 error
+		})
+	}
+	fake.getFirstPersonsReturnsOnCall[i] = struct {
+		result1 []json.RawMessage
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakePersonsEndpoint) GetPerson(arg1 int) ([]json.RawMessage, error) {
 	fake.getPersonMutex.Lock()
 	ret, specificReturn := fake.getPersonReturnsOnCall[len(fake.getPersonArgsForCall)]
@@ -89,11 +192,150 @@ func (fake *FakePersonsEndpoint) GetPersonReturnsOnCall(i int, result1 []json.Ra
 	}{result1, result2}
 }
 
+func (fake *FakePersonsEndpoint) GetPersons(arg1 []int) ([]json.RawMessage, error) {
+	var arg1Copy []int
+	if arg1 != nil {
+		arg1Copy = make([]int, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.getPersonsMutex.Lock()
+	ret, specificReturn := fake.getPersonsReturnsOnCall[len(fake.getPersonsArgsForCall)]
+	fake.getPersonsArgsForCall = append(fake.getPersonsArgsForCall, struct {
+		arg1 []int
+	}{arg1Copy})
+	stub := fake.GetPersonsStub
+	fakeReturns := fake.getPersonsReturns
+	fake.recordInvocation("GetPersons", []interface{}{arg1Copy})
+	fake.getPersonsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakePersonsEndpoint) GetPersonsCallCount() int {
+	fake.getPersonsMutex.RLock()
+	defer fake.getPersonsMutex.RUnlock()
+	return len(fake.getPersonsArgsForCall)
+}
+
+func (fake *FakePersonsEndpoint) GetPersonsCalls(stub func([]int) ([]json.RawMessage, error)) {
+	fake.getPersonsMutex.Lock()
+	defer fake.getPersonsMutex.Unlock()
+	fake.GetPersonsStub = stub
+}
+
+func (fake *FakePersonsEndpoint) GetPersonsArgsForCall(i int) []int {
+	fake.getPersonsMutex.RLock()
+	defer fake.getPersonsMutex.RUnlock()
+	argsForCall := fake.getPersonsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakePersonsEndpoint) GetPersonsReturns(result1 []json.RawMessage, result2 error) {
+	fake.getPersonsMutex.Lock()
+	defer fake.getPersonsMutex.Unlock()
+	fake.GetPersonsStub = nil
+	fake.getPersonsReturns = struct {
+		result1 []json.RawMessage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakePersonsEndpoint) GetPersonsReturnsOnCall(i int, result1 []json.RawMessage, result2 error) {
+	fake.getPersonsMutex.Lock()
+	defer fake.getPersonsMutex.Unlock()
+	fake.GetPersonsStub = nil
+	if fake.getPersonsReturnsOnCall == nil {
+		fake.getPersonsReturnsOnCall = make(map[int]struct {
+			result1 []json.RawMessage
+			result2 error
+		})
+	}
+	fake.getPersonsReturnsOnCall[i] = struct {
+		result1 []json.RawMessage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakePersonsEndpoint) GetRelationships(arg1 int) ([]rest.PersonRelationshipResponse, error) {
+	fake.getRelationshipsMutex.Lock()
+	ret, specificReturn := fake.getRelationshipsReturnsOnCall[len(fake.getRelationshipsArgsForCall)]
+	fake.getRelationshipsArgsForCall = append(fake.getRelationshipsArgsForCall, struct {
+		arg1 int
+	}{arg1})
+	stub := fake.GetRelationshipsStub
+	fakeReturns := fake.getRelationshipsReturns
+	fake.recordInvocation("GetRelationships", []interface{}{arg1})
+	fake.getRelationshipsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakePersonsEndpoint) GetRelationshipsCallCount() int {
+	fake.getRelationshipsMutex.RLock()
+	defer fake.getRelationshipsMutex.RUnlock()
+	return len(fake.getRelationshipsArgsForCall)
+}
+
+func (fake *FakePersonsEndpoint) GetRelationshipsCalls(stub func(int) ([]rest.PersonRelationshipResponse, error)) {
+	fake.getRelationshipsMutex.Lock()
+	defer fake.getRelationshipsMutex.Unlock()
+	fake.GetRelationshipsStub = stub
+}
+
+func (fake *FakePersonsEndpoint) GetRelationshipsArgsForCall(i int) int {
+	fake.getRelationshipsMutex.RLock()
+	defer fake.getRelationshipsMutex.RUnlock()
+	argsForCall := fake.getRelationshipsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakePersonsEndpoint) GetRelationshipsReturns(result1 []rest.PersonRelationshipResponse, result2 error) {
+	fake.getRelationshipsMutex.Lock()
+	defer fake.getRelationshipsMutex.Unlock()
+	fake.GetRelationshipsStub = nil
+	fake.getRelationshipsReturns = struct {
+		result1 []rest.PersonRelationshipResponse
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakePersonsEndpoint) GetRelationshipsReturnsOnCall(i int, result1 []rest.PersonRelationshipResponse, result2 error) {
+	fake.getRelationshipsMutex.Lock()
+	defer fake.getRelationshipsMutex.Unlock()
+	fake.GetRelationshipsStub = nil
+	if fake.getRelationshipsReturnsOnCall == nil {
+		fake.getRelationshipsReturnsOnCall = make(map[int]struct {
+			result1 []rest.PersonRelationshipResponse
+			result2 error
+		})
+	}
+	fake.getRelationshipsReturnsOnCall[i] = struct {
+		result1 []rest.PersonRelationshipResponse
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakePersonsEndpoint) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.getFirstPersonsMutex.RLock()
+	defer fake.getFirstPersonsMutex.RUnlock()
 	fake.getPersonMutex.RLock()
 	defer fake.getPersonMutex.RUnlock()
+	fake.getPersonsMutex.RLock()
+	defer fake.getPersonsMutex.RUnlock()
+	fake.getRelationshipsMutex.RLock()
+	defer fake.getRelationshipsMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,265 @@
+package scheduler
+
+import (
+	"context"
+	"ctRestClient/config"
+	"ctRestClient/logger"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// DefaultReloadInterval is how often the config file is checked for changes.
+const DefaultReloadInterval = 30 * time.Second
+
+// Options are the dependencies of the scheduler.
+type Options struct {
+	ConfigFilePath string
+	// LoadConfig reads and validates the config file.
+	LoadConfig func(path string) (*config.Config, error)
+	// Prepare is called with each loaded config before it is used, e.g. to
+	// unlock the secrets it needs. A reloaded config is rejected if it
+	// fails.
+	Prepare func(cfg config.Config) error
+	// Export runs an export of the due groups, it returns when the export
+	// has finished.
+	Export func(cfg config.Config)
+	Logger logger.Logger
+	// ReloadInterval is how often the config file is checked for changes,
+	// DefaultReloadInterval if it is not set.
+	ReloadInterval time.Duration
+
+	// Now and After are the clock, time.Now and time.After if not set.
+	Now   func() time.Time
+	After func(d time.Duration) <-chan time.Time
+}
+
+// Scheduler runs the exports of the groups at the cron schedules of the
+// config. The exports never overlap: a schedule that is due while an export
+// is running is skipped.
+type Scheduler struct {
+	options   Options
+	config    config.Config
+	configMod fileVersion
+	jobs      []*job
+}
+
+// job is a scheduled group.
+type job struct {
+	hostname string
+	group    string
+	spec     string
+	schedule cron.Schedule
+	next     time.Time
+}
+
+func (j job) String() string {
+	return fmt.Sprintf("'%s' of '%s'", j.group, j.hostname)
+}
+
+// fileVersion detects changes of a file.
+type fileVersion struct {
+	modTime time.Time
+	size    int64
+}
+
+func New(options Options) *Scheduler {
+	if options.ReloadInterval == 0 {
+		options.ReloadInterval = DefaultReloadInterval
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+	if options.After == nil {
+		options.After = time.After
+	}
+	if options.Prepare == nil {
+		options.Prepare = func(config.Config) error { return nil }
+	}
+	return &Scheduler{options: options}
+}
+
+// Run loads the config and runs the scheduled exports until the context is
+// canceled. A running export is finished first.
+func (s *Scheduler) Run(ctx context.Context) error {
+	if err := s.load(); err != nil {
+		return err
+	}
+	s.logPlan()
+
+	for ctx.Err() == nil {
+		wait := s.options.ReloadInterval
+		if next, exists := s.next(); exists {
+			wait = min(next.Sub(s.options.Now()), wait)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.options.After(max(wait, 0)):
+		}
+
+		s.reloadIfChanged()
+
+		now := s.options.Now()
+		var due []*job
+		for _, job := range s.jobs {
+			if !job.next.IsZero() && !job.next.After(now) {
+				due = append(due, job)
+			}
+		}
+		if len(due) == 0 {
+			continue
+		}
+
+		s.export(due)
+		s.advance(due, now)
+	}
+	return nil
+}
+
+// load reads the config and plans the jobs.
+func (s *Scheduler) load() error {
+	version, err := s.configVersion()
+	if err != nil {
+		return err
+	}
+	cfg, err := s.options.LoadConfig(s.options.ConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to load config from path %s: %w", s.options.ConfigFilePath, err)
+	}
+	if !cfg.Scheduled() {
+		return errors.New("no instance or group of the config has a schedule")
+	}
+	jobs, err := plan(*cfg, s.options.Now())
+	if err != nil {
+		return err
+	}
+	if err := s.options.Prepare(*cfg); err != nil {
+		return err
+	}
+
+	s.config = *cfg
+	s.configMod = version
+	s.jobs = jobs
+	return nil
+}
+
+// reloadIfChanged loads the config again if the file has changed. An invalid
+// config is logged and the previous one is kept.
+func (s *Scheduler) reloadIfChanged() {
+	version, err := s.configVersion()
+	if err != nil {
+		s.options.Logger.Error(err.Error())
+		return
+	}
+	if version == s.configMod {
+		return
+	}
+
+	s.options.Logger.Info(fmt.Sprintf("the config file '%s' has changed, reloading it", s.options.ConfigFilePath))
+	if err := s.load(); err != nil {
+		// the changed file is not read again until it changes once more
+		s.configMod = version
+		s.options.Logger.Error(fmt.Sprintf("keeping the previous config, %v", err))
+		return
+	}
+	s.logPlan()
+}
+
+func (s *Scheduler) configVersion() (fileVersion, error) {
+	info, err := os.Stat(s.options.ConfigFilePath)
+	if err != nil {
+		return fileVersion{}, fmt.Errorf("failed to read the config file, %w", err)
+	}
+	return fileVersion{modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// export runs one export of all due groups.
+func (s *Scheduler) export(due []*job) {
+	isDue := make(map[[2]string]bool)
+	for _, job := range due {
+		isDue[[2]string{job.hostname, job.group}] = true
+	}
+	cfg := s.config.Filter(func(instance config.Instance, group config.Group) bool {
+		return isDue[[2]string{instance.Hostname, group.Name}]
+	})
+
+	s.options.Logger.Info(fmt.Sprintf("starting the scheduled export of %d groups", len(due)))
+	s.options.Export(cfg)
+	s.options.Logger.Info("the scheduled export has finished")
+}
+
+// advance plans the next run of the jobs. Runs that were due while the
+// export was running are skipped, so that exports never overlap.
+func (s *Scheduler) advance(due []*job, start time.Time) {
+	for _, job := range due {
+		job.next = job.schedule.Next(start)
+	}
+
+	finished := s.options.Now()
+	for _, job := range s.jobs {
+		if job.next.IsZero() || job.next.After(finished) {
+			continue
+		}
+		s.options.Logger.Warn(fmt.Sprintf("skipping the run of the group %s at %s, the previous export was still running",
+			job, job.next.Format("2006-01-02 15:04")))
+		job.next = job.schedule.Next(finished)
+	}
+}
+
+// next returns the time of the next job. Schedules like '0 0 30 2 *' never
+// run, their next time is zero.
+func (s *Scheduler) next() (time.Time, bool) {
+	var next time.Time
+	for _, job := range s.jobs {
+		if !job.next.IsZero() && (next.IsZero() || job.next.Before(next)) {
+			next = job.next
+		}
+	}
+	return next, !next.IsZero()
+}
+
+func (s *Scheduler) logPlan() {
+	jobs := make([]*job, len(s.jobs))
+	copy(jobs, s.jobs)
+	sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].next.Before(jobs[j].next) })
+
+	s.options.Logger.Info(fmt.Sprintf("%d groups are scheduled", len(jobs)))
+	for _, job := range jobs {
+		if job.next.IsZero() {
+			s.options.Logger.Warn(fmt.Sprintf("  %s at '%s' never runs", job, job.spec))
+			continue
+		}
+		s.options.Logger.Info(fmt.Sprintf("  %s at '%s', next run at %s", job, job.spec, job.next.Format("2006-01-02 15:04")))
+	}
+}
+
+// plan returns the jobs of the scheduled groups with their next run after
+// now.
+func plan(cfg config.Config, now time.Time) ([]*job, error) {
+	var jobs []*job
+	for _, instance := range cfg.Instances {
+		for _, group := range instance.Groups {
+			spec := instance.GroupSchedule(group)
+			if spec == "" {
+				continue
+			}
+			schedule, err := config.ParseSchedule(spec)
+			if err != nil {
+				return nil, fmt.Errorf("invalid schedule of group '%s', %w", group.Name, err)
+			}
+			jobs = append(jobs, &job{
+				hostname: instance.Hostname,
+				group:    group.Name,
+				spec:     spec,
+				schedule: schedule,
+				next:     schedule.Next(now),
+			})
+		}
+	}
+	return jobs, nil
+}
@@ -0,0 +1,197 @@
+package scheduler_test
+
+import (
+	"context"
+	"ctRestClient/config"
+	"ctRestClient/logger/loggerfakes"
+	"ctRestClient/scheduler"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type export struct {
+	at     time.Time
+	groups []string
+}
+
+var _ = Describe("Scheduler", func() {
+	var (
+		now        time.Time
+		configPath string
+		cfg        *config.Config
+		loadErr    error
+		prepared   []config.Config
+		prepareErr error
+		exports    []export
+		exportTime time.Duration
+		onExport   func()
+		logger     *loggerfakes.FakeLogger
+		ctx        context.Context
+		cancel     context.CancelFunc
+	)
+
+	group := func(name string, schedule string) config.Group {
+		return config.Group{Name: name, Schedule: schedule, Fields: []config.Field{}}
+	}
+
+	newScheduler := func() *scheduler.Scheduler {
+		return scheduler.New(scheduler.Options{
+			ConfigFilePath: configPath,
+			LoadConfig: func(path string) (*config.Config, error) {
+				return cfg, loadErr
+			},
+			Prepare: func(cfg config.Config) error {
+				prepared = append(prepared, cfg)
+				return prepareErr
+			},
+			Export: func(cfg config.Config) {
+				var groups []string
+				for _, instance := range cfg.Instances {
+					for _, group := range instance.Groups {
+						groups = append(groups, instance.Hostname+"/"+group.Name)
+					}
+				}
+				exports = append(exports, export{at: now, groups: groups})
+				now = now.Add(exportTime)
+				if onExport != nil {
+					onExport()
+				}
+			},
+			Logger:         logger,
+			ReloadInterval: time.Hour,
+			Now:            func() time.Time { return now },
+			After: func(d time.Duration) <-chan time.Time {
+				now = now.Add(d)
+				elapsed := make(chan time.Time, 1)
+				elapsed <- now
+				return elapsed
+			},
+		})
+	}
+
+	// stopAfter cancels the scheduler after the number of exports.
+	stopAfter := func(count int) {
+		previous := onExport
+		onExport = func() {
+			if previous != nil {
+				previous()
+			}
+			if len(exports) == count {
+				cancel()
+			}
+		}
+	}
+
+	BeforeEach(func() {
+		now = time.Date(2025, 3, 3, 5, 30, 0, 0, time.Local)
+		configPath = filepath.Join(GinkgoT().TempDir(), "config.yml")
+		Expect(os.WriteFile(configPath, []byte("first"), 0600)).To(Succeed())
+		cfg = &config.Config{
+			Instances: []config.Instance{
+				{
+					Hostname: "cumulus",
+					Schedule: "0 6 * * *",
+					Groups:   []config.Group{group("daily", ""), group("hourly", "15 * * * *")},
+				},
+				{
+					Hostname: "stratus",
+					Groups:   []config.Group{group("unscheduled", ""), group("mondays", "0 6 * * 1")},
+				},
+			},
+		}
+		loadErr = nil
+		prepared = nil
+		prepareErr = nil
+		exports = nil
+		exportTime = time.Minute
+		onExport = nil
+		logger = &loggerfakes.FakeLogger{}
+		ctx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(func() { cancel() })
+	})
+
+	It("exports the groups when their schedule is due", func() {
+		stopAfter(3)
+
+		Expect(newScheduler().Run(ctx)).To(Succeed())
+
+		Expect(exports).To(Equal([]export{
+			{at: time.Date(2025, 3, 3, 6, 0, 0, 0, time.Local), groups: []string{"cumulus/daily", "stratus/mondays"}},
+			{at: time.Date(2025, 3, 3, 6, 15, 0, 0, time.Local), groups: []string{"cumulus/hourly"}},
+			{at: time.Date(2025, 3, 3, 7, 15, 0, 0, time.Local), groups: []string{"cumulus/hourly"}},
+		}))
+	})
+
+	It("unlocks the secrets of the config before the first run", func() {
+		stopAfter(1)
+
+		Expect(newScheduler().Run(ctx)).To(Succeed())
+
+		Expect(prepared).To(HaveLen(1))
+		Expect(prepared[0].Instances).To(HaveLen(2))
+	})
+
+	It("returns an error if the secrets cannot be unlocked", func() {
+		prepareErr = errors.New("the password is invalid")
+
+		Expect(newScheduler().Run(ctx)).To(MatchError("the password is invalid"))
+		Expect(exports).To(BeEmpty())
+	})
+
+	It("returns an error if no group has a schedule", func() {
+		cfg.Instances[0].Schedule = ""
+		cfg.Instances[0].Groups[1].Schedule = ""
+		cfg.Instances[1].Groups[1].Schedule = ""
+
+		Expect(newScheduler().Run(ctx)).To(MatchError("no instance or group of the config has a schedule"))
+	})
+
+	It("skips the runs that are due while an export is running", func() {
+		exportTime = 90 * time.Minute
+		stopAfter(2)
+
+		Expect(newScheduler().Run(ctx)).To(Succeed())
+
+		// the hourly run at 06:15 and 07:15 are skipped
+		Expect(exports[1].at).To(Equal(time.Date(2025, 3, 3, 8, 15, 0, 0, time.Local)))
+		Expect(exports[1].groups).To(Equal([]string{"cumulus/hourly"}))
+		Expect(logger.WarnArgsForCall(0)).To(Equal("skipping the run of the group 'hourly' of 'cumulus' at 2025-03-03 06:15, the previous export was still running"))
+	})
+
+	It("reloads the config when the file changes", func() {
+		onExport = func() {
+			if len(exports) == 1 {
+				cfg = &config.Config{Instances: []config.Instance{
+					{Hostname: "nimbus", Groups: []config.Group{group("weekly", "0 9 * * 1")}},
+				}}
+				Expect(os.WriteFile(configPath, []byte("second"), 0600)).To(Succeed())
+			}
+		}
+		stopAfter(2)
+
+		Expect(newScheduler().Run(ctx)).To(Succeed())
+
+		Expect(exports[1]).To(Equal(export{at: time.Date(2025, 3, 3, 9, 0, 0, 0, time.Local), groups: []string{"nimbus/weekly"}}))
+		Expect(prepared).To(HaveLen(2))
+	})
+
+	It("keeps the previous config if the changed config is invalid", func() {
+		onExport = func() {
+			if len(exports) == 1 {
+				loadErr = errors.New("invalid yaml")
+				Expect(os.WriteFile(configPath, []byte("second"), 0600)).To(Succeed())
+			}
+		}
+		stopAfter(2)
+
+		Expect(newScheduler().Run(ctx)).To(Succeed())
+
+		Expect(exports[1].groups).To(Equal([]string{"cumulus/hourly"}))
+		Expect(logger.ErrorArgsForCall(0)).To(ContainSubstring("keeping the previous config, failed to load config from path"))
+	})
+})
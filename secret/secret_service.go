@@ -0,0 +1,71 @@
+package secret
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// The names of the freedesktop.org Secret Service API, see
+// https://specifications.freedesktop.org/secret-service-spec/latest/
+const (
+	secretServiceName = "org.freedesktop.secrets"
+	secretServicePath = "/org/freedesktop/secrets"
+	secretServiceIf   = "org.freedesktop.Secret.Service"
+	secretItemIf      = "org.freedesktop.Secret.Item"
+	secretSessionIf   = "org.freedesktop.Secret.Session"
+)
+
+// serviceSecret is the secret struct (oayays) of the Secret Service API.
+type serviceSecret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// readSecretService returns the secret of the first unlocked item of the
+// Secret Service with the attributes, e.g. of GNOME Keyring or KWallet.
+func readSecretService(attributes map[string]string) (string, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to the session bus, %w", err)
+	}
+	defer conn.Close()
+
+	service := conn.Object(secretServiceName, secretServicePath)
+
+	// The plain algorithm transfers the secret unencrypted, which is fine
+	// since the session bus is only accessible by the user
+	var output dbus.Variant
+	var sessionPath dbus.ObjectPath
+	err = service.Call(secretServiceIf+".OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&output, &sessionPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open a secret service session, %w", err)
+	}
+	defer conn.Object(secretServiceName, sessionPath).Call(secretSessionIf+".Close", 0)
+
+	var unlocked, locked []dbus.ObjectPath
+	err = service.Call(secretServiceIf+".SearchItems", 0, attributes).Store(&unlocked, &locked)
+	if err != nil {
+		return "", fmt.Errorf("failed to search the keyring, %w", err)
+	}
+	if len(unlocked) == 0 {
+		if len(locked) > 0 {
+			return "", errors.New("the keyring item is locked, unlock the keyring first")
+		}
+		return "", errors.New("the keyring item could not be found")
+	}
+
+	var secret serviceSecret
+	err = conn.Object(secretServiceName, unlocked[0]).Call(secretItemIf+".GetSecret", 0, sessionPath).Store(&secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the secret of the keyring item, %w", err)
+	}
+	if len(secret.Value) == 0 {
+		return "", errors.New("the secret of the keyring item is empty")
+	}
+
+	return string(secret.Value), nil
+}
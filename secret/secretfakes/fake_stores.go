@@ -0,0 +1,115 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package secretfakes
+
+import (
+	"ctRestClient/secret"
+	"sync"
+)
+
+type FakeStores struct {
+	StoreStub        func(string) (secret.Store, error)
+	storeMutex       sync.RWMutex
+	storeArgsForCall []struct {
+		arg1 string
+	}
+	storeReturns struct {
+		result1 secret.Store
+		result2 error
+	}
+	storeReturnsOnCall map[int]struct {
+		result1 secret.Store
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeStores) Store(arg1 string) (secret.Store, error) {
+	fake.storeMutex.Lock()
+	ret, specificReturn := fake.storeReturnsOnCall[len(fake.storeArgsForCall)]
+	fake.storeArgsForCall = append(fake.storeArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.StoreStub
+	fakeReturns := fake.storeReturns
+	fake.recordInvocation("Store", []interface{}{arg1})
+	fake.storeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeStores) StoreCallCount() int {
+	fake.storeMutex.RLock()
+	defer fake.storeMutex.RUnlock()
+	return len(fake.storeArgsForCall)
+}
+
+func (fake *FakeStores) StoreCalls(stub func(string) (secret.Store, error)) {
+	fake.storeMutex.Lock()
+	defer fake.storeMutex.Unlock()
+	fake.StoreStub = stub
+}
+
+func (fake *FakeStores) StoreArgsForCall(i int) string {
+	fake.storeMutex.RLock()
+	defer fake.storeMutex.RUnlock()
+	argsForCall := fake.storeArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeStores) StoreReturns(result1 secret.Store, result2 error) {
+	fake.storeMutex.Lock()
+	defer fake.storeMutex.Unlock()
+	fake.StoreStub = nil
+	fake.storeReturns = struct {
+		result1 secret.Store
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStores) StoreReturnsOnCall(i int, result1 secret.Store, result2 error) {
+	fake.storeMutex.Lock()
+	defer fake.storeMutex.Unlock()
+	fake.StoreStub = nil
+	if fake.storeReturnsOnCall == nil {
+		fake.storeReturnsOnCall = make(map[int]struct {
+			result1 secret.Store
+			result2 error
+		})
+	}
+	fake.storeReturnsOnCall[i] = struct {
+		result1 secret.Store
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStores) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.storeMutex.RLock()
+	defer fake.storeMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeStores) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ secret.Stores = new(FakeStores)
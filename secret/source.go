@@ -0,0 +1,271 @@
+package secret
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// PasswordEnvVariable is the environment variable of the KeePass password.
+// If it is set, the password is read from it unless another source is
+// selected.
+const PasswordEnvVariable = "CTRESTCLIENT_KEEPASS_PASSWORD"
+
+// TokenFilePasswordEnvVariable is the environment variable of the password
+// of encrypted token files.
+const TokenFilePasswordEnvVariable = "CTRESTCLIENT_TOKEN_FILE_PASSWORD"
+
+// A Secret describes a secret that is read from a source.
+type Secret struct {
+	// Name is shown at the prompt, e.g. "Keepass database password".
+	Name string
+	// EnvVariable is read if no source is selected and it is set.
+	EnvVariable string
+}
+
+var (
+	KeepassPassword   = Secret{Name: "Keepass database password", EnvVariable: PasswordEnvVariable}
+	TokenFilePassword = Secret{Name: "token file password", EnvVariable: TokenFilePasswordEnvVariable}
+)
+
+// A Source provides a secret like the master password of the KeePass
+// database.
+type Source interface {
+	// Description describes where the secret is read from without revealing
+	// it, e.g. "environment variable 'FOO'".
+	Description() string
+	Read() (string, error)
+}
+
+// Stdin is the standard input the secrets of a run are read from. It holds
+// only one secret, a second secret would get the rest of the input or EOF.
+// Its first line is therefore read once and belongs to the secret that read
+// it first.
+type Stdin struct {
+	file   *os.File
+	secret string
+	line   string
+	err    error
+}
+
+func NewStdin(file *os.File) *Stdin {
+	return &Stdin{file: file}
+}
+
+func (s *Stdin) read(secret Secret) (string, error) {
+	if s.secret == "" {
+		s.secret = secret.Name
+		s.line, s.err = readerSource{description: "stdin", reader: s.file}.Read()
+	}
+	if s.secret != secret.Name {
+		return "", fmt.Errorf("stdin is already read for the %s, select another source for the %s, e.g. the environment variable '%s'", s.secret, secret.Name, secret.EnvVariable)
+	}
+	return s.line, s.err
+}
+
+// ParseSource returns the source of a specification like 'env:NAME',
+// 'file:PATH', 'fd:3', 'stdin', 'keyring:service=foo,account=bar' or
+// 'prompt'. If the specification is empty, the source is chosen
+// automatically: the environment variable of the secret if it is set, stdin
+// if it is not a terminal and the prompt otherwise.
+func ParseSource(spec string, secret Secret, stdin *Stdin) (Source, error) {
+	kind, value, _ := strings.Cut(spec, ":")
+
+	switch kind {
+	case "":
+		if _, exists := os.LookupEnv(secret.EnvVariable); exists {
+			return envSource{name: secret.EnvVariable}, nil
+		}
+		if !term.IsTerminal(int(stdin.file.Fd())) {
+			return stdinSource{stdin: stdin, secret: secret}, nil
+		}
+		return promptSource{terminal: stdin.file, name: secret.Name}, nil
+	case "prompt":
+		return promptSource{terminal: stdin.file, name: secret.Name}, nil
+	case "env":
+		if value == "" {
+			return nil, errors.New("the name of the environment variable is not set, e.g. 'env:NAME'")
+		}
+		return envSource{name: value}, nil
+	case "file":
+		if value == "" {
+			return nil, errors.New("the path of the file is not set, e.g. 'file:PATH'")
+		}
+		return fileSource{path: value}, nil
+	case "fd":
+		fd, err := strconv.Atoi(value)
+		if err != nil || fd < 0 {
+			return nil, fmt.Errorf("invalid file descriptor '%s', e.g. 'fd:3'", value)
+		}
+		return readerSource{description: fmt.Sprintf("file descriptor %d", fd), reader: os.NewFile(uintptr(fd), "fd"+value)}, nil
+	case "stdin":
+		return stdinSource{stdin: stdin, secret: secret}, nil
+	case "keyring":
+		attributes, err := parseAttributes(value)
+		if err != nil {
+			return nil, err
+		}
+		return keyringSource{attributes: attributes}, nil
+	default:
+		return nil, fmt.Errorf("unknown secret source '%s', use prompt, env, file, fd, stdin or keyring", kind)
+	}
+}
+
+// DefaultKeyringAttributes are the attributes of the keyring item if no
+// attributes are set, e.g. stored with
+// 'secret-tool store --label=ctRestClient service ctRestClient account keepass'.
+var DefaultKeyringAttributes = map[string]string{"service": "ctRestClient", "account": "keepass"}
+
+func parseAttributes(value string) (map[string]string, error) {
+	if value == "" {
+		return DefaultKeyringAttributes, nil
+	}
+
+	attributes := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		key, attributeValue, found := strings.Cut(pair, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid keyring attribute '%s', e.g. 'keyring:service=ctRestClient,account=keepass'", pair)
+		}
+		attributes[key] = attributeValue
+	}
+	return attributes, nil
+}
+
+type promptSource struct {
+	terminal *os.File
+	name     string
+}
+
+func (s promptSource) Description() string {
+	return "prompt"
+}
+
+func (s promptSource) Read() (string, error) {
+	fmt.Printf("Enter %s: ", s.name)
+
+	password, err := term.ReadPassword(int(s.terminal.Fd()))
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %v", err)
+	}
+	fmt.Println()
+
+	return string(password), nil
+}
+
+type envSource struct {
+	name string
+}
+
+func (s envSource) Description() string {
+	return fmt.Sprintf("environment variable '%s'", s.name)
+}
+
+func (s envSource) Read() (string, error) {
+	value, exists := os.LookupEnv(s.name)
+	if !exists || value == "" {
+		return "", fmt.Errorf("the environment variable '%s' is not set", s.name)
+	}
+	return value, nil
+}
+
+type fileSource struct {
+	path string
+}
+
+func (s fileSource) Description() string {
+	return fmt.Sprintf("file '%s'", s.path)
+}
+
+func (s fileSource) Read() (string, error) {
+	if err := checkPrivateFile(s.path); err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return readerSource{description: s.Description(), reader: file}.Read()
+}
+
+// checkPrivateFile returns an error if the file with secrets is accessible
+// by other users.
+func checkPrivateFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	// Windows has no permission bits, access is controlled by ACLs there
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("the file '%s' is accessible by other users (%04o), its permissions must be 0600 or stricter", path, info.Mode().Perm())
+	}
+	return nil
+}
+
+type stdinSource struct {
+	stdin  *Stdin
+	secret Secret
+}
+
+func (s stdinSource) Description() string {
+	return "stdin"
+}
+
+func (s stdinSource) Read() (string, error) {
+	return s.stdin.read(s.secret)
+}
+
+type readerSource struct {
+	description string
+	reader      io.Reader
+}
+
+func (s readerSource) Description() string {
+	return s.description
+}
+
+// Read returns the first line of the reader.
+func (s readerSource) Read() (string, error) {
+	line, err := bufio.NewReader(s.reader).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read the secret from %s: %v", s.description, err)
+	}
+	line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+	if line == "" {
+		return "", fmt.Errorf("the secret from %s is empty", s.description)
+	}
+	return line, nil
+}
+
+type keyringSource struct {
+	attributes map[string]string
+}
+
+func (s keyringSource) Description() string {
+	keys := make([]string, 0, len(s.attributes))
+	for key := range s.attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + s.attributes[key]
+	}
+	return fmt.Sprintf("keyring item with %s", strings.Join(pairs, ", "))
+}
+
+func (s keyringSource) Read() (string, error) {
+	return readSecretService(s.attributes)
+}
@@ -0,0 +1,178 @@
+package secret_test
+
+import (
+	"ctRestClient/secret"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseSource", func() {
+	var (
+		tempDir string
+		stdin   *os.File
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "secret_test_")
+		Expect(err).ToNot(HaveOccurred())
+
+		stdin, err = os.Open(os.DevNull)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		stdin.Close()
+		os.RemoveAll(tempDir)
+	})
+
+	// pipe returns the read end of a pipe that contains the content
+	pipe := func(content string) *os.File {
+		reader, writer, err := os.Pipe()
+		Expect(err).ToNot(HaveOccurred())
+		_, err = writer.WriteString(content)
+		Expect(err).ToNot(HaveOccurred())
+		writer.Close()
+		return reader
+	}
+
+	It("reads the secret from an environment variable", func() {
+		GinkgoT().Setenv("FOO_PASSWORD", "s3cret")
+
+		source, err := secret.ParseSource("env:FOO_PASSWORD", secret.KeepassPassword, secret.NewStdin(stdin))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(source.Description()).To(Equal("environment variable 'FOO_PASSWORD'"))
+
+		Expect(source.Read()).To(Equal("s3cret"))
+	})
+
+	It("returns an error if the environment variable is not set", func() {
+		source, err := secret.ParseSource("env:UNKNOWN_PASSWORD", secret.KeepassPassword, secret.NewStdin(stdin))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = source.Read()
+		Expect(err).To(MatchError("the environment variable 'UNKNOWN_PASSWORD' is not set"))
+	})
+
+	It("reads the first line of a file", func() {
+		path := filepath.Join(tempDir, "password")
+		Expect(os.WriteFile(path, []byte("s3cret pass\r\nsecond line\n"), 0600)).To(Succeed())
+
+		source, err := secret.ParseSource("file:"+path, secret.KeepassPassword, secret.NewStdin(stdin))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(source.Description()).To(Equal(fmt.Sprintf("file '%s'", path)))
+
+		Expect(source.Read()).To(Equal("s3cret pass"))
+	})
+
+	It("returns an error if the file is accessible by other users", func() {
+		path := filepath.Join(tempDir, "password")
+		Expect(os.WriteFile(path, []byte("s3cret"), 0644)).To(Succeed())
+		Expect(os.Chmod(path, 0644)).To(Succeed())
+
+		source, err := secret.ParseSource("file:"+path, secret.KeepassPassword, secret.NewStdin(stdin))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = source.Read()
+		Expect(err).To(MatchError(fmt.Sprintf("the file '%s' is accessible by other users (0644), its permissions must be 0600 or stricter", path)))
+	})
+
+	It("reads the secret from a file descriptor", func() {
+		reader := pipe("s3cret\n")
+		defer reader.Close()
+
+		source, err := secret.ParseSource(fmt.Sprintf("fd:%d", reader.Fd()), secret.KeepassPassword, secret.NewStdin(stdin))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(source.Description()).To(Equal(fmt.Sprintf("file descriptor %d", reader.Fd())))
+
+		Expect(source.Read()).To(Equal("s3cret"))
+	})
+
+	It("reads the secret from stdin", func() {
+		reader := pipe("s3cret")
+		defer reader.Close()
+
+		source, err := secret.ParseSource("stdin", secret.KeepassPassword, secret.NewStdin(reader))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(source.Description()).To(Equal("stdin"))
+
+		Expect(source.Read()).To(Equal("s3cret"))
+	})
+
+	It("reads stdin once for the first secret only", func() {
+		reader := pipe("s3cret\nsecond\n")
+		defer reader.Close()
+		stdin := secret.NewStdin(reader)
+
+		keepassPassword, err := secret.ParseSource("", secret.KeepassPassword, stdin)
+		Expect(err).ToNot(HaveOccurred())
+		tokenFilePassword, err := secret.ParseSource("stdin", secret.TokenFilePassword, stdin)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(keepassPassword.Read()).To(Equal("s3cret"))
+		Expect(keepassPassword.Read()).To(Equal("s3cret"))
+		_, err = tokenFilePassword.Read()
+		Expect(err).To(MatchError("stdin is already read for the Keepass database password, select another source for the token file password, e.g. the environment variable 'CTRESTCLIENT_TOKEN_FILE_PASSWORD'"))
+	})
+
+	It("returns an error if stdin is empty", func() {
+		source, err := secret.ParseSource("stdin", secret.KeepassPassword, secret.NewStdin(stdin))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = source.Read()
+		Expect(err).To(MatchError("the secret from stdin is empty"))
+	})
+
+	It("selects the keyring item by attributes", func() {
+		source, err := secret.ParseSource("keyring:service=ct,account=main", secret.KeepassPassword, secret.NewStdin(stdin))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(source.Description()).To(Equal("keyring item with account=main, service=ct"))
+
+		source, err = secret.ParseSource("keyring", secret.KeepassPassword, secret.NewStdin(stdin))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(source.Description()).To(Equal("keyring item with account=keepass, service=ctRestClient"))
+	})
+
+	It("returns an error for invalid specifications", func() {
+		_, err := secret.ParseSource("vault:foo", secret.KeepassPassword, secret.NewStdin(stdin))
+		Expect(err).To(MatchError("unknown secret source 'vault', use prompt, env, file, fd, stdin or keyring"))
+
+		_, err = secret.ParseSource("fd:three", secret.KeepassPassword, secret.NewStdin(stdin))
+		Expect(err).To(MatchError("invalid file descriptor 'three', e.g. 'fd:3'"))
+
+		_, err = secret.ParseSource("keyring:service", secret.KeepassPassword, secret.NewStdin(stdin))
+		Expect(err).To(HaveOccurred())
+
+		_, err = secret.ParseSource("env:", secret.KeepassPassword, secret.NewStdin(stdin))
+		Expect(err).To(HaveOccurred())
+	})
+
+	Describe("without a specification", func() {
+		It("uses the environment variable if it is set", func() {
+			GinkgoT().Setenv(secret.PasswordEnvVariable, "s3cret")
+
+			source, err := secret.ParseSource("", secret.KeepassPassword, secret.NewStdin(stdin))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(source.Description()).To(Equal("environment variable 'CTRESTCLIENT_KEEPASS_PASSWORD'"))
+		})
+
+		It("uses the environment variable of the secret", func() {
+			GinkgoT().Setenv(secret.TokenFilePasswordEnvVariable, "s3cret")
+
+			source, err := secret.ParseSource("", secret.TokenFilePassword, secret.NewStdin(stdin))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(source.Description()).To(Equal("environment variable 'CTRESTCLIENT_TOKEN_FILE_PASSWORD'"))
+			Expect(source.Read()).To(Equal("s3cret"))
+		})
+
+		It("uses stdin if it is not a terminal", func() {
+			source, err := secret.ParseSource("", secret.KeepassPassword, secret.NewStdin(stdin))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(source.Description()).To(Equal("stdin"))
+		})
+	})
+})
@@ -0,0 +1,289 @@
+package secret
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"gopkg.in/yaml.v3"
+)
+
+// KeepassStore is the token source of the KeePass database, it is used if no
+// token source is set.
+const KeepassStore = "keepass"
+
+// A Store provides named secrets like the API tokens of the instances.
+//
+//counterfeiter:generate . Store
+type Store interface {
+	// Get returns the secret with the name, e.g. the token name of an
+	// instance.
+	Get(name string) (string, error)
+}
+
+// StoreFunc is a Store calling the function, e.g. KeepassCli.GetPassword.
+type StoreFunc func(name string) (string, error)
+
+func (f StoreFunc) Get(name string) (string, error) {
+	return f(name)
+}
+
+// Stores provides the store of each token source. A store is created on
+// first use and then shared, e.g. the KeePass password is only read when an
+// instance uses KeePass and only once.
+//
+//counterfeiter:generate . Stores
+type Stores interface {
+	// Store returns the store of a token source like 'keepass', 'env',
+	// 'file:PATH', 'encrypted:PATH' or 'pass[:DIR]'. An empty token source is
+	// the KeePass database.
+	Store(tokenSource string) (Store, error)
+}
+
+// StoresOptions are the dependencies of the stores.
+type StoresOptions struct {
+	// Keepass opens the KeePass database.
+	Keepass func() (Store, error)
+	// TokenFilePassword is the source of the password of encrypted token
+	// files.
+	TokenFilePassword Source
+	// BaseDir is the directory of relative paths, e.g. of the config file.
+	BaseDir string
+}
+
+type stores struct {
+	options StoresOptions
+	stores  map[string]Store
+}
+
+func NewStores(options StoresOptions) Stores {
+	return &stores{
+		options: options,
+		stores:  make(map[string]Store),
+	}
+}
+
+func (s *stores) Store(tokenSource string) (Store, error) {
+	kind, value, err := parseTokenSource(tokenSource)
+	if err != nil {
+		return nil, err
+	}
+	key := kind + ":" + value
+	if store, exists := s.stores[key]; exists {
+		return store, nil
+	}
+
+	var store Store
+	switch kind {
+	case KeepassStore:
+		store, err = s.options.Keepass()
+		if err != nil {
+			return nil, err
+		}
+	case "env":
+		store = envStore{}
+	case "file":
+		store = &fileStore{path: s.path(value)}
+	case "encrypted":
+		store = &fileStore{path: s.path(value), password: s.options.TokenFilePassword}
+	case "pass":
+		store = &passStore{dir: value, secrets: make(map[string]string)}
+	}
+	s.stores[key] = store
+	return store, nil
+}
+
+func (s *stores) path(path string) string {
+	if filepath.IsAbs(path) || s.options.BaseDir == "" {
+		return path
+	}
+	return filepath.Join(s.options.BaseDir, path)
+}
+
+// Lookup returns the secret with the name from the store of the token
+// source.
+func Lookup(stores Stores, tokenSource string, name string) (string, error) {
+	store, err := stores.Store(tokenSource)
+	if err != nil {
+		return "", err
+	}
+	return store.Get(name)
+}
+
+// ValidateTokenSource checks the syntax of a token source without creating
+// the store.
+func ValidateTokenSource(tokenSource string) error {
+	_, _, err := parseTokenSource(tokenSource)
+	return err
+}
+
+// IsKeepass returns true if the token source is the KeePass database.
+func IsKeepass(tokenSource string) bool {
+	kind, _, err := parseTokenSource(tokenSource)
+	return err == nil && kind == KeepassStore
+}
+
+func parseTokenSource(tokenSource string) (string, string, error) {
+	kind, value, _ := strings.Cut(tokenSource, ":")
+
+	switch kind {
+	case "", KeepassStore:
+		if value != "" {
+			return "", "", fmt.Errorf("the token source '%s' has no parameter", KeepassStore)
+		}
+		return KeepassStore, "", nil
+	case "env":
+		if value != "" {
+			return "", "", errors.New("the token source 'env' has no parameter, the token name is the name of the environment variable")
+		}
+		return kind, "", nil
+	case "file", "encrypted":
+		if value == "" {
+			return "", "", fmt.Errorf("the path of the token file is not set, e.g. '%s:tokens.yml'", kind)
+		}
+		return kind, value, nil
+	case "pass":
+		if value == "" {
+			value = os.Getenv("PASSWORD_STORE_DIR")
+		}
+		if value == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", "", fmt.Errorf("failed to find the password store, %w", err)
+			}
+			value = filepath.Join(home, ".password-store")
+		}
+		return kind, value, nil
+	default:
+		return "", "", fmt.Errorf("unknown token source '%s', use keepass, env, file, encrypted or pass", kind)
+	}
+}
+
+// envStore reads each secret from the environment variable with its name.
+type envStore struct{}
+
+func (s envStore) Get(name string) (string, error) {
+	return envSource{name: name}.Read()
+}
+
+// fileStore reads the secrets from a YAML file with names and secrets, e.g.
+// 'my_token: abc'. If a password is set, the file is encrypted with age and
+// the password.
+type fileStore struct {
+	path     string
+	password Source
+	secrets  map[string]string
+}
+
+func (s *fileStore) Get(name string) (string, error) {
+	if s.secrets == nil {
+		secrets, err := s.read()
+		if err != nil {
+			return "", err
+		}
+		s.secrets = secrets
+	}
+
+	value, exists := s.secrets[name]
+	if !exists || value == "" {
+		return "", fmt.Errorf("the token file '%s' has no token '%s'", s.path, name)
+	}
+	return value, nil
+}
+
+func (s *fileStore) read() (map[string]string, error) {
+	var content []byte
+	var err error
+	if s.password == nil {
+		// only plain files must be private, encrypted files may be shared
+		if err := checkPrivateFile(s.path); err != nil {
+			return nil, err
+		}
+		content, err = os.ReadFile(s.path)
+	} else {
+		content, err = s.decrypt()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets map[string]string
+	if err := yaml.Unmarshal(content, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to read the token file '%s', it must contain names and tokens, %w", s.path, err)
+	}
+	if secrets == nil {
+		secrets = make(map[string]string)
+	}
+	return secrets, nil
+}
+
+// decrypt decrypts the file, which is encrypted with a passphrase by age,
+// e.g. with 'age --passphrase --output tokens.age tokens.yml'.
+func (s *fileStore) decrypt() ([]byte, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := s.password.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the password of the token file '%s', %w", s.path, err)
+	}
+	identity, err := age.NewScryptIdentity(password)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader io.Reader = bytes.NewReader(data)
+	if bytes.HasPrefix(data, []byte(armor.Header)) {
+		reader = armor.NewReader(reader)
+	}
+	decrypted, err := age.Decrypt(reader, identity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the token file '%s', %w", s.path, err)
+	}
+	return io.ReadAll(decrypted)
+}
+
+// passStore reads the secrets from a password store of pass, each secret is
+// the first line of the GPG encrypted file with its name. The decrypted
+// secrets are kept, so that gpg is only asked once per secret.
+type passStore struct {
+	dir     string
+	secrets map[string]string
+}
+
+func (s *passStore) Get(name string) (string, error) {
+	if value, exists := s.secrets[name]; exists {
+		return value, nil
+	}
+
+	path := filepath.Join(s.dir, filepath.FromSlash(name)+".gpg")
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("the password store has no entry '%s', %w", name, err)
+	}
+
+	cmd := exec.Command("gpg", "--quiet", "--batch", "--decrypt", path)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to decrypt the entry '%s' of the password store: %v, stderr: %s", name, err, stderr.String())
+	}
+
+	line, _ := bufio.NewReader(&out).ReadString('\n')
+	line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+	if line == "" {
+		return "", fmt.Errorf("the entry '%s' of the password store is empty", name)
+	}
+	s.secrets[name] = line
+	return line, nil
+}
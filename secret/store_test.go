@@ -0,0 +1,191 @@
+package secret_test
+
+import (
+	"bytes"
+	"ctRestClient/secret"
+	"ctRestClient/secret/secretfakes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stores", func() {
+	var (
+		tempDir      string
+		keepassStore *secretfakes.FakeStore
+		keepassCalls int
+		stores       secret.Stores
+	)
+
+	BeforeEach(func() {
+		tempDir = GinkgoT().TempDir()
+		keepassStore = &secretfakes.FakeStore{}
+		keepassStore.GetReturns("keepass token", nil)
+		keepassCalls = 0
+
+		GinkgoT().Setenv("TOKEN_FILE_PASSWORD", "file password")
+		tokenFilePassword, err := secret.ParseSource("env:TOKEN_FILE_PASSWORD", secret.TokenFilePassword, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		stores = secret.NewStores(secret.StoresOptions{
+			Keepass: func() (secret.Store, error) {
+				keepassCalls++
+				return keepassStore, nil
+			},
+			TokenFilePassword: tokenFilePassword,
+			BaseDir:           tempDir,
+		})
+	})
+
+	It("opens the KeePass database once when it is used", func() {
+		Expect(secret.Lookup(stores, "", "KEEPASS_TOKEN")).To(Equal("keepass token"))
+		Expect(secret.Lookup(stores, "keepass", "KEEPASS_TOKEN")).To(Equal("keepass token"))
+
+		Expect(keepassCalls).To(Equal(1))
+		Expect(keepassStore.GetArgsForCall(1)).To(Equal("KEEPASS_TOKEN"))
+	})
+
+	It("returns the error of the KeePass database", func() {
+		stores = secret.NewStores(secret.StoresOptions{
+			Keepass: func() (secret.Store, error) {
+				return nil, errors.New("the keepass password is invalid")
+			},
+		})
+
+		_, err := stores.Store("keepass")
+
+		Expect(err).To(MatchError("the keepass password is invalid"))
+	})
+
+	It("reads the token from an environment variable", func() {
+		GinkgoT().Setenv("MY_TOKEN", "env token")
+
+		Expect(secret.Lookup(stores, "env", "MY_TOKEN")).To(Equal("env token"))
+		_, err := secret.Lookup(stores, "env", "UNKNOWN_TOKEN")
+		Expect(err).To(MatchError("the environment variable 'UNKNOWN_TOKEN' is not set"))
+		Expect(keepassCalls).To(Equal(0))
+	})
+
+	It("reads the token from a file relative to the base directory", func() {
+		Expect(os.WriteFile(filepath.Join(tempDir, "tokens.yml"), []byte("my_token: file token\n"), 0600)).To(Succeed())
+
+		Expect(secret.Lookup(stores, "file:tokens.yml", "my_token")).To(Equal("file token"))
+		_, err := secret.Lookup(stores, "file:tokens.yml", "other_token")
+		Expect(err).To(MatchError("the token file '" + filepath.Join(tempDir, "tokens.yml") + "' has no token 'other_token'"))
+	})
+
+	It("returns an error if the token file is accessible by other users", func() {
+		path := filepath.Join(tempDir, "tokens.yml")
+		Expect(os.WriteFile(path, []byte("my_token: file token\n"), 0600)).To(Succeed())
+		Expect(os.Chmod(path, 0644)).To(Succeed())
+
+		_, err := secret.Lookup(stores, "file:"+path, "my_token")
+
+		Expect(err).To(MatchError(ContainSubstring("is accessible by other users (0644)")))
+	})
+
+	It("reads the token from an encrypted file", func() {
+		writeEncrypted(filepath.Join(tempDir, "tokens.age"), "my_token: encrypted token\n", "file password", false)
+		writeEncrypted(filepath.Join(tempDir, "armored.age"), "my_token: armored token\n", "file password", true)
+
+		Expect(secret.Lookup(stores, "encrypted:tokens.age", "my_token")).To(Equal("encrypted token"))
+		Expect(secret.Lookup(stores, "encrypted:armored.age", "my_token")).To(Equal("armored token"))
+	})
+
+	It("returns an error if the password of the encrypted file is wrong", func() {
+		writeEncrypted(filepath.Join(tempDir, "tokens.age"), "my_token: encrypted token\n", "other password", false)
+
+		_, err := secret.Lookup(stores, "encrypted:tokens.age", "my_token")
+
+		Expect(err).To(MatchError(ContainSubstring("failed to decrypt the token file")))
+	})
+
+	It("reads the token from a password store", func() {
+		if _, err := exec.LookPath("gpg"); err != nil {
+			Skip("gpg is not installed")
+		}
+		// the socket path of the gpg agent must be short
+		gnupgHome, err := os.MkdirTemp("", "gnupg")
+		Expect(err).NotTo(HaveOccurred())
+		GinkgoT().Setenv("GNUPGHOME", gnupgHome)
+		DeferCleanup(func() {
+			_ = exec.Command("gpgconf", "--kill", "gpg-agent").Run()
+			_ = os.RemoveAll(gnupgHome)
+		})
+		storeDir := filepath.Join(tempDir, "password-store")
+		Expect(os.MkdirAll(filepath.Join(storeDir, "churchtools"), 0700)).To(Succeed())
+
+		gpg := func(stdin string, args ...string) {
+			cmd := exec.Command("gpg", append([]string{"--batch", "--quiet", "--passphrase", ""}, args...)...)
+			cmd.Stdin = bytes.NewBufferString(stdin)
+			output, err := cmd.CombinedOutput()
+			Expect(err).NotTo(HaveOccurred(), string(output))
+		}
+		gpg("", "--quick-gen-key", "ctRestClient test", "future-default", "default", "never")
+		gpg("pass token\nuser: admin\n", "--encrypt", "--recipient", "ctRestClient test", "--output", filepath.Join(storeDir, "churchtools", "main.gpg"))
+
+		Expect(secret.Lookup(stores, "pass:"+storeDir, "churchtools/main")).To(Equal("pass token"))
+		_, err = secret.Lookup(stores, "pass:"+storeDir, "churchtools/other")
+		Expect(err).To(MatchError(ContainSubstring("the password store has no entry 'churchtools/other'")))
+	})
+
+	It("returns an error for an unknown token source", func() {
+		_, err := stores.Store("vault:foo")
+
+		Expect(err).To(MatchError("unknown token source 'vault', use keepass, env, file, encrypted or pass"))
+	})
+})
+
+var _ = Describe("ValidateTokenSource", func() {
+	It("accepts the token sources", func() {
+		for _, tokenSource := range []string{"", "keepass", "env", "file:tokens.yml", "encrypted:tokens.age", "pass", "pass:/tmp/store"} {
+			Expect(secret.ValidateTokenSource(tokenSource)).To(Succeed(), tokenSource)
+		}
+	})
+
+	It("returns an error for invalid token sources", func() {
+		Expect(secret.ValidateTokenSource("file")).To(MatchError("the path of the token file is not set, e.g. 'file:tokens.yml'"))
+		Expect(secret.ValidateTokenSource("env:FOO")).To(HaveOccurred())
+		Expect(secret.ValidateTokenSource("keepass:foo")).To(HaveOccurred())
+		Expect(secret.ValidateTokenSource("vault")).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("IsKeepass", func() {
+	It("returns true for the KeePass database", func() {
+		Expect(secret.IsKeepass("")).To(BeTrue())
+		Expect(secret.IsKeepass("keepass")).To(BeTrue())
+		Expect(secret.IsKeepass("env")).To(BeFalse())
+	})
+})
+
+func writeEncrypted(path string, content string, password string, armored bool) {
+	recipient, err := age.NewScryptRecipient(password)
+	Expect(err).NotTo(HaveOccurred())
+	recipient.SetWorkFactor(10)
+
+	var buffer bytes.Buffer
+	var target io.Writer = &buffer
+	var armorWriter io.WriteCloser
+	if armored {
+		armorWriter = armor.NewWriter(&buffer)
+		target = armorWriter
+	}
+	writer, err := age.Encrypt(target, recipient)
+	Expect(err).NotTo(HaveOccurred())
+	_, err = writer.Write([]byte(content))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(writer.Close()).To(Succeed())
+	if armored {
+		Expect(armorWriter.Close()).To(Succeed())
+	}
+	Expect(os.WriteFile(path, buffer.Bytes(), 0600)).To(Succeed())
+}
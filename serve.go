@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"ctRestClient/app"
+	"ctRestClient/cli"
+	"ctRestClient/config"
+	"ctRestClient/logger"
+	"ctRestClient/privacy"
+	"ctRestClient/scheduler"
+	"ctRestClient/secret"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func serveCommand() cli.Command {
+	var schedule bool
+	var processorOptions app.ProcessorOptions
+
+	return cli.Command{
+		Name: "serve",
+		Description: "Runs the exports at the cron schedules of the config until it is stopped.\n" +
+			"The secrets are unlocked once at the start, the config is reloaded when the file changes\n" +
+			"and exports never overlap. Each export writes its usual timestamped output directory.\n" +
+			"With -diff each group is compared with its previous export by the person id.",
+		SetFlags: func(flags *flag.FlagSet) {
+			flags.BoolVar(&schedule, "schedule", false, "run the exports at the schedules of the instances and groups")
+			flags.BoolVar(&processorOptions.Diff, "diff", false, "write the added, removed and changed persons since the previous export of each group")
+		},
+		Run: func(options cli.GlobalOptions, args []string) int {
+			if !schedule {
+				fmt.Println("serve requires -schedule, run 'ctRestClient help serve' for the options")
+				return cli.ExitUsage
+			}
+			return runServe(options, processorOptions)
+		},
+	}
+}
+
+func runServe(options cli.GlobalOptions, processorOptions app.ProcessorOptions) int {
+	serveLogger := logger.NewLogger("")
+	logGeneralInfo(serveLogger, getCurrentUserName(), getCurrentOSName(), getDate())
+
+	// The stores are kept for the life of the process, so the secrets are
+	// only unlocked once
+	stores, err := secretStores(options, serveLogger)
+	if err != nil {
+		serveLogger.Error(err.Error())
+		return cli.ExitError
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = scheduler.New(scheduler.Options{
+		ConfigFilePath: options.ConfigFilePath,
+		LoadConfig:     config.LoadConfig,
+		Prepare: func(cfg config.Config) error {
+			return unlockSecrets(cfg, stores, serveLogger)
+		},
+		Export: func(cfg config.Config) {
+			exportScheduled(options, processorOptions, cfg, stores, serveLogger)
+		},
+		Logger: serveLogger,
+	}).Run(ctx)
+	if err != nil {
+		serveLogger.Error(err.Error())
+		return cli.ExitError
+	}
+	serveLogger.Info("stopped")
+	return cli.ExitOK
+}
+
+// unlockSecrets opens the stores of the config and reads the secrets, so
+// that no password is asked for when an export runs unattended. A store that
+// cannot be opened, e.g. because of a wrong KeePass password, is an error. A
+// missing token is only logged, the export of its instance fails later.
+func unlockSecrets(cfg config.Config, stores secret.Stores, serveLogger logger.Logger) error {
+	type lookup struct{ tokenSource, name string }
+	var lookups []lookup
+	for _, instance := range cfg.Instances {
+		lookups = append(lookups, lookup{instance.TokenSource, instance.TokenName})
+	}
+	if cfg.UsesPrivacyRule(privacy.Hash) {
+		lookups = append(lookups, lookup{cfg.PrivacySecretSource, cfg.PrivacySecretName})
+	}
+	if cfg.Output.Encryption.IsSet() {
+		lookups = append(lookups, lookup{cfg.Output.Encryption.SecretSource, cfg.Output.Encryption.SecretName})
+	}
+
+	for _, lookup := range lookups {
+		store, err := stores.Store(lookup.tokenSource)
+		if err != nil {
+			return err
+		}
+		if _, err := store.Get(lookup.name); err != nil {
+			serveLogger.Warn(fmt.Sprintf("failed to get the secret with name '%s', %v", lookup.name, err))
+		}
+	}
+	return nil
+}
+
+// exportScheduled runs an export of the config like the export command. The
+// config only contains the due groups.
+func exportScheduled(options cli.GlobalOptions, processorOptions app.ProcessorOptions, cfg config.Config, stores secret.Stores, serveLogger logger.Logger) {
+	processorOptions.Partial = true
+	rootDir := runDir(options)
+	if err := os.MkdirAll(rootDir, 0755); err != nil {
+		serveLogger.Error(fmt.Sprintf("failed to create directory: %v", err))
+		return
+	}
+
+	appLogger, runLog := runLogger(rootDir, cfg.Output.Encryption.IsSet())
+	defer appLogger.Close()
+	logGeneralInfo(appLogger, getCurrentUserName(), getCurrentOSName(), getDate())
+
+	report, err := processInstances(options, processorOptions, cfg, stores, rootDir, appLogger, runLog)
+	if err != nil {
+		appLogger.Error(fmt.Sprintf("Failed to process instances: %v", err))
+		return
+	}
+	serveLogger.Info(fmt.Sprintf("the export into '%s' finished with status '%s'", rootDir, report.Status))
+}
@@ -0,0 +1,277 @@
+package validation
+
+import (
+	"bytes"
+	"ctRestClient/config"
+	"ctRestClient/data_provider"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+	"gopkg.in/yaml.v3"
+)
+
+// A Problem is an error found in the config, a mapping file or a blocklist.
+type Problem struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+	}
+	return fmt.Sprintf("%s: %s", p.File, p.Message)
+}
+
+// Validate checks the config file, the mapping files of all mapped fields
+// and all blocklists in the data directory without connecting to any
+// instance.
+func Validate(configFilePath string, dataDir string) []Problem {
+	problems, cfg, groupLines := validateConfig(configFilePath)
+	if cfg != nil {
+		problems = append(problems, validateMappingFiles(configFilePath, cfg, groupLines, filepath.Join(dataDir, "mappings", "persons"))...)
+		problems = append(problems, validateFileNames(configFilePath, cfg, groupLines)...)
+	}
+	problems = append(problems, validateBlocklists(filepath.Join(dataDir, "blocklists"))...)
+	return problems
+}
+
+var linePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// problemFromError creates a problem of a file, the line is taken from
+// error messages like 'line 5: ...'.
+func problemFromError(file string, err error) Problem {
+	message := err.Error()
+	if match := linePattern.FindStringSubmatch(message); match != nil {
+		line, _ := strconv.Atoi(match[1])
+		return Problem{File: file, Line: line, Message: match[2]}
+	}
+	return Problem{File: file, Message: message}
+}
+
+// groupKey identifies a group by the index of its instance and its index
+// within the instance.
+type groupKey struct {
+	instance int
+	group    int
+}
+
+func validateConfig(configFilePath string) ([]Problem, *config.Config, map[groupKey]int) {
+	data, err := os.ReadFile(configFilePath)
+	if err != nil {
+		return []Problem{{File: configFilePath, Message: err.Error()}}, nil, nil
+	}
+
+	var problems []Problem
+
+	var cfg config.Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil {
+		var typeError *yaml.TypeError
+		if !errors.As(err, &typeError) {
+			return []Problem{problemFromError(configFilePath, err)}, nil, nil
+		}
+		// Unknown keys are reported, the remaining config is still checked
+		for _, message := range typeError.Errors {
+			problems = append(problems, problemFromError(configFilePath, errors.New(message)))
+		}
+		// The type errors of fields are returned without KnownFields as
+		// well, they are already reported
+		cfg = config.Config{}
+		if err := yaml.Unmarshal(data, &cfg); err != nil && !errors.As(err, &typeError) {
+			return append(problems, problemFromError(configFilePath, err)), nil, nil
+		}
+	}
+
+	groupLines := findGroupLines(data)
+
+	groupProblems := 0
+	for i, instance := range cfg.Instances {
+		for j, group := range instance.Groups {
+			// A field with an unknown key is dropped while decoding, the
+			// group is only checked without such errors
+			if hasProblemInGroup(problems, groupLines, groupKey{i, j}) {
+				groupProblems++
+				continue
+			}
+			if err := group.Validate(); err != nil {
+				groupProblems++
+				problems = append(problems, Problem{File: configFilePath, Line: groupLines[groupKey{i, j}], Message: err.Error()})
+			}
+		}
+	}
+
+	// The errors of groups are already reported with their line
+	if err := cfg.Validate(); err != nil && groupProblems == 0 {
+		problems = append(problems, Problem{File: configFilePath, Message: err.Error()})
+	}
+
+	return problems, &cfg, groupLines
+}
+
+// hasProblemInGroup reports whether a problem is in the lines of the group,
+// from its first line up to the first line of the next group.
+func hasProblemInGroup(problems []Problem, groupLines map[groupKey]int, key groupKey) bool {
+	start, ok := groupLines[key]
+	if !ok {
+		return false
+	}
+	end := math.MaxInt
+	for _, line := range groupLines {
+		if line > start && line < end {
+			end = line
+		}
+	}
+	for _, problem := range problems {
+		if problem.Line >= start && problem.Line < end {
+			return true
+		}
+	}
+	return false
+}
+
+// findGroupLines returns the line of each group in the config file.
+func findGroupLines(data []byte) map[groupKey]int {
+	groupLines := make(map[groupKey]int)
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil || len(document.Content) == 0 {
+		return groupLines
+	}
+
+	instancesNode := mappingValue(document.Content[0], "instances")
+	if instancesNode == nil {
+		return groupLines
+	}
+	for i, instanceNode := range instancesNode.Content {
+		groupsNode := mappingValue(instanceNode, "groups")
+		if groupsNode == nil {
+			continue
+		}
+		for j, groupNode := range groupsNode.Content {
+			groupLines[groupKey{i, j}] = groupNode.Line
+		}
+	}
+	return groupLines
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func validateMappingFiles(configFilePath string, cfg *config.Config, groupLines map[groupKey]int, mappingsDir string) []Problem {
+	var problems []Problem
+	checkedFiles := make(map[string]bool)
+
+	for i, instance := range cfg.Instances {
+		for j, group := range instance.Groups {
+			for _, field := range group.Fields {
+				if !field.IsMappedData() || field.GetMapping() != nil {
+					continue
+				}
+
+				path, err := data_provider.FindMappingFile(mappingsDir, field.GetFieldName(), instance, group)
+				if err != nil {
+					problems = append(problems, Problem{
+						File:    configFilePath,
+						Line:    groupLines[groupKey{i, j}],
+						Message: fmt.Sprintf("field '%s' of group '%s' is mapped but %v", field.GetFieldName(), group.Name, err),
+					})
+					continue
+				}
+
+				if checkedFiles[path] {
+					continue
+				}
+				checkedFiles[path] = true
+				if _, err := data_provider.ReadMappingFile(path); err != nil {
+					problems = append(problems, problemFromError(path, err))
+				}
+			}
+		}
+	}
+
+	return problems
+}
+
+// validateFileNames finds groups that are exported to the same CSV file. The
+// paths are compared like on Windows and macOS, where case does not matter.
+// The IDs of the groups are only known online, groups with the same name
+// of an instance get the same ID.
+func validateFileNames(configFilePath string, cfg *config.Config, groupLines map[groupKey]int) []Problem {
+	var problems []Problem
+
+	groupsByPath := make(map[string]groupKey)
+	groupIDs := make(map[[2]string]int)
+	for i, instance := range cfg.Instances {
+		for j, group := range instance.Groups {
+			groupID, exists := groupIDs[[2]string{instance.Hostname, group.Name}]
+			if !exists {
+				groupID = len(groupIDs) + 1
+				groupIDs[[2]string{instance.Hostname, group.Name}] = groupID
+			}
+			path := cfg.Output.GroupPath(config.PathValues{Instance: instance, Group: group, GroupID: groupID, Time: time.Now()})
+			key := strings.ToLower(norm.NFC.String(path))
+			if first, exists := groupsByPath[key]; exists {
+				problems = append(problems, Problem{
+					File: configFilePath,
+					Line: groupLines[groupKey{i, j}],
+					Message: fmt.Sprintf("group '%s' has the same CSV file '%s' as group '%s' in line %d",
+						group.Name, path, cfg.Instances[first.instance].Groups[first.group].Name, groupLines[first]),
+				})
+				continue
+			}
+			groupsByPath[key] = groupKey{i, j}
+		}
+	}
+
+	return problems
+}
+
+func validateBlocklists(blocklistsDir string) []Problem {
+	var paths []string
+	err := filepath.WalkDir(blocklistsDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && filepath.Ext(path) == ".yml" {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return []Problem{{File: blocklistsDir, Message: err.Error()}}
+	}
+	sort.Strings(paths)
+
+	var problems []Problem
+	for _, path := range paths {
+		if _, err := data_provider.ReadBlocklistFile(path); err != nil {
+			problems = append(problems, problemFromError(path, err))
+		}
+	}
+	return problems
+}
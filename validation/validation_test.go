@@ -0,0 +1,235 @@
+package validation_test
+
+import (
+	"ctRestClient/testutil"
+	"ctRestClient/validation"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Validate", func() {
+	var (
+		tempDir        string
+		configFilePath string
+		dataDir        string
+	)
+
+	writeFile := func(path string, content string) {
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, testutil.YamlToByteArray(content), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "validation_test_")
+		Expect(err).ToNot(HaveOccurred())
+
+		configFilePath = filepath.Join(tempDir, "config.yml")
+		dataDir = filepath.Join(tempDir, "data")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	It("returns no problems for a valid setup", func() {
+		writeFile(configFilePath, `
+			---
+			instances:
+			- hostname: foo
+			  token_name: foo
+			  groups:
+			  - name: foo_group
+			    fields:
+			    - id
+			    - {fieldname: sexId, columnname: sex}
+			`)
+		writeFile(filepath.Join(dataDir, "mappings", "persons", "sexId.yml"), `
+			1: male
+			`)
+		writeFile(filepath.Join(dataDir, "blocklists", "_all.yml"), `
+			- zip: "12345"
+			`)
+
+		Expect(validation.Validate(configFilePath, dataDir)).To(BeEmpty())
+	})
+
+	It("returns unknown keys of the config with their line", func() {
+		writeFile(configFilePath, `
+			---
+			instances:
+			- hostname: foo
+			  token_name: foo
+			  groups:
+			  - name: foo_group
+			    filds: [id]
+			    fields: [id]
+			`)
+
+		problems := validation.Validate(configFilePath, dataDir)
+		Expect(problems).To(HaveLen(1))
+		Expect(problems[0].Line).To(Equal(7))
+		Expect(problems[0].String()).To(Equal(configFilePath + ":7: field filds not found in type config.Group"))
+	})
+
+	It("returns unknown keys of fields and of later groups with their line", func() {
+		writeFile(configFilePath, `
+			---
+			instances:
+			- hostname: foo
+			  token_name: foo
+			  groups:
+			  - name: foo_group
+			    fields:
+			    - fieldname: sexId
+			      colunmname: Geschlecht
+			  - name: bar_group
+			    filds: [id]
+			    fields: [id]
+			`)
+
+		problems := validation.Validate(configFilePath, dataDir)
+		Expect(problems).To(HaveLen(2))
+		Expect(problems[0].String()).To(Equal(configFilePath + ":9: field colunmname not found in type config.FieldInformation"))
+		Expect(problems[1].String()).To(Equal(configFilePath + ":11: field filds not found in type config.Group"))
+	})
+
+	It("returns invalid groups with their line", func() {
+		writeFile(configFilePath, `
+			---
+			instances:
+			- hostname: foo
+			  token_name: foo
+			  groups:
+			  - name: foo_group
+			    fields: [id]
+			  - name: bar_group
+			    filter: "age(birthday) >"
+			    fields: [id]
+			`)
+
+		problems := validation.Validate(configFilePath, dataDir)
+		Expect(problems).To(Equal([]validation.Problem{{
+			File:    configFilePath,
+			Line:    8,
+			Message: "property filter of group 'bar_group' is invalid, unexpected end of expression at position 16",
+		}}))
+	})
+
+	It("returns mapped fields without mapping file", func() {
+		writeFile(configFilePath, `
+			---
+			instances:
+			- hostname: foo
+			  token_name: foo
+			  groups:
+			  - name: foo_group
+			    fields:
+			    - {fieldname: sexId, columnname: sex}
+			    - {fieldname: statusId, columnname: status, mapping: {1: member}}
+			`)
+
+		problems := validation.Validate(configFilePath, dataDir)
+		Expect(problems).To(HaveLen(1))
+		Expect(problems[0].Line).To(Equal(6))
+		Expect(problems[0].Message).To(ContainSubstring("field 'sexId' of group 'foo_group' is mapped but the mapping file"))
+	})
+
+	It("returns invalid mapping files", func() {
+		writeFile(configFilePath, `
+			---
+			instances:
+			- hostname: foo
+			  token_name: foo
+			  groups:
+			  - name: foo_group
+			    fields:
+			    - {fieldname: sexId, columnname: sex}
+			`)
+		mappingFilePath := filepath.Join(dataDir, "mappings", "persons", "sexId.csv")
+		writeFile(mappingFilePath, "value;name\n1;male\n1;female\n")
+
+		problems := validation.Validate(configFilePath, dataDir)
+		Expect(problems).To(HaveLen(1))
+		Expect(problems[0].File).To(Equal(mappingFilePath))
+		Expect(problems[0].Message).To(ContainSubstring("contains the duplicate value '1'"))
+	})
+
+	It("returns groups with colliding CSV file names", func() {
+		writeFile(configFilePath, `
+			---
+			instances:
+			- hostname: foo
+			  token_name: foo
+			  groups:
+			  - name: Youth Group
+			    fields: [id]
+			  - name: Youth_Group
+			    fields: [id]
+			`)
+
+		problems := validation.Validate(configFilePath, dataDir)
+		Expect(problems).To(HaveLen(1))
+		Expect(problems[0].String()).To(Equal(configFilePath + ":8: group 'Youth_Group' has the same CSV file '" + filepath.Join("foo", "Youth_Group.csv") + "' as group 'Youth Group' in line 6"))
+	})
+
+	It("returns groups of different instances with the same output path", func() {
+		writeFile(configFilePath, `
+			---
+			instances:
+			- hostname: foo
+			  alias: choirs
+			  token_name: foo
+			  groups:
+			  - name: Choir
+			    fields: [id]
+			- hostname: bar
+			  alias: Choirs
+			  token_name: bar
+			  groups:
+			  - name: choir
+			    fields: [id]
+			  - name: Choir Ü
+			    fields: [id]
+			output:
+			  path: "{instance}/{group}.{ext}"
+			`)
+
+		problems := validation.Validate(configFilePath, dataDir)
+		Expect(problems).To(HaveLen(1))
+		Expect(problems[0].String()).To(Equal(configFilePath + ":13: group 'choir' has the same CSV file '" + filepath.Join("Choirs", "choir.csv") + "' as group 'Choir' in line 7"))
+	})
+
+	It("returns blocklist entries with duplicate keys and invalid values", func() {
+		writeFile(configFilePath, `
+			---
+			instances:
+			- hostname: foo
+			  token_name: foo
+			  groups:
+			  - name: foo_group
+			    fields: [id]
+			`)
+		duplicatePath := filepath.Join(dataDir, "blocklists", "foo_group.yml")
+		writeFile(duplicatePath, `
+			- zip: "12345"
+			- zip: "12345"
+			  zip: "54321"
+			`)
+		invalidPath := filepath.Join(dataDir, "blocklists", "foo", "_all.yml")
+		writeFile(invalidPath, `
+			- match: regex
+			  fields:
+			    city: "(Berlin"
+			`)
+
+		problems := validation.Validate(configFilePath, dataDir)
+		Expect(problems).To(HaveLen(2))
+		Expect(problems[0].File).To(Equal(invalidPath))
+		Expect(problems[0].Line).To(Equal(3))
+		Expect(problems[1]).To(Equal(validation.Problem{File: duplicatePath, Line: 3, Message: "duplicate key 'zip'"}))
+	})
+})